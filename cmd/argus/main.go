@@ -2,20 +2,29 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
+	"github.com/charmbracelet/x/term"
 	"github.com/lbarahona/argus/internal/ai"
 	"github.com/lbarahona/argus/internal/alert"
+	"github.com/lbarahona/argus/internal/cite"
 	"github.com/lbarahona/argus/internal/config"
 	"github.com/lbarahona/argus/internal/diff"
 	"github.com/lbarahona/argus/internal/explain"
 	"github.com/lbarahona/argus/internal/output"
+	"github.com/lbarahona/argus/internal/ownership"
+	"github.com/lbarahona/argus/internal/prompt"
 	"github.com/lbarahona/argus/internal/report"
+	"github.com/lbarahona/argus/internal/session"
 	"github.com/lbarahona/argus/internal/signoz"
 	"github.com/lbarahona/argus/internal/slo"
 	topkg "github.com/lbarahona/argus/internal/top"
+	"github.com/lbarahona/argus/internal/topology"
 	"github.com/lbarahona/argus/internal/tui"
 	"github.com/lbarahona/argus/internal/watch"
 	"github.com/lbarahona/argus/pkg/types"
@@ -30,12 +39,192 @@ var (
 	date    = "unknown"
 )
 
+// aiProfileName, showPrompt and noCache are the --ai-profile, --show-prompt
+// and --no-cache flags shared by every command that calls a model.
+var (
+	aiProfileName string
+	showPrompt    bool
+	noCache       bool
+)
+
+// recordPath is the --record flag; recorder is set while a session is being
+// recorded to it, and commandLine is what the bundle says was run.
+var (
+	recordPath  string
+	recorder    *session.Recorder
+	commandLine string
+)
+
+// exitError ends a command with a non-zero exit code once its output has been
+// printed, such as a failed deploy gate. It is not reported as an error.
+type exitError struct{ code int }
+
+func (e exitError) Error() string { return fmt.Sprintf("exit status %d", e.code) }
+
+// exitWith returns an exitError for code, or nil for 0.
+func exitWith(cmd *cobra.Command, code int) error {
+	if code == 0 {
+		return nil
+	}
+	cmd.SilenceErrors, cmd.SilenceUsage = true, true
+	return exitError{code}
+}
+
+// errNoAI is returned by commands that can't work without a model.
+var errNoAI = fmt.Errorf("no AI provider configured. Run: argus config init, or add an ai_profiles entry to %s", config.Path())
+
+// analyzerFor returns an analyzer for the --ai-profile profile, or nil when
+// that profile has no credentials configured.
+func analyzerFor(cfg *types.Config) (*ai.Analyzer, error) {
+	profile, err := resolveAIProfile(cfg)
+	if err != nil || !profile.Ready() {
+		return nil, err
+	}
+	return ai.FromProfile(profile)
+}
+
+// resolveAIProfile returns the --ai-profile profile with the --show-prompt,
+// --no-cache and --record settings applied.
+func resolveAIProfile(cfg *types.Config) (types.AIProfile, error) {
+	profile, err := cfg.ResolveAIProfile(aiProfileName)
+	profile.ShowPrompt = showPrompt
+	profile.CacheDir = ai.CacheDir()
+	profile.NoCache = noCache
+	if recorder != nil {
+		profile.Recording = &recorder.AI
+	}
+	return profile, err
+}
+
+// recordData adds what a command collected to the session being recorded,
+// if any.
+func recordData(v interface{}) {
+	if recorder == nil {
+		return
+	}
+	if err := recorder.SetData(v); err != nil {
+		fmt.Fprintln(os.Stderr, output.WarningStyle.Render("⚠️  "+err.Error()))
+	}
+}
+
+// promptBudget returns the prompt budget of the --ai-profile profile, 0 for
+// the default.
+func promptBudget(cfg *types.Config) int {
+	profile, _ := resolveAIProfile(cfg)
+	return profile.PromptBudget
+}
+
+// printTrimmed tells the user what was left out of a prompt to fit its
+// budget.
+func printTrimmed(p prompt.Result) {
+	if p.Trimmed() {
+		fmt.Fprintln(os.Stderr, output.MutedStyle.Render("✂️  Prompt trimmed to fit: "+p.Summary()))
+	}
+}
+
+// analyze streams the answer to prompt to stdout, with the logs and traces
+// it cites linked to Signoz, followed by the tokens it used. Ctrl+C stops the
+// answer without an error.
+func analyze(ctx context.Context, analyzer *ai.Analyzer, prompt string, refs *cite.Refs) error {
+	w := refs.NewWriter(os.Stdout)
+	err := analyzer.Analyze(ctx, prompt, w)
+	w.Flush()
+	if ctx.Err() != nil {
+		fmt.Println(output.MutedStyle.Render("\nCancelled."))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if usage := analyzer.Usage(); !usage.Empty() {
+		fmt.Fprintln(os.Stderr, output.MutedStyle.Render("🪙 "+usage.String()))
+	}
+	return nil
+}
+
+// assess asks for a structured answer to prompt and prints it to stdout as
+// JSON (--format json), with evidence linked to Signoz. Token usage goes to
+// stderr so stdout stays parseable.
+func assess(ctx context.Context, analyzer *ai.Analyzer, prompt string, refs *cite.Refs) error {
+	assessment, err := analyzer.Assess(ctx, prompt)
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, output.MutedStyle.Render("Cancelled."))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	refs.LinkAssessment(assessment)
+	if usage := analyzer.Usage(); !usage.Empty() {
+		fmt.Fprintln(os.Stderr, output.MutedStyle.Render("🪙 "+usage.String()))
+	}
+	return printJSON(assessment)
+}
+
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// checkAnswerFormat validates the --format flag of AI commands.
+func checkAnswerFormat(format string) error {
+	switch format {
+	case "text", "json":
+		return nil
+	}
+	return fmt.Errorf("unknown --format %q (want text or json)", format)
+}
+
 func main() {
+	os.Exit(run(newRootCmd(), os.Args[1:]))
+}
+
+// run executes args and returns the exit code, writing the --record bundle
+// first.
+func run(rootCmd *cobra.Command, args []string) int {
+	rootCmd.SetArgs(args)
+	commandLine = strings.Join(args, " ")
+	err := rootCmd.Execute()
+	if recorder != nil {
+		if rerr := recorder.Finish(recordPath, err); rerr != nil {
+			fmt.Fprintln(os.Stderr, output.WarningStyle.Render("⚠️  "+rerr.Error()))
+		} else {
+			fmt.Fprintln(os.Stderr, output.MutedStyle.Render("📼 Session recorded to "+recordPath))
+		}
+		recorder = nil
+	}
+	var exit exitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exit):
+		return exit.code
+	}
+	return 1
+}
+
+func newRootCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "argus",
 		Short: "AI-powered observability CLI for SREs",
 		Long:  "Argus connects to Signoz instances and uses Anthropic AI to analyze logs, metrics, and traces with natural language queries.",
 	}
+	rootCmd.PersistentFlags().StringVar(&aiProfileName, "ai-profile", "", "AI profile from ai_profiles in config (default: default_ai_profile)")
+	rootCmd.PersistentFlags().BoolVar(&showPrompt, "show-prompt", false, "Print every prompt sent to the AI provider, after redaction, to stderr")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Ask the AI provider even when an identical prompt was answered recently")
+	rootCmd.PersistentFlags().StringVar(&recordPath, "record", "", "Record the session (collected data, prompts, answers and output) to a bundle file for argus replay")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if recordPath == "" {
+			return nil
+		}
+		var err error
+		recorder, err = session.Start(commandLine)
+		return err
+	}
 
 	rootCmd.AddCommand(
 		versionCmd(),
@@ -54,13 +243,13 @@ func main() {
 		watchCmd(),
 		alertCmd(),
 		explainCmd(),
+		mapCmd(),
 		sloCmd(),
 		tuiCmd(),
+		replayCmd(),
 	)
 
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
-	}
+	return rootCmd
 }
 
 func versionCmd() *cobra.Command {
@@ -174,11 +363,17 @@ func logsCmd() *cobra.Command {
 	var duration int
 	var limit int
 	var severity string
+	var patterns bool
 
 	cmd := &cobra.Command{
 		Use:   "logs [service]",
 		Short: "Query and analyze logs",
-		Long:  "Query logs from Signoz and optionally analyze them with AI.",
+		Long: `Query logs from Signoz and optionally analyze them with AI.
+
+With --patterns, similar messages are clustered into templates (variable parts
+shown as <*>, <n>, <id>, <ip>, <uuid>) and printed with their counts and sample
+values. Raise --limit to mine a larger sample.`,
+		Example: `  argus logs api --severity ERROR --patterns --limit 1000`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg, err := config.Load()
 			if err != nil {
@@ -196,7 +391,8 @@ func logsCmd() *cobra.Command {
 			}
 
 			client := signoz.New(*inst)
-			ctx := context.Background()
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
 
 			fmt.Printf("%s Querying logs from %s...\n", output.MutedStyle.Render("⏳"), output.AccentStyle.Render(instKey))
 
@@ -206,19 +402,32 @@ func logsCmd() *cobra.Command {
 			}
 
 			// If we have a query, send to AI for analysis
-			if query != "" && cfg.AnthropicKey != "" {
+			var analyzer *ai.Analyzer
+			if query != "" {
+				if analyzer, err = analyzerFor(cfg); err != nil {
+					return err
+				}
+			}
+			if analyzer != nil {
 				output.PrintAnalyzing(query)
 
+				refs := cite.New(inst.URL)
 				dataContext := result.Raw
-				if len(result.Logs) > 0 {
-					dataContext = formatLogsForAI(result.Logs)
+				if patterns && len(result.Logs) > 0 {
+					dataContext = formatPatternsForAI(report.GroupPatterns(result.Logs))
+				} else if len(result.Logs) > 0 {
+					dataContext = formatLogsForAI(result.Logs, refs)
 				}
 
-				prompt := fmt.Sprintf("User query: %s\n\nObservability data from Signoz instance %q:\n%s",
-					query, instKey, dataContext)
+				prompt := fmt.Sprintf("User query: %s\n\nObservability data from Signoz instance %q:\n%s%s",
+					query, instKey, dataContext, refs.Instruction())
+
+				return analyze(ctx, analyzer, prompt, refs)
+			}
 
-				analyzer := ai.New(cfg.AnthropicKey)
-				return analyzer.Analyze(prompt, os.Stdout)
+			if patterns {
+				printLogPatterns(report.GroupPatterns(result.Logs), len(result.Logs))
+				return nil
 			}
 
 			// Print formatted logs
@@ -232,10 +441,47 @@ func logsCmd() *cobra.Command {
 	cmd.Flags().IntVarP(&duration, "duration", "d", 60, "Duration in minutes to look back")
 	cmd.Flags().IntVarP(&limit, "limit", "l", 100, "Maximum number of log entries")
 	cmd.Flags().StringVarP(&severity, "severity", "s", "", "Filter by severity (ERROR, WARN, INFO, DEBUG)")
+	cmd.Flags().BoolVar(&patterns, "patterns", false, "Group logs into message templates with counts")
 
 	return cmd
 }
 
+// printLogPatterns shows mined log templates, most frequent first, with the
+// most common values of each variable.
+func printLogPatterns(patterns []report.ErrorPattern, total int) {
+	if len(patterns) == 0 {
+		fmt.Println(output.MutedStyle.Render("  No logs found."))
+		return
+	}
+
+	fmt.Println(output.TitleStyle.Render(fmt.Sprintf("🧩 Log Patterns (%d templates from %d entries)", len(patterns), total)))
+	fmt.Println()
+
+	for _, p := range patterns {
+		pct := float64(p.Count) / float64(total) * 100
+		fmt.Printf("  %s %s %s\n",
+			output.AccentStyle.Render(fmt.Sprintf("%6dx", p.Count)),
+			output.MutedStyle.Render(fmt.Sprintf("%5.1f%%", pct)),
+			output.AccentStyle.Render("["+p.Service+"]"))
+		fmt.Printf("         %s\n", p.Pattern)
+		for _, param := range p.Params {
+			var vals []string
+			for i, v := range param.Values {
+				if i == 3 {
+					break
+				}
+				vals = append(vals, fmt.Sprintf("%s (%d)", v.Value, v.Count))
+			}
+			line := strings.Join(vals, ", ")
+			if param.Distinct > len(vals) {
+				line += fmt.Sprintf(", … %d distinct", param.Distinct)
+			}
+			fmt.Printf("         %s %s\n", output.MutedStyle.Render(fmt.Sprintf("#%d:", param.Position+1)), output.MutedStyle.Render(line))
+		}
+	}
+	fmt.Println()
+}
+
 func servicesCmd() *cobra.Command {
 	var instance string
 
@@ -301,7 +547,8 @@ func tracesCmd() *cobra.Command {
 			}
 
 			client := signoz.New(*inst)
-			ctx := context.Background()
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
 
 			fmt.Printf("%s Querying traces from %s...\n", output.MutedStyle.Render("⏳"), output.AccentStyle.Render(instKey))
 
@@ -311,14 +558,25 @@ func tracesCmd() *cobra.Command {
 			}
 
 			// If we have a query, send to AI
-			if query != "" && cfg.AnthropicKey != "" {
+			var analyzer *ai.Analyzer
+			if query != "" {
+				if analyzer, err = analyzerFor(cfg); err != nil {
+					return err
+				}
+			}
+			if analyzer != nil {
 				output.PrintAnalyzing(query)
 
-				prompt := fmt.Sprintf("User query: %s\n\nTrace data from Signoz instance %q:\n%s",
-					query, instKey, result.Raw)
+				refs := cite.New(inst.URL)
+				dataContext := result.Raw
+				if len(result.Traces) > 0 {
+					dataContext = formatTracesForAI(result.Traces, refs)
+				}
 
-				analyzer := ai.New(cfg.AnthropicKey)
-				return analyzer.Analyze(prompt, os.Stdout)
+				prompt := fmt.Sprintf("User query: %s\n\nTrace data from Signoz instance %q:\n%s%s",
+					query, instKey, dataContext, refs.Instruction())
+
+				return analyze(ctx, analyzer, prompt, refs)
 			}
 
 			output.PrintTraces(result.Traces)
@@ -361,7 +619,8 @@ func metricsCmd() *cobra.Command {
 			}
 
 			client := signoz.New(*inst)
-			ctx := context.Background()
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
 
 			fmt.Printf("%s Querying metrics from %s...\n", output.MutedStyle.Render("⏳"), output.AccentStyle.Render(instKey))
 
@@ -370,14 +629,19 @@ func metricsCmd() *cobra.Command {
 				return fmt.Errorf("querying metrics: %w", err)
 			}
 
-			if query != "" && cfg.AnthropicKey != "" {
+			var analyzer *ai.Analyzer
+			if query != "" {
+				if analyzer, err = analyzerFor(cfg); err != nil {
+					return err
+				}
+			}
+			if analyzer != nil {
 				output.PrintAnalyzing(query)
 
 				prompt := fmt.Sprintf("User query: %s\n\nMetric data from Signoz instance %q:\n%s",
 					query, instKey, result.Raw)
 
-				analyzer := ai.New(cfg.AnthropicKey)
-				return analyzer.Analyze(prompt, os.Stdout)
+				return analyze(ctx, analyzer, prompt, nil)
 			}
 
 			output.PrintMetrics(result.Metrics)
@@ -456,67 +720,93 @@ func dashboardCmd() *cobra.Command {
 
 func askCmd() *cobra.Command {
 	var instance string
+	var format string
 
 	cmd := &cobra.Command{
 		Use:   "ask [question]",
 		Short: "Ask a free-form question about your infrastructure",
-		Long:  "Use AI to analyze your observability data and answer questions about your infrastructure.",
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Use AI to analyze your observability data and answer questions about your infrastructure.
+
+--format json prints a structured answer instead: severity, suspected root
+causes with confidence, affected services, evidence (log timestamps and trace
+IDs) and recommended actions, validated against a schema.`,
+		Example: `  argus ask "why is checkout slow?"
+  argus ask --format json "what is failing?" | jq .severity`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkAnswerFormat(format); err != nil {
+				return err
+			}
 			cfg, err := config.Load()
 			if err != nil {
 				return err
 			}
 
-			if cfg.AnthropicKey == "" {
-				return fmt.Errorf("Anthropic API key not configured. Run: argus config init")
+			analyzer, err := analyzerFor(cfg)
+			if err != nil {
+				return err
+			}
+			if analyzer == nil {
+				return errNoAI
 			}
 
 			question := strings.Join(args, " ")
-			output.PrintAnalyzing(question)
+			if format == "text" {
+				output.PrintAnalyzing(question)
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
 
-			// Gather context from Signoz
+			// Gather context from Signoz, recent errors first when the
+			// prompt budget runs out
+			b := prompt.New(promptBudget(cfg))
+			b.Text(question + "\n")
 			inst, instKey, _ := config.GetInstance(cfg, instance)
-			contextInfo := ""
+			var refs *cite.Refs
 			if inst != nil {
 				client := signoz.New(*inst)
-				ctx := context.Background()
+				refs = cite.New(inst.URL)
+				found := false
 
 				// Try to get services for context
 				if services, err := client.ListServices(ctx); err == nil && len(services) > 0 {
-					contextInfo += fmt.Sprintf("\n\nServices in %s:\n", instKey)
+					section := b.Section(fmt.Sprintf("Services in %s:", instKey), prompt.Normal)
 					for _, svc := range services {
-						contextInfo += fmt.Sprintf("- %s (calls: %d, errors: %d, error rate: %.1f%%)\n",
+						section.Addf("- %s (calls: %d, errors: %d, error rate: %.1f%%)",
 							svc.Name, svc.NumCalls, svc.NumErrors, svc.ErrorRate)
 					}
+					found = true
 				}
 
 				// Try to get recent error logs
 				if result, err := client.QueryLogs(ctx, "", 30, 20, "ERROR"); err == nil && len(result.Logs) > 0 {
-					contextInfo += "\nRecent errors:\n"
+					section := b.Section("Recent errors:", prompt.High)
 					for _, log := range result.Logs {
-						body := log.Body
-						if len(body) > 200 {
-							body = body[:200]
-						}
-						contextInfo += fmt.Sprintf("- [%s] %s: %s\n",
-							log.Timestamp.Format("15:04:05"), log.ServiceName, body)
+						section.AddKeyed(log.ServiceName+"\x00"+log.Body, fmt.Sprintf("- %s[%s] %s: %s",
+							refs.Log(log), log.Timestamp.Format("15:04:05"), log.ServiceName, log.Body))
 					}
+					found = true
 				}
 
-				if contextInfo == "" {
-					contextInfo = fmt.Sprintf("\n\nConnected Signoz instance: %s (%s)", instKey, inst.URL)
+				if !found {
+					b.Textf("\nConnected Signoz instance: %s (%s)", instKey, inst.URL)
 				}
 			}
 
-			prompt := question + contextInfo
+			b.Text(refs.Instruction())
 
-			analyzer := ai.New(cfg.AnthropicKey)
-			return analyzer.Analyze(prompt, os.Stdout)
+			p := b.Build()
+			printTrimmed(p)
+			if format == "json" {
+				return assess(ctx, analyzer, p.Text, refs)
+			}
+			return analyze(ctx, analyzer, p.Text, refs)
 		},
 	}
 
 	cmd.Flags().StringVarP(&instance, "instance", "i", "", "Signoz instance for context")
+	cmd.Flags().StringVarP(&format, "format", "f", "text", "Answer format: text or json")
 
 	return cmd
 }
@@ -526,12 +816,37 @@ func reportCmd() *cobra.Command {
 	var duration int
 	var withAI bool
 	var format string
+	var noHistory bool
+	var team string
 
 	cmd := &cobra.Command{
 		Use:   "report",
 		Short: "Generate a health report for shift handoffs",
-		Long:  "Compile a comprehensive health report including service status, error patterns, and optional AI summary. Perfect for shift handoffs and incident reviews.",
+		Long: `Compile a comprehensive health report including service status, error patterns, and optional AI summary. Perfect for shift handoffs and incident reviews.
+
+Formats:
+  terminal  colored summary (default)
+  markdown  for wikis and tickets
+  html      a single self-contained page with inline CSS and SVG charts, for email and archiving
+  json      a stable, versioned schema (schema_version) for archiving and diffing
+
+Every report is saved under ~/.argus/history/reports and the next one gets a
+"Changes since last report" section: services whose error rate moved, new and
+resolved error patterns, SLO status changes (~/.argus/slos.yaml) and alert
+rules (~/.argus/alerts.yaml) that started or stopped firing.
+
+--team scopes the report to the services a team owns (teams: in
+~/.argus/config.yaml) and prints its escalation contact and runbook.`,
+		Example: `  argus report --format html > handoff.html
+  argus report --format json --ai > reports/$(date +%F).json
+  argus report --team payments`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			switch format {
+			case "terminal", "markdown", "html", "json":
+			default:
+				return fmt.Errorf("unknown --format %q (want terminal, markdown, html or json)", format)
+			}
+
 			cfg, err := config.Load()
 			if err != nil {
 				return err
@@ -542,23 +857,49 @@ func reportCmd() *cobra.Command {
 				return err
 			}
 
+			owners, err := ownership.FromConfig(cfg)
+			if err != nil {
+				return err
+			}
+
+			var profile types.AIProfile
+			if withAI {
+				if profile, err = resolveAIProfile(cfg); err != nil {
+					return err
+				}
+			}
+
 			client := signoz.New(*inst)
-			ctx := context.Background()
-			fmt.Printf("%s Generating health report...\n", output.MutedStyle.Render("⏳"))
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+			// Progress goes to stderr so the report itself can be redirected.
+			fmt.Fprintf(os.Stderr, "%s Generating health report...\n", output.MutedStyle.Render("⏳"))
 
+			slos, rules := report.LoadChecks()
 			r, err := report.Generate(ctx, client, instKey, report.Options{
-				Duration:     duration,
-				WithAI:       withAI,
-				Format:       format,
-				AnthropicKey: cfg.AnthropicKey,
+				Duration:   duration,
+				WithAI:     withAI,
+				Format:     format,
+				AI:         profile,
+				SLOs:       slos,
+				AlertRules: rules,
+				Persist:    !noHistory,
+				Team:       team,
+				Owners:     owners,
 			})
 			if err != nil {
 				return err
 			}
+			recordData(r.JSON())
 
-			if format == "markdown" {
+			switch format {
+			case "markdown":
 				r.RenderMarkdown(os.Stdout)
-			} else {
+			case "html":
+				return r.RenderHTML(os.Stdout)
+			case "json":
+				return r.RenderJSON(os.Stdout)
+			default:
 				r.RenderTerminal(os.Stdout)
 			}
 			return nil
@@ -567,8 +908,97 @@ func reportCmd() *cobra.Command {
 
 	cmd.Flags().StringVarP(&instance, "instance", "i", "", "Signoz instance to report on")
 	cmd.Flags().IntVarP(&duration, "duration", "d", 60, "Duration in minutes to cover")
-	cmd.Flags().BoolVar(&withAI, "ai", false, "Include AI-generated summary (uses Anthropic API)")
-	cmd.Flags().StringVarP(&format, "format", "f", "terminal", "Output format: terminal or markdown")
+	cmd.Flags().BoolVar(&withAI, "ai", false, "Include an AI summary (a structured assessment with --format json)")
+	cmd.Flags().StringVarP(&format, "format", "f", "terminal", "Output format: terminal, markdown, html or json")
+	cmd.Flags().BoolVar(&noHistory, "no-history", false, "Don't save this report or compare it with the previous one")
+	cmd.Flags().StringVarP(&team, "team", "t", "", "Only cover services owned by this team")
+
+	cmd.AddCommand(reportScheduleCmd())
+
+	return cmd
+}
+
+func reportScheduleCmd() *cobra.Command {
+	var list bool
+	var now bool
+	var only string
+
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Generate and deliver reports on the schedules in config",
+		Long: `Run the report schedules defined under reports.schedules in ~/.argus/config.yaml.
+
+Each schedule has a cron expression, an optional instance, format (html by
+default) and delivery targets. Each report covers the time since the previous
+report of the same schedule, so consecutive reports tile without gaps or
+overlap. Reports are written to reports.output_dir (~/.argus/reports by
+default), keeping the newest reports.keep (30) per schedule.
+
+  reports:
+    keep: 60
+    schedules:
+      - name: shift-handoff
+        cron: "0 7,19 * * *"
+        timezone: Europe/Madrid
+        format: html
+        ai: true
+        deliver: [smtp, slack]
+    smtp:
+      host: smtp.example.com
+      username: argus
+      password: secret
+      from: argus@example.com
+      to: [oncall@example.com]
+    slack:
+      token: xoxb-...      # bot token with files:write, uploads the file
+      channel: C0123456
+      # webhook_url: ...   # alternatively, post a summary to an incoming webhook`,
+		Example: `  argus report schedule            # run in the foreground until interrupted
+  argus report schedule --list     # show when each schedule fires next
+  argus report schedule --now --only shift-handoff`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+
+			connect := func(instance string) (signoz.SignozQuerier, string, error) {
+				inst, instKey, err := config.GetInstance(cfg, instance)
+				if err != nil {
+					return nil, "", err
+				}
+				return signoz.New(*inst), instKey, nil
+			}
+			sched, err := report.NewScheduler(cfg, connect, os.Stdout)
+			if err != nil {
+				return err
+			}
+
+			if list {
+				for _, u := range sched.Upcoming() {
+					fmt.Printf("  %-24s %-18s next: %s\n", u.Name, u.Cron, u.Next.Format("2006-01-02 15:04 MST"))
+				}
+				return nil
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+
+			if now {
+				return sched.RunNow(ctx, only)
+			}
+
+			fmt.Printf("%s Report scheduler running (Ctrl+C to stop)\n", output.MutedStyle.Render("⏰"))
+			for _, u := range sched.Upcoming() {
+				fmt.Printf("  %-24s next: %s\n", u.Name, u.Next.Format("2006-01-02 15:04 MST"))
+			}
+			return sched.Run(ctx)
+		},
+	}
+
+	cmd.Flags().BoolVar(&list, "list", false, "List schedules and their next run, then exit")
+	cmd.Flags().BoolVar(&now, "now", false, "Generate reports immediately instead of waiting for the schedule")
+	cmd.Flags().StringVar(&only, "only", "", "With --now, run only the named schedule")
 
 	return cmd
 }
@@ -578,6 +1008,7 @@ func topCmd() *cobra.Command {
 	var limit int
 	var sortBy string
 	var duration int
+	var team string
 
 	cmd := &cobra.Command{
 		Use:   "top",
@@ -608,6 +1039,14 @@ func topCmd() *cobra.Command {
 				return err
 			}
 
+			owners, err := ownership.FromConfig(cfg)
+			if err != nil {
+				return err
+			}
+			if _, ok := owners.Team(team); team != "" && !ok {
+				return fmt.Errorf("unknown team %q (teams are defined under teams: in config)", team)
+			}
+
 			client := signoz.New(*inst)
 			ctx := context.Background()
 			fmt.Printf("%s Fetching service data...\n", output.MutedStyle.Render("⏳"))
@@ -616,6 +1055,8 @@ func topCmd() *cobra.Command {
 				Limit:    limit,
 				SortBy:   sf,
 				Duration: duration,
+				Owners:   owners,
+				Team:     team,
 			})
 			if err != nil {
 				return err
@@ -630,6 +1071,7 @@ func topCmd() *cobra.Command {
 	cmd.Flags().IntVarP(&limit, "limit", "l", 20, "Number of services to show")
 	cmd.Flags().StringVarP(&sortBy, "sort", "s", "errors", "Sort by: errors, rate, calls, name")
 	cmd.Flags().IntVarP(&duration, "duration", "d", 60, "Duration in minutes for recent error lookup")
+	cmd.Flags().StringVarP(&team, "team", "t", "", "Only show services owned by this team")
 
 	return cmd
 }
@@ -637,11 +1079,32 @@ func topCmd() *cobra.Command {
 func diffCmd() *cobra.Command {
 	var instance string
 	var duration int
+	var around string
+	var window time.Duration
+	var maxRateIncrease, maxP99Increase, maxThroughputDrop float64
+	var failOnNewPatterns bool
+	var confidence float64
+	var minCalls, minErrors int
 
 	cmd := &cobra.Command{
 		Use:   "diff",
 		Short: "Compare error rates between two time windows",
-		Long:  "Compare the current time window against the previous window to detect anomalies. Shows which services are degrading, improving, or stable.",
+		Long: `Compare the current time window against the previous window to detect anomalies. Shows which services are degrading, improving, or stable, and which error log patterns are new, gone, or grew sharply.
+
+With --around, compares equal-length windows before and after a point in time
+(e.g. a deploy), including latency percentiles, throughput and new error log
+patterns. Exits with code 2 when a regression exceeds its threshold, so it can
+gate a CD pipeline.
+
+Changes are only reported as degraded/improved when they are statistically
+significant at --confidence (two-proportion z-test on error rates, or a Poisson
+rate test when only error counts are available). Services with fewer than
+--min-calls calls or --min-errors errors are shown as "not enough data".`,
+		Example: `  argus diff
+  argus diff -d 30
+  argus diff --around 14:32 --window 30m
+  argus diff --around 2026-03-01T14:32:00Z --max-p99-increase 10
+  argus diff mark deploy-1234 && argus diff --around deploy-1234`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg, err := config.Load()
 			if err != nil {
@@ -657,28 +1120,101 @@ func diffCmd() *cobra.Command {
 			ctx := context.Background()
 			fmt.Printf("%s Comparing time windows...\n", output.MutedStyle.Render("⏳"))
 
-			result, err := diff.Compare(ctx, client, instKey, diff.Options{
-				Duration: duration,
+			if around == "" {
+				result, err := diff.Compare(ctx, client, instKey, diff.Options{
+					Duration:   duration,
+					Confidence: confidence,
+					MinCalls:   minCalls,
+					MinErrors:  minErrors,
+				})
+				if err != nil {
+					return err
+				}
+
+				result.RenderTerminal(os.Stdout)
+				return nil
+			}
+
+			at, err := diff.ParseAround(around, time.Now())
+			if err != nil {
+				return err
+			}
+			result, err := diff.CompareAround(ctx, client, instKey, diff.Options{
+				Around: at,
+				Window: window,
+				Thresholds: diff.Thresholds{
+					ErrorRateIncrease: maxRateIncrease,
+					P99Increase:       maxP99Increase,
+					ThroughputDrop:    maxThroughputDrop,
+					NewPatterns:       failOnNewPatterns,
+				},
+				Confidence: confidence,
+				MinCalls:   minCalls,
+				MinErrors:  minErrors,
 			})
 			if err != nil {
 				return err
 			}
 
 			result.RenderTerminal(os.Stdout)
-			return nil
+			return exitWith(cmd, result.ExitCode())
 		},
 	}
 
+	defaults := diff.DefaultThresholds()
 	cmd.Flags().StringVarP(&instance, "instance", "i", "", "Signoz instance to query")
 	cmd.Flags().IntVarP(&duration, "duration", "d", 60, "Duration per window in minutes (compares last N min vs previous N min)")
+	cmd.Flags().StringVar(&around, "around", "", "Compare before/after this point: timestamp (RFC3339, 15:04, unix) or marker name")
+	cmd.Flags().DurationVar(&window, "window", 30*time.Minute, "Window length on each side of --around")
+	cmd.Flags().Float64Var(&maxRateIncrease, "max-error-rate-increase", defaults.ErrorRateIncrease, "Max allowed error rate increase in percentage points (0 disables)")
+	cmd.Flags().Float64Var(&maxP99Increase, "max-p99-increase", defaults.P99Increase, "Max allowed p99 latency increase in percent (0 disables)")
+	cmd.Flags().Float64Var(&maxThroughputDrop, "max-throughput-drop", defaults.ThroughputDrop, "Max allowed throughput drop in percent (0 disables)")
+	cmd.Flags().BoolVar(&failOnNewPatterns, "fail-on-new-patterns", defaults.NewPatterns, "Treat new error log patterns as regressions")
+	cmd.Flags().Float64Var(&confidence, "confidence", 0.95, "Confidence level required to call a change significant")
+	cmd.Flags().IntVar(&minCalls, "min-calls", 30, "Minimum calls per window before error rates are compared")
+	cmd.Flags().IntVar(&minErrors, "min-errors", 5, "Minimum errors across both windows before a change is judged")
+
+	cmd.AddCommand(diffMarkCmd())
+
+	return cmd
+}
+
+func diffMarkCmd() *cobra.Command {
+	var at string
+	var note string
+
+	cmd := &cobra.Command{
+		Use:   "mark [name]",
+		Short: "Record a named point in time (e.g. a deploy) for --around",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t := time.Now()
+			if at != "" {
+				parsed, err := diff.ParseAround(at, t)
+				if err != nil {
+					return err
+				}
+				t = parsed
+			}
+			if err := diff.SaveMarker(diff.Marker{Name: args[0], Time: t, Note: note}); err != nil {
+				return err
+			}
+			fmt.Printf("✅ Marker %q recorded at %s\n", args[0], t.Format(time.RFC3339))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&at, "at", "", "Time of the marker (default: now)")
+	cmd.Flags().StringVar(&note, "note", "", "Free-form note, e.g. the deployed version")
 
 	return cmd
 }
 
-func formatLogsForAI(logs []types.LogEntry) string {
+func formatLogsForAI(logs []types.LogEntry, refs *cite.Refs) string {
 	var sb strings.Builder
 	for _, log := range logs {
-		sb.WriteString(fmt.Sprintf("[%s] %s [%s] %s\n",
+		sb.WriteString(fmt.Sprintf("%s[%s] %s [%s] %s\n",
+			refs.Log(log),
 			log.Timestamp.Format("2006-01-02 15:04:05"),
 			log.SeverityText,
 			log.ServiceName,
@@ -688,10 +1224,45 @@ func formatLogsForAI(logs []types.LogEntry) string {
 	return sb.String()
 }
 
+func formatTracesForAI(traces []types.TraceEntry, refs *cite.Refs) string {
+	var sb strings.Builder
+	for _, t := range traces {
+		sb.WriteString(fmt.Sprintf("%s[%s] %s %s %.1fms status=%s trace_id=%s\n",
+			refs.Trace(t),
+			t.Timestamp.Format("2006-01-02 15:04:05"),
+			t.ServiceName,
+			t.OperationName,
+			t.DurationMs(),
+			t.StatusCode,
+			t.TraceID,
+		))
+	}
+	return sb.String()
+}
+
+func formatPatternsForAI(patterns []report.ErrorPattern) string {
+	var sb strings.Builder
+	for _, p := range patterns {
+		sb.WriteString(fmt.Sprintf("%dx [%s] %s\n  e.g. %s\n", p.Count, p.Service, p.Pattern, p.Sample))
+	}
+	return sb.String()
+}
+
 func watchCmd() *cobra.Command {
 	var instance string
 	var interval int
 	var errWarn, errCrit, p99Warn, p99Crit, spike float64
+	var confidence, minErrors float64
+	var detectors []string
+	var window time.Duration
+	var madThreshold, seasonalThreshold, cusumThreshold, cusumDrift float64
+	var seasonalDays int
+	var resetBaseline bool
+	var fullscreen bool
+	var onAlert []string
+	var cooldown time.Duration
+	var outputFormat string
+	var rulesFile string
 
 	cmd := &cobra.Command{
 		Use:   "watch",
@@ -704,11 +1275,50 @@ Thresholds can be customized. Alerts include:
 - Error rate exceeding warning/critical thresholds
 - P99 latency exceeding warning/critical thresholds  
 - Error count spikes compared to rolling baseline
-- New errors on previously clean services`,
+- New errors on previously clean services
+
+Spikes must also be statistically significant (Poisson test against the
+baseline at --confidence), and spikes or new errors below --min-errors are
+ignored, so a service going from 1 to 3 errors doesn't page anyone.
+
+--detectors selects the anomaly detectors to run (comma-separated):
+  spike     error count vs a rolling EMA baseline (default)
+  mad       robust z-score of error rate/p99 vs the rolling median (MAD)
+  seasonal  error rate/p99 vs the same hour of the week, bootstrapped from
+            --seasonal-days of history so daily traffic curves aren't alerts
+  cusum     cumulative sum change-point detection for slow drifts
+
+The rolling baseline is saved per instance under ~/.argus/state and restored on
+the next run, so spike and new-error detection work from the first tick. When no
+recent state exists it is backfilled from history; --reset-baseline discards it.
+
+--fullscreen switches to a dashboard that redraws in place: a service table
+with error rate and latency sparklines, a live alert feed, drill-down into a
+service's recent logs (enter) and traces (t), pause (p) and runtime threshold
+adjustment ([ ] error rate, { } p99, - + spike).
+
+--on-alert sends each new alert, and a "recovered" event once it clears, to a
+hook (repeatable): an http(s) URL receives a JSON POST, file:<path> appends a
+JSON line, and anything else is run as a shell command with the JSON on stdin.
+Repeats of the same service+condition are suppressed for --cooldown.
+
+Per-service threshold overrides (exact names or glob patterns), ignore lists
+and minimum-traffic floors are read from ~/.argus/watch.yaml, or --config.
+
+--output ndjson writes one JSON object per line instead of colored text: a
+"start" event, a "snapshot" per poll, an "alert" per alert, "notice"/"error"
+events, and a final "summary" when the session ends.`,
 		Example: `  argus watch
   argus watch --interval 60
   argus watch --error-rate-warn 3 --error-rate-crit 10
-  argus watch -i production --p99-warn 1000`,
+  argus watch -i production --p99-warn 1000
+  argus watch --detectors mad,seasonal,cusum --window 15m
+  argus watch --detectors seasonal --seasonal-threshold 4 --seasonal-days 14
+  argus watch --fullscreen
+  argus watch --on-alert https://hooks.slack.com/... --on-alert file:~/.argus/alerts.jsonl
+  argus watch --on-alert 'jq -r .message | notify-send argus' --cooldown 30m
+  argus watch --output ndjson | jq 'select(.type == "alert")'
+  argus watch --config ./watch.yaml`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg, err := config.Load()
 			if err != nil {
@@ -741,11 +1351,70 @@ Thresholds can be customized. Alerts include:
 			if cmd.Flags().Changed("spike") {
 				thresholds.ErrorSpike = spike
 			}
+			if cmd.Flags().Changed("confidence") {
+				thresholds.Confidence = confidence
+			}
+			if cmd.Flags().Changed("min-errors") {
+				thresholds.MinErrors = minErrors
+			}
+
+			if resetBaseline {
+				if err := watch.ResetState(instKey); err != nil {
+					return err
+				}
+			}
+
+			switch outputFormat {
+			case "text", watch.FormatNDJSON:
+			default:
+				return fmt.Errorf("unknown --output %q (want text or ndjson)", outputFormat)
+			}
+			if fullscreen && outputFormat == watch.FormatNDJSON {
+				return fmt.Errorf("--fullscreen and --output ndjson cannot be combined")
+			}
+
+			rules, err := watch.LoadConfig(rulesFile)
+			if err != nil {
+				return err
+			}
+
+			opts := watch.Options{Lookback: window, StateKey: instKey, Cooldown: cooldown, Format: outputFormat, Config: rules, Progress: os.Stderr}
+			for _, spec := range onAlert {
+				hook, err := watch.ParseHook(spec)
+				if err != nil {
+					return err
+				}
+				opts.Hooks = append(opts.Hooks, hook)
+			}
+			useSpike := false
+			for _, name := range detectors {
+				switch strings.TrimSpace(name) {
+				case "spike":
+					useSpike = true
+				case "mad":
+					opts.Detectors = append(opts.Detectors, watch.NewRobustZ(madThreshold))
+				case "seasonal":
+					opts.Detectors = append(opts.Detectors, watch.NewSeasonal(seasonalThreshold, seasonalDays))
+				case "cusum":
+					opts.Detectors = append(opts.Detectors, watch.NewCUSUM(cusumThreshold, cusumDrift))
+				default:
+					return fmt.Errorf("unknown detector %q (want spike, mad, seasonal or cusum)", name)
+				}
+			}
+			if !useSpike {
+				thresholds.ErrorSpike = 0
+			}
 
 			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 			defer cancel()
 
-			w := watch.New(client, instName, time.Duration(interval)*time.Second, thresholds, os.Stdout)
+			w := watch.NewWithOptions(client, instName, time.Duration(interval)*time.Second, thresholds, os.Stdout, opts)
+			if fullscreen {
+				if !term.IsTerminal(os.Stdin.Fd()) || !term.IsTerminal(os.Stdout.Fd()) {
+					return fmt.Errorf("--fullscreen requires an interactive terminal")
+				}
+				return watch.NewDashboard(w, os.Stdin, os.Stdout).Run(ctx)
+			}
 			return w.Run(ctx)
 		},
 	}
@@ -757,6 +1426,21 @@ Thresholds can be customized. Alerts include:
 	cmd.Flags().Float64Var(&p99Warn, "p99-warn", 2000, "P99 latency ms warning threshold")
 	cmd.Flags().Float64Var(&p99Crit, "p99-crit", 5000, "P99 latency ms critical threshold")
 	cmd.Flags().Float64Var(&spike, "spike", 3, "Error spike multiplier over baseline")
+	cmd.Flags().Float64Var(&confidence, "confidence", 0.95, "Confidence level a spike must reach to alert (0 disables)")
+	cmd.Flags().Float64Var(&minErrors, "min-errors", 5, "Minimum errors before spikes or new errors alert")
+	cmd.Flags().StringSliceVar(&detectors, "detectors", []string{"spike"}, "Anomaly detectors to run: spike, mad, seasonal, cusum")
+	cmd.Flags().DurationVar(&window, "window", 6*time.Hour, "Time window each poll aggregates over")
+	cmd.Flags().Float64Var(&madThreshold, "mad-threshold", 3.5, "Robust z-score at which the mad detector alerts")
+	cmd.Flags().Float64Var(&seasonalThreshold, "seasonal-threshold", 3, "Z-score vs the hour-of-week baseline at which the seasonal detector alerts")
+	cmd.Flags().IntVar(&seasonalDays, "seasonal-days", 7, "Days of history to bootstrap the seasonal baseline from")
+	cmd.Flags().Float64Var(&cusumThreshold, "cusum-threshold", 5, "Cumulative deviation (in std devs) at which the cusum detector alerts")
+	cmd.Flags().Float64Var(&cusumDrift, "cusum-drift", 0.5, "Per-poll slack (in std devs) the cusum detector ignores")
+	cmd.Flags().BoolVar(&resetBaseline, "reset-baseline", false, "Discard the saved baseline and start fresh")
+	cmd.Flags().BoolVar(&fullscreen, "fullscreen", false, "Full-screen interactive dashboard instead of scrolling output")
+	cmd.Flags().StringArrayVar(&onAlert, "on-alert", nil, "Alert hook: http(s) URL, file:<path> (JSONL) or shell command (repeatable)")
+	cmd.Flags().DurationVar(&cooldown, "cooldown", 15*time.Minute, "Suppress repeats of the same service+condition for this long")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format: text or ndjson")
+	cmd.Flags().StringVar(&rulesFile, "config", "", "Watch config with per-service overrides (default ~/.argus/watch.yaml)")
 
 	return cmd
 }
@@ -839,6 +1523,10 @@ Exit code reflects highest severity: 0=ok, 1=warning, 2=critical.`,
 			if err != nil {
 				return err
 			}
+			owners, err := ownership.FromConfig(appCfg)
+			if err != nil {
+				return err
+			}
 			ctx := context.Background()
 			client := signoz.New(*inst)
 			if format != "json" {
@@ -849,6 +1537,7 @@ Exit code reflects highest severity: 0=ok, 1=warning, 2=critical.`,
 			if err != nil {
 				return err
 			}
+			rpt.AssignOwners(owners)
 			if format == "json" {
 				out, err := alert.FormatJSON(rpt)
 				if err != nil {
@@ -858,8 +1547,7 @@ Exit code reflects highest severity: 0=ok, 1=warning, 2=critical.`,
 			} else {
 				fmt.Print(alert.FormatText(rpt))
 			}
-			os.Exit(rpt.ExitCode())
-			return nil
+			return exitWith(cmd, rpt.ExitCode())
 		},
 	}
 	checkCmd.Flags().StringVarP(&instance, "instance", "i", "", "Signoz instance to check against")
@@ -971,8 +1659,7 @@ Exit code reflects worst SLO: 0=ok, 1=warning, 2=critical/exhausted.`,
 			} else {
 				fmt.Print(slo.FormatText(rpt))
 			}
-			os.Exit(rpt.ExitCode())
-			return nil
+			return exitWith(cmd, rpt.ExitCode())
 		},
 	}
 	checkCmd.Flags().StringVarP(&instance, "instance", "i", "", "Signoz instance to check against")
@@ -985,59 +1672,249 @@ Exit code reflects worst SLO: 0=ok, 1=warning, 2=critical/exhausted.`,
 func explainCmd() *cobra.Command {
 	var instance string
 	var duration int
+	var maxSteps int
+	var oneShot bool
+	var noAI bool
+	var format string
 
 	cmd := &cobra.Command{
 		Use:   "explain [service]",
 		Short: "AI-powered root cause analysis for a service",
 		Long: `Correlate logs, traces, and metrics for a service and use AI to
-perform root cause analysis. Collects all available observability data,
-identifies patterns, and provides actionable recommendations.
+perform root cause analysis.
+
+The model investigates with tools backed by your Signoz instance: it lists
+services, queries logs with filters, fetches traces and whole trace trees,
+reads metrics and compares the window with the one before it, pulling
+whatever it needs over up to --max-steps rounds. Each tool call is shown as it
+runs. --one-shot instead collects a fixed sample of logs and traces and sends
+a single prompt.
+
+Before any AI call, explain derives the service dependency graph from
+parent/child spans and prints the blast radius: the upstream callers that
+depend on the service, with their error rates, and the downstream services it
+calls. It then ranks suspected causes with deterministic heuristics: which
+downstream dependency started failing first, error patterns that are new
+compared to the previous window, operations whose latency jumped, and services
+whose errors rise and fall with this one's. This part needs no API key or
+network access beyond Signoz; use --no-ai to stop there. The AI receives the
+ranking as structured input to confirm or refute.
+
+--format json prints the AI's conclusion as a schema-validated JSON object
+(severity, root causes with confidence, affected services, evidence and
+recommended actions) on stdout; progress goes to stderr.
 
 Think of it as having a senior SRE look at all your dashboards at once.`,
 		Example: `  argus explain api-service
   argus explain payment-service --duration 30
-  argus explain auth-service -i production`,
+  argus explain auth-service -i production --max-steps 12
+  argus explain api-service --one-shot
+  argus explain checkout --no-ai
+  argus explain checkout --format json > rca.json`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkAnswerFormat(format); err != nil {
+				return err
+			}
 			cfg, err := config.Load()
 			if err != nil {
 				return err
 			}
-			if cfg.AnthropicKey == "" {
-				return fmt.Errorf("Anthropic API key required. Run: argus config init")
+			if maxSteps < 1 {
+				return fmt.Errorf("--max-steps must be at least 1")
+			}
+			profile, err := resolveAIProfile(cfg)
+			if err != nil {
+				return err
 			}
 			inst, instKey, err := config.GetInstance(cfg, instance)
 			if err != nil {
 				return err
 			}
 			client := signoz.New(*inst)
-			ctx := context.Background()
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer cancel()
+			opts := explain.Options{
+				Service:   args[0],
+				Duration:  duration,
+				AI:        profile,
+				MaxSteps:  maxSteps,
+				SignozURL: inst.URL,
+			}
+			// Keep stdout for the JSON answer.
+			var out io.Writer = os.Stdout
+			if format == "json" {
+				out = os.Stderr
+			}
 
-			fmt.Printf("%s Collecting observability data for %s from %s...\n",
+			fmt.Fprintf(out, "%s Collecting observability data for %s from %s...\n",
 				output.MutedStyle.Render("🔍"), output.AccentStyle.Render(args[0]), output.AccentStyle.Render(instKey))
 
-			data, err := explain.Collect(ctx, client, instKey, explain.Options{
-				Service:      args[0],
-				Duration:     duration,
-				AnthropicKey: cfg.AnthropicKey,
-			})
+			data, err := explain.Collect(ctx, client, instKey, opts)
 			if err != nil {
 				return err
 			}
+			recordData(data)
 
-			fmt.Printf("%s Collected: %d error logs, %d recent logs, %d traces\n",
+			fmt.Fprintf(out, "%s Collected: %d error logs, %d recent logs, %d traces\n",
 				output.MutedStyle.Render("📊"),
 				len(data.ErrorLogs), len(data.RecentLogs), len(data.Traces))
-			fmt.Printf("%s Analyzing with AI...\n\n", output.MutedStyle.Render("🤖"))
+			data.BlastRadius.Render(out)
+			explain.RenderFindings(out, data.Findings)
+			fmt.Fprintln(out)
+
+			if noAI {
+				return nil
+			}
+			if !profile.Ready() {
+				if format == "json" {
+					return errNoAI
+				}
+				fmt.Println(output.MutedStyle.Render("No AI provider configured; skipping AI analysis. Run: argus config init"))
+				return nil
+			}
+
+			if !oneShot {
+				fmt.Fprintf(out, "%s Investigating %s on %s (up to %d steps)...\n\n",
+					output.MutedStyle.Render("🤖"), output.AccentStyle.Render(args[0]), output.AccentStyle.Render(instKey), maxSteps)
+				if format == "json" {
+					assessment, err := explain.Assess(ctx, client, data, opts, out)
+					if ctx.Err() != nil {
+						fmt.Fprintln(out, output.MutedStyle.Render("\nCancelled."))
+						return nil
+					}
+					if err != nil {
+						return err
+					}
+					return printJSON(assessment)
+				}
+				err := explain.Investigate(ctx, client, data, opts, out)
+				if ctx.Err() != nil {
+					fmt.Println(output.MutedStyle.Render("\nCancelled."))
+					return nil
+				}
+				return err
+			}
 
-			prompt := explain.BuildPrompt(data)
-			analyzer := ai.New(cfg.AnthropicKey)
-			return analyzer.Analyze(prompt, os.Stdout)
+			fmt.Fprintf(out, "%s Analyzing with AI...\n\n", output.MutedStyle.Render("🤖"))
+
+			analyzer, err := ai.FromProfile(profile)
+			if err != nil {
+				return err
+			}
+			p := explain.BuildPrompt(data, profile.PromptBudget)
+			printTrimmed(p)
+			if format == "json" {
+				return assess(ctx, analyzer, p.Text, data.Refs)
+			}
+			return analyze(ctx, analyzer, p.Text, data.Refs)
 		},
 	}
 
 	cmd.Flags().StringVarP(&instance, "instance", "i", "", "Signoz instance to query")
 	cmd.Flags().IntVarP(&duration, "duration", "d", 60, "Duration in minutes to analyze")
+	cmd.Flags().IntVar(&maxSteps, "max-steps", explain.DefaultMaxSteps, "Maximum rounds of tool calls the model may make")
+	cmd.Flags().BoolVar(&oneShot, "one-shot", false, "Send one prompt with a fixed data sample instead of investigating with tools")
+	cmd.Flags().BoolVar(&noAI, "no-ai", false, "Only show the blast radius and heuristic cause ranking, without AI analysis")
+	cmd.Flags().StringVarP(&format, "format", "f", "text", "Answer format: text or json")
+
+	return cmd
+}
+
+func mapCmd() *cobra.Command {
+	var instance string
+	var duration int
+	var limit int
+	var service string
+	var depth int
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "map",
+		Short: "Show the service dependency map derived from traces",
+		Long: `Build the service dependency graph from trace spans: whenever a span's
+parent belongs to another service, that service called this one. Each edge
+shows the sampled call count, error rate and p99 latency.
+
+The terminal view is a call tree from each entry point; a service that was
+already expanded is marked ↺ rather than repeated. --format dot and
+--format mermaid export the graph for Graphviz or Markdown docs.
+
+--service focuses on one service and everything within --depth hops of it,
+upstream and downstream.`,
+		Example: `  argus map
+  argus map --service checkout --depth 2
+  argus map -f dot | dot -Tsvg > services.svg
+  argus map -f mermaid -d 180`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch format {
+			case "text", "dot", "mermaid":
+			default:
+				return fmt.Errorf("unknown format %q (use text, dot or mermaid)", format)
+			}
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			inst, instKey, err := config.GetInstance(cfg, instance)
+			if err != nil {
+				return err
+			}
+			client := signoz.New(*inst)
+			ctx := context.Background()
+
+			if format == "text" {
+				fmt.Printf("%s Sampling up to %d spans from %s...\n", output.MutedStyle.Render("⏳"), limit, output.AccentStyle.Render(instKey))
+			}
+			result, err := client.QueryTraces(ctx, "", duration, limit)
+			if err != nil {
+				return err
+			}
+			graph := topology.Build(result.Traces)
+			if service != "" {
+				if !graph.Has(service) {
+					return fmt.Errorf("service %q not found in the sampled traces", service)
+				}
+				graph = graph.Neighborhood(service, depth)
+			}
+
+			switch format {
+			case "dot":
+				fmt.Print(graph.DOT())
+				return nil
+			case "mermaid":
+				fmt.Print(graph.Mermaid())
+				return nil
+			}
+
+			fmt.Println()
+			if len(graph.Edges()) == 0 {
+				fmt.Println(output.MutedStyle.Render("No cross-service calls found in the sampled spans. Try a longer --duration or a higher --limit."))
+				return nil
+			}
+			if service == "" {
+				fmt.Println(output.TitleStyle.Render(fmt.Sprintf("🗺️  Service map — %d services, %d edges (last %dm)", len(graph.Services()), len(graph.Edges()), duration)))
+				fmt.Println()
+				graph.RenderTree(os.Stdout)
+				return nil
+			}
+			fmt.Println(output.TitleStyle.Render(fmt.Sprintf("🗺️  Neighbourhood of %s — depth %d (last %dm)", service, depth, duration)))
+			if len(graph.Callers(service)) > 0 {
+				fmt.Printf("\n%s\n", output.MutedStyle.Render("⬆️  Called by"))
+				graph.RenderCallers(os.Stdout, service, depth)
+			}
+			fmt.Printf("\n%s\n", output.MutedStyle.Render("⬇️  Calls"))
+			graph.RenderTree(os.Stdout, service)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&instance, "instance", "i", "", "Signoz instance to query")
+	cmd.Flags().IntVarP(&duration, "duration", "d", 60, "Duration in minutes of traces to sample")
+	cmd.Flags().IntVarP(&limit, "limit", "l", 2000, "Maximum number of spans to sample")
+	cmd.Flags().StringVarP(&service, "service", "s", "", "Focus on the neighbourhood of this service")
+	cmd.Flags().IntVar(&depth, "depth", 2, "Hops around --service to include")
+	cmd.Flags().StringVarP(&format, "format", "f", "text", "Output format: text, dot or mermaid")
 
 	return cmd
 }
@@ -1065,8 +1942,12 @@ down into issues with follow-up questions.`,
 				return err
 			}
 
-			if cfg.AnthropicKey == "" {
-				return fmt.Errorf("Anthropic API key required. Run: argus config init")
+			profile, err := resolveAIProfile(cfg)
+			if err != nil {
+				return err
+			}
+			if !profile.Ready() {
+				return errNoAI
 			}
 
 			inst, instKey, err := config.GetInstance(cfg, instance)
@@ -1087,8 +1968,9 @@ down into issues with follow-up questions.`,
 			session := tui.New(client, tui.Options{
 				InstanceKey:  instKey,
 				InstanceName: instName,
-				AnthropicKey: cfg.AnthropicKey,
+				AI:           profile,
 				MaxHistory:   maxHistory,
+				SignozURL:    inst.URL,
 			})
 
 			return session.Run(ctx)
@@ -1100,3 +1982,55 @@ down into issues with follow-up questions.`,
 
 	return cmd
 }
+
+func replayCmd() *cobra.Command {
+	var exchanges bool
+	var data bool
+
+	cmd := &cobra.Command{
+		Use:   "replay <bundle>",
+		Short: "Render a recorded session without network access",
+		Long: `Render a session recorded with --record: the output exactly as it was
+printed, without querying Signoz or calling an AI provider.
+
+A bundle also holds the data the command collected (explain's logs, traces
+and rankings, or the report) and every AI request and answer, for
+postmortems and as fixtures for tests.`,
+		Example: `  argus explain checkout --record checkout-incident.json
+  argus replay checkout-incident.json
+  argus replay checkout-incident.json --exchanges
+  argus replay checkout-incident.json --data | jq '.ErrorLogs | length'`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := session.Load(args[0])
+			if err != nil {
+				return err
+			}
+
+			if data {
+				if len(b.Data) == 0 {
+					return fmt.Errorf("bundle %s has no collected data", args[0])
+				}
+				var v interface{}
+				if err := json.Unmarshal(b.Data, &v); err != nil {
+					return fmt.Errorf("parsing bundle data: %w", err)
+				}
+				return printJSON(v)
+			}
+
+			fmt.Fprintln(os.Stderr, output.MutedStyle.Render(fmt.Sprintf("📼 argus %s, recorded %s (%d AI exchanges)",
+				b.Command, b.RecordedAt.Local().Format("2006-01-02 15:04:05"), len(b.Exchanges))))
+			b.Render(os.Stdout)
+			if exchanges {
+				fmt.Println()
+				b.RenderExchanges(os.Stdout)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&exchanges, "exchanges", false, "Also print every AI request and answer")
+	cmd.Flags().BoolVar(&data, "data", false, "Print only the collected data, as JSON")
+
+	return cmd
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lbarahona/argus/internal/session"
+	"github.com/spf13/cobra"
+)
+
+func TestRunRecordsFailingCommand(t *testing.T) {
+	defer func() { recordPath, recorder, commandLine = "", nil, "" }()
+
+	rootCmd := newRootCmd()
+	rootCmd.AddCommand(&cobra.Command{
+		Use: "gate",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Println("FAIL: error rate regressed")
+			return exitWith(cmd, 2)
+		},
+	})
+	path := filepath.Join(t.TempDir(), "bundle.json")
+
+	if code := run(rootCmd, []string{"gate", "--record", path}); code != 2 {
+		t.Fatalf("exit code = %d, want 2", code)
+	}
+	b, err := session.Load(path)
+	if err != nil {
+		t.Fatalf("bundle not written: %v", err)
+	}
+	if b.Command != "gate --record "+path {
+		t.Errorf("command = %q", b.Command)
+	}
+	if !strings.Contains(b.Output, "FAIL: error rate regressed") {
+		t.Errorf("output = %q, want the gate's verdict", b.Output)
+	}
+	if b.Error != "exit status 2" {
+		t.Errorf("error = %q, want exit status 2", b.Error)
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"ok", nil, 0},
+		{"failed", fmt.Errorf("boom"), 1},
+		{"exit", exitError{3}, 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rootCmd := newRootCmd()
+			rootCmd.SilenceErrors, rootCmd.SilenceUsage = true, true
+			rootCmd.AddCommand(&cobra.Command{
+				Use:  "probe",
+				RunE: func(cmd *cobra.Command, args []string) error { return tt.err },
+			})
+			if code := run(rootCmd, []string{"probe"}); code != tt.want {
+				t.Errorf("exit code = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
@@ -1,19 +1,16 @@
 package ai
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
+	"os"
 	"strings"
-)
 
-const (
-	anthropicAPI     = "https://api.anthropic.com/v1/messages"
-	anthropicVersion = "2023-06-01"
-	model            = "claude-sonnet-4-20250514"
+	"github.com/lbarahona/argus/internal/redact"
+	"github.com/lbarahona/argus/pkg/types"
 )
 
 const systemPrompt = `You are an expert Site Reliability Engineer (SRE) analyzing observability data from Signoz.
@@ -33,152 +30,253 @@ When analyzing data:
 
 Format your response with clear sections using markdown.`
 
-// Analyzer handles AI-powered analysis via Anthropic Claude.
+// Analyzer handles AI-powered analysis through an LLMProvider. Everything it
+// sends is passed through its redactor first, and answers to a request seen
+// before are served from its cache.
 type Analyzer struct {
-	apiKey string
-	client *http.Client
+	provider LLMProvider
+	usage    Usage
+	redactor *redact.Redactor
+	audit    io.Writer // receives each prompt as sent, for --show-prompt
+	shown    int       // messages of the current conversation already audited
+	cache    *Cache
+	refresh  bool       // skip cache lookups, for --no-cache
+	scope    cacheScope // model settings, part of every cache key
+	lastKey  string     // cache key of the latest answer, for Forget
+	record   *types.Recording
 }
 
-// New creates a new Analyzer.
-func New(apiKey string) *Analyzer {
-	return &Analyzer{
-		apiKey: apiKey,
-		client: &http.Client{},
-	}
+// cacheScope is what besides the messages decides a model's answer.
+type cacheScope struct {
+	Provider    string   `json:"provider"`
+	BaseURL     string   `json:"base_url"`
+	Model       string   `json:"model"`
+	MaxTokens   int      `json:"max_tokens"`
+	Temperature *float64 `json:"temperature"`
 }
 
-// Message represents a conversation message for the Anthropic API.
-type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+// New creates an Analyzer backed by Anthropic with default settings.
+func New(apiKey string) *Analyzer {
+	return NewWithURL(apiKey, "")
 }
 
-type request struct {
-	Model     string    `json:"model"`
-	MaxTokens int       `json:"max_tokens"`
-	System    string    `json:"system"`
-	Messages  []Message `json:"messages"`
-	Stream    bool      `json:"stream"`
+// NewWithURL creates an Anthropic Analyzer that talks to a different base
+// URL, such as a proxy or a fake server in tests. An empty url uses
+// Anthropic's.
+func NewWithURL(apiKey, url string) *Analyzer {
+	return NewWithProvider(NewAnthropic(types.AIProfile{APIKey: apiKey, BaseURL: url}))
 }
 
-// Analyze sends data to Claude and streams the response to the writer.
-func (a *Analyzer) Analyze(prompt string, w io.Writer) error {
-	reqBody := request{
-		Model:     model,
-		MaxTokens: 4096,
-		System:    systemPrompt,
-		Messages: []Message{
-			{Role: "user", Content: prompt},
-		},
-		Stream: true,
-	}
+// NewWithProvider creates an Analyzer backed by provider.
+func NewWithProvider(provider LLMProvider) *Analyzer {
+	return &Analyzer{provider: provider}
+}
 
-	body, err := json.Marshal(reqBody)
+// FromProfile creates an Analyzer for a configured AI profile, redacting
+// with the profile's redaction settings, caching in its CacheDir, recording
+// to its Recording and printing prompts to stderr when ShowPrompt is set.
+func FromProfile(p types.AIProfile) (*Analyzer, error) {
+	provider, err := NewProvider(p)
 	if err != nil {
-		return fmt.Errorf("marshaling request: %w", err)
+		return nil, err
 	}
-
-	req, err := http.NewRequest(http.MethodPost, anthropicAPI, bytes.NewReader(body))
+	redactor, err := redact.New(p.Redaction)
 	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
+		return nil, err
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", a.apiKey)
-	req.Header.Set("anthropic-version", anthropicVersion)
-
-	resp, err := a.client.Do(req)
+	cache, err := newCache(p)
 	if err != nil {
-		return fmt.Errorf("calling Anthropic API: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Anthropic API error (status %d): %s", resp.StatusCode, string(respBody))
+	a := NewWithProvider(provider)
+	a.redactor = redactor
+	a.cache = cache
+	a.refresh = p.NoCache
+	a.scope = cacheScope{p.GetProvider(), p.BaseURL, p.Model, maxTokens(p), p.Temperature}
+	a.record = p.Recording
+	if p.ShowPrompt {
+		a.audit = os.Stderr
 	}
+	return a, nil
+}
 
-	return a.streamResponse(resp.Body, w)
+// Message represents a conversation message.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
 }
 
-// AnalyzeWithHistory sends a multi-turn conversation to Claude and streams the response.
-func (a *Analyzer) AnalyzeWithHistory(systemPrompt string, messages []Message, w io.Writer) error {
-	reqBody := request{
-		Model:     model,
-		MaxTokens: 4096,
-		System:    systemPrompt,
-		Messages:  messages,
-		Stream:    true,
-	}
+// Analyze sends data to the model and streams the response to the writer.
+func (a *Analyzer) Analyze(ctx context.Context, prompt string, w io.Writer) error {
+	return a.AnalyzeWithHistory(ctx, systemPrompt, []Message{{Role: "user", Content: prompt}}, w)
+}
 
-	body, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("marshaling request: %w", err)
+// AnalyzeWithHistory sends a multi-turn conversation and streams the response.
+func (a *Analyzer) AnalyzeWithHistory(ctx context.Context, systemPrompt string, messages []Message, w io.Writer) error {
+	redacted := make([]Message, len(messages))
+	shown := make([]auditMessage, len(messages))
+	for i, m := range messages {
+		redacted[i] = Message{Role: m.Role, Content: a.redactor.Redact(m.Content)}
+		shown[i] = auditMessage{m.Role, redacted[i].Content}
+	}
+	a.showPrompt(systemPrompt, shown)
+
+	key := cacheKey(a.scope, "stream", systemPrompt, redacted)
+	a.lastKey = key
+	var answer string
+	if !a.refresh && a.cache.get(key, &answer) {
+		a.usage.Cached++
+		a.recordExchange(systemPrompt, redacted, answer, nil, true)
+		_, err := io.WriteString(w, answer)
+		return err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, anthropicAPI, bytes.NewReader(body))
+	var buf bytes.Buffer
+	usage, err := a.provider.Stream(ctx, systemPrompt, redacted, io.MultiWriter(w, &buf))
+	a.usage.Add(usage)
 	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
+		return err
 	}
+	a.cache.put(key, buf.String()) // a failed write only costs a future hit
+	a.recordExchange(systemPrompt, redacted, buf.String(), nil, false)
+	return nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", a.apiKey)
-	req.Header.Set("anthropic-version", anthropicVersion)
+// Converse sends one turn of a tool-use conversation and returns the model's
+// reply without streaming. With allowTools false the model must answer in
+// text, which is how callers force a conclusion once a step budget runs out.
+func (a *Analyzer) Converse(ctx context.Context, system string, messages []ToolMessage, tools []Tool, allowTools bool) (*ToolResponse, error) {
+	redacted := make([]ToolMessage, len(messages))
+	shown := make([]auditMessage, len(messages))
+	for i, m := range messages {
+		redacted[i] = ToolMessage{Role: m.Role, Content: make([]ContentBlock, len(m.Content))}
+		var text strings.Builder
+		for j, b := range m.Content {
+			b.Text = a.redactor.Redact(b.Text)
+			b.Content = a.redactor.Redact(b.Content)
+			redacted[i].Content[j] = b
+			switch b.Type {
+			case "text":
+				text.WriteString(b.Text + "\n")
+			case "tool_use":
+				fmt.Fprintf(&text, "→ %s %s\n", b.Name, b.Input)
+			case "tool_result":
+				fmt.Fprintf(&text, "← %s\n%s\n", b.ToolUseID, b.Content)
+			}
+		}
+		shown[i] = auditMessage{m.Role, strings.TrimSuffix(text.String(), "\n")}
+	}
+	a.showPrompt(system, shown)
+
+	key := cacheKey(a.scope, "converse", system, redacted, tools, allowTools)
+	a.lastKey = key
+	var cached ToolResponse
+	if !a.refresh && a.cache.get(key, &cached) {
+		cached.Usage = Usage{}
+		a.usage.Cached++
+		a.recordExchange(system, redacted, "", &cached, true)
+		return &cached, nil
+	}
 
-	resp, err := a.client.Do(req)
+	resp, err := a.provider.Converse(ctx, system, redacted, tools, allowTools)
+	if resp != nil {
+		a.usage.Add(resp.Usage)
+	}
 	if err != nil {
-		return fmt.Errorf("calling Anthropic API: %w", err)
+		return resp, err
 	}
-	defer resp.Body.Close()
+	a.cache.put(key, resp)
+	a.recordExchange(system, redacted, "", resp, false)
+	return resp, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Anthropic API error (status %d): %s", resp.StatusCode, string(respBody))
-	}
+// Forget removes the latest answer from the cache, for callers that reject
+// it, so that running the command again asks anew.
+func (a *Analyzer) Forget() {
+	a.cache.remove(a.lastKey)
+}
 
-	return a.streamResponse(resp.Body, w)
+// recordExchange adds a request and its answer, or tool-use response, to the
+// profile's recording.
+func (a *Analyzer) recordExchange(system string, messages interface{}, answer string, resp *ToolResponse, cached bool) {
+	if a.record == nil {
+		return
+	}
+	e := types.AIExchange{System: system, Answer: answer, Cached: cached}
+	e.Messages, _ = json.Marshal(messages)
+	if resp != nil {
+		e.Response, _ = json.Marshal(resp)
+	}
+	a.record.Exchanges = append(a.record.Exchanges, e)
 }
 
-func (a *Analyzer) streamResponse(body io.Reader, w io.Writer) error {
-	scanner := bufio.NewScanner(body)
-	for scanner.Scan() {
-		line := scanner.Text()
+// AnalyzeSync sends data to the model and returns the full response (non-streaming).
+func (a *Analyzer) AnalyzeSync(ctx context.Context, prompt string) (string, error) {
+	var buf bytes.Buffer
+	if err := a.Analyze(ctx, prompt, &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
 
-		if !strings.HasPrefix(line, "data: ") {
-			continue
-		}
+// Usage returns the tokens used by every call made through the analyzer.
+func (a *Analyzer) Usage() Usage {
+	return a.usage
+}
 
-		data := strings.TrimPrefix(line, "data: ")
-		if data == "[DONE]" {
-			break
-		}
+type auditMessage struct {
+	role, text string
+}
 
-		var event struct {
-			Type  string `json:"type"`
-			Delta struct {
-				Type string `json:"type"`
-				Text string `json:"text"`
-			} `json:"delta"`
-		}
+// showPrompt writes the messages of a request that weren't shown by an
+// earlier request of the same conversation, with the system prompt when the
+// conversation starts.
+func (a *Analyzer) showPrompt(system string, messages []auditMessage) {
+	if a.audit == nil {
+		return
+	}
+	if len(messages) <= a.shown {
+		a.shown = 0 // a new conversation
+	}
+	if a.shown == 0 {
+		fmt.Fprintf(a.audit, "──── prompt: system ────\n%s\n", system)
+	}
+	for _, m := range messages[a.shown:] {
+		fmt.Fprintf(a.audit, "──── prompt: %s ────\n%s\n", m.role, m.text)
+	}
+	fmt.Fprintln(a.audit, "──── end of prompt ────")
+	a.shown = len(messages)
+}
 
-		if err := json.Unmarshal([]byte(data), &event); err != nil {
-			continue
-		}
+// LLMProvider is a chat model backend.
+type LLMProvider interface {
+	// Stream sends a conversation, writes the reply to w as it arrives and
+	// returns the tokens used.
+	Stream(ctx context.Context, system string, messages []Message, w io.Writer) (Usage, error)
+	// Converse sends one turn of a tool-use conversation without streaming.
+	Converse(ctx context.Context, system string, messages []ToolMessage, tools []Tool, allowTools bool) (*ToolResponse, error)
+}
 
-		if event.Type == "content_block_delta" && event.Delta.Type == "text_delta" {
-			fmt.Fprint(w, event.Delta.Text)
+// NewProvider creates the provider a profile asks for.
+func NewProvider(p types.AIProfile) (LLMProvider, error) {
+	switch p.GetProvider() {
+	case types.ProviderAnthropic:
+		return NewAnthropic(p), nil
+	case types.ProviderOpenAI:
+		if p.Model == "" {
+			return nil, fmt.Errorf("a model is required for OpenAI-compatible providers")
 		}
+		return NewOpenAI(p), nil
+	default:
+		return nil, fmt.Errorf("unknown AI provider %q (want anthropic or openai)", p.Provider)
 	}
-
-	fmt.Fprintln(w)
-	return scanner.Err()
 }
 
-// AnalyzeSync sends data to Claude and returns the full response (non-streaming).
-func (a *Analyzer) AnalyzeSync(prompt string) (string, error) {
-	var buf bytes.Buffer
-	if err := a.Analyze(prompt, &buf); err != nil {
-		return "", err
+const defaultMaxTokens = 4096
+
+func maxTokens(p types.AIProfile) int {
+	if p.MaxTokens > 0 {
+		return p.MaxTokens
 	}
-	return buf.String(), nil
+	return defaultMaxTokens
 }
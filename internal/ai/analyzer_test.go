@@ -2,10 +2,14 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/lbarahona/argus/pkg/types"
 )
 
 func TestAnalyzerBuildsCorrectRequest(t *testing.T) {
@@ -32,31 +36,66 @@ func TestAnalyzerBuildsCorrectRequest(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// We can't easily override the const API URL, so verify request structure
-	_ = server // server used to validate handler logic above
-
-	reqBody := request{
-		Model:     model,
-		MaxTokens: 4096,
-		System:    systemPrompt,
-		Messages:  []Message{{Role: "user", Content: "test prompt"}},
-		Stream:    true,
+	var buf bytes.Buffer
+	if err := NewWithURL("test-key", server.URL).Analyze(context.Background(), "test prompt", &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != "hello\n" {
+		t.Errorf("got %q, want %q", buf.String(), "hello\n")
 	}
 
-	if reqBody.Model != model {
-		t.Errorf("expected model=%s", model)
+	if capturedReq.Model != defaultAnthropicModel {
+		t.Errorf("expected model=%s, got %s", defaultAnthropicModel, capturedReq.Model)
+	}
+	if capturedReq.MaxTokens != defaultMaxTokens || capturedReq.Temperature != nil {
+		t.Errorf("expected default max_tokens and no temperature, got %d %v", capturedReq.MaxTokens, capturedReq.Temperature)
 	}
-	if reqBody.System != systemPrompt {
+	if capturedReq.System != systemPrompt {
 		t.Error("expected system prompt to be set")
 	}
-	if len(reqBody.Messages) != 1 || reqBody.Messages[0].Content != "test prompt" {
+	if len(capturedReq.Messages) != 1 || capturedReq.Messages[0].Content != "test prompt" {
 		t.Error("unexpected messages")
 	}
-	if !reqBody.Stream {
+	if !capturedReq.Stream {
 		t.Error("expected stream=true")
 	}
 }
 
+func TestAnthropicProfileSettings(t *testing.T) {
+	var got request
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	temp := 0.2
+	a, err := FromProfile(types.AIProfile{APIKey: "k", BaseURL: server.URL + "/", Model: "claude-haiku", MaxTokens: 1000, Temperature: &temp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Analyze(context.Background(), "hi", &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1/messages" {
+		t.Errorf("expected /v1/messages, got %s", path)
+	}
+	if got.Model != "claude-haiku" || got.MaxTokens != 1000 || got.Temperature == nil || *got.Temperature != 0.2 {
+		t.Errorf("profile settings not applied: %+v", got)
+	}
+}
+
+func TestFromProfileErrors(t *testing.T) {
+	if _, err := FromProfile(types.AIProfile{Provider: "bard"}); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+	if _, err := FromProfile(types.AIProfile{Provider: types.ProviderOpenAI, BaseURL: "http://localhost:11434/v1"}); err == nil {
+		t.Error("expected an error for an OpenAI-compatible profile without a model")
+	}
+}
+
 func TestStreamResponse(t *testing.T) {
 	input := `data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"Hello "}}
 
@@ -66,9 +105,9 @@ data: [DONE]
 
 `
 
-	analyzer := &Analyzer{}
+	analyzer := &Anthropic{}
 	var buf bytes.Buffer
-	err := analyzer.streamResponse(bytes.NewBufferString(input), &buf)
+	_, err := analyzer.streamResponse(bytes.NewBufferString(input), &buf)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -90,9 +129,9 @@ data: {"type":"message_stop"}
 
 `
 
-	analyzer := &Analyzer{}
+	analyzer := &Anthropic{}
 	var buf bytes.Buffer
-	err := analyzer.streamResponse(bytes.NewBufferString(input), &buf)
+	_, err := analyzer.streamResponse(bytes.NewBufferString(input), &buf)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -101,3 +140,102 @@ data: {"type":"message_stop"}
 		t.Errorf("got %q, want %q", buf.String(), "ok\n")
 	}
 }
+
+func TestConverse(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"stop_reason":"tool_use","content":[{"type":"text","text":"looking"},{"type":"tool_use","id":"t1","name":"list_services","input":{}}]}`))
+	}))
+	defer server.Close()
+
+	a := NewWithURL("test-key", server.URL)
+	tools := []Tool{{Name: "list_services", Description: "d", InputSchema: json.RawMessage(`{"type":"object"}`)}}
+	msgs := []ToolMessage{{Role: "user", Content: []ContentBlock{{Type: "text", Text: "hi"}}}}
+	resp, err := a.Converse(context.Background(), "sys", msgs, tools, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Text() != "looking" || len(resp.ToolCalls()) != 1 || resp.ToolCalls()[0].ID != "t1" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if got["system"] != "sys" || got["tool_choice"] != nil || len(got["tools"].([]interface{})) != 1 {
+		t.Errorf("unexpected request %v", got)
+	}
+
+	if _, err := a.Converse(context.Background(), "sys", msgs, tools, false); err != nil {
+		t.Fatal(err)
+	}
+	if choice, _ := got["tool_choice"].(map[string]interface{}); choice["type"] != "none" {
+		t.Errorf("expected tool_choice none, got %v", got["tool_choice"])
+	}
+}
+
+func TestFromProfileRedacts(t *testing.T) {
+	var got request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	profile := types.AIProfile{APIKey: "k", BaseURL: server.URL, Redaction: &types.RedactionConfig{
+		Rules: []types.RedactRule{{Name: "host", Pattern: `\bdb-\d+\.corp\b`}},
+	}}
+	a, err := FromProfile(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var audit bytes.Buffer
+	a.audit = &audit
+	if err := a.Analyze(context.Background(), "db-3.corp refused jane@example.com from 10.1.2.3", &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "[HOST] refused [EMAIL] from [IP]"
+	if len(got.Messages) != 1 || got.Messages[0].Content != want {
+		t.Errorf("expected %q to be sent, got %+v", want, got.Messages)
+	}
+	if !strings.Contains(audit.String(), "──── prompt: user ────\n"+want+"\n") || !strings.Contains(audit.String(), "──── prompt: system ────") {
+		t.Errorf("expected the redacted prompt in the audit output, got:\n%s", audit.String())
+	}
+
+	profile.Redaction = &types.RedactionConfig{Rules: []types.RedactRule{{Name: "bad", Pattern: "("}}}
+	if _, err := FromProfile(profile); err == nil {
+		t.Error("expected an error for an invalid redaction rule")
+	}
+}
+
+func TestConverseRedactsToolResults(t *testing.T) {
+	var got toolRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"stop_reason":"end_turn","content":[{"type":"text","text":"done"}]}`))
+	}))
+	defer server.Close()
+
+	a, _ := FromProfile(types.AIProfile{APIKey: "k", BaseURL: server.URL})
+	var audit bytes.Buffer
+	a.audit = &audit
+	msgs := []ToolMessage{
+		{Role: "user", Content: []ContentBlock{{Type: "text", Text: "why is checkout failing?"}}},
+		{Role: "assistant", Content: []ContentBlock{{Type: "tool_use", ID: "t1", Name: "query_logs", Input: json.RawMessage(`{}`)}}},
+		{Role: "user", Content: []ContentBlock{{Type: "tool_result", ToolUseID: "t1", Content: "card 4111 1111 1111 1111 declined"}}},
+	}
+	if _, err := a.Converse(context.Background(), "sys", msgs, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if c := got.Messages[2].Content[0].Content; c != "card [CARD] declined" {
+		t.Errorf("tool results should be redacted, got %q", c)
+	}
+	if msgs[2].Content[0].Content != "card 4111 1111 1111 1111 declined" {
+		t.Error("the caller's messages must not be modified")
+	}
+
+	// The next round only shows what is new.
+	msgs = append(msgs, ToolMessage{Role: "assistant", Content: []ContentBlock{{Type: "text", Text: "looking"}}}, ToolMessage{Role: "user", Content: []ContentBlock{{Type: "text", Text: "go on"}}})
+	audit.Reset()
+	a.Converse(context.Background(), "sys", msgs, nil, true)
+	if strings.Contains(audit.String(), "system") || strings.Contains(audit.String(), "checkout") || !strings.Contains(audit.String(), "go on") {
+		t.Errorf("expected only the new messages, got:\n%s", audit.String())
+	}
+}
@@ -0,0 +1,231 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+const (
+	anthropicBaseURL      = "https://api.anthropic.com"
+	anthropicVersion      = "2023-06-01"
+	defaultAnthropicModel = "claude-sonnet-4-20250514"
+)
+
+// Anthropic is an LLMProvider for the Anthropic Messages API.
+type Anthropic struct {
+	profile types.AIProfile
+	url     string
+	client  *http.Client
+	retry   retrier
+}
+
+// NewAnthropic creates an Anthropic provider. The base URL defaults to
+// Anthropic's and the model to Claude Sonnet.
+func NewAnthropic(p types.AIProfile) *Anthropic {
+	if p.Model == "" {
+		p.Model = defaultAnthropicModel
+	}
+	return &Anthropic{
+		profile: p,
+		url:     endpoint(p.BaseURL, anthropicBaseURL, "/v1/messages"),
+		client:  &http.Client{},
+		retry:   defaultRetrier(),
+	}
+}
+
+// endpoint joins base (or fallback when empty) with path, unless base is
+// already the full endpoint.
+func endpoint(base, fallback, path string) string {
+	if base == "" {
+		base = fallback
+	}
+	base = strings.TrimRight(base, "/")
+	if strings.HasSuffix(base, path) {
+		return base
+	}
+	return base + path
+}
+
+type request struct {
+	Model       string    `json:"model"`
+	MaxTokens   int       `json:"max_tokens"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	System      string    `json:"system"`
+	Messages    []Message `json:"messages"`
+	Stream      bool      `json:"stream"`
+}
+
+type toolChoice struct {
+	Type string `json:"type"` // "auto" or "none"
+}
+
+type toolRequest struct {
+	Model       string        `json:"model"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	System      string        `json:"system"`
+	Messages    []ToolMessage `json:"messages"`
+	Tools       []Tool        `json:"tools"`
+	ToolChoice  *toolChoice   `json:"tool_choice,omitempty"`
+}
+
+// Stream implements LLMProvider. Rate-limited or overloaded requests are
+// retried, as long as no part of the answer was written yet.
+func (a *Anthropic) Stream(ctx context.Context, system string, messages []Message, w io.Writer) (Usage, error) {
+	reqBody := request{
+		Model:       a.profile.Model,
+		MaxTokens:   maxTokens(a.profile),
+		Temperature: a.profile.Temperature,
+		System:      system,
+		Messages:    messages,
+		Stream:      true,
+	}
+	var usage Usage
+	err := a.retry.do(ctx, func() error {
+		resp, err := a.post(ctx, reqBody)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		usage, err = a.streamResponse(resp.Body, w)
+		return err
+	})
+	return usage, err
+}
+
+// Converse implements LLMProvider.
+func (a *Anthropic) Converse(ctx context.Context, system string, messages []ToolMessage, tools []Tool, allowTools bool) (*ToolResponse, error) {
+	reqBody := toolRequest{
+		Model:       a.profile.Model,
+		MaxTokens:   maxTokens(a.profile),
+		Temperature: a.profile.Temperature,
+		System:      system,
+		Messages:    messages,
+		Tools:       tools,
+	}
+	if !allowTools {
+		reqBody.ToolChoice = &toolChoice{Type: "none"}
+	}
+
+	var out ToolResponse
+	err := a.retry.do(ctx, func() error {
+		resp, err := a.post(ctx, reqBody)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return fmt.Errorf("parsing response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// post sends a Messages API request and returns the response if it
+// succeeded, or an *APIError.
+func (a *Anthropic) post(ctx context.Context, reqBody interface{}) (*http.Response, error) {
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", a.profile.APIKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calling Anthropic API: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, statusError("Anthropic", resp)
+	}
+	return resp, nil
+}
+
+// streamResponse writes the text deltas of an SSE stream to w and returns
+// the token usage from message_start and message_delta. An error event ends
+// the stream with an *APIError.
+func (a *Anthropic) streamResponse(body io.Reader, w io.Writer) (Usage, error) {
+	var usage Usage
+	tw := &trackingWriter{w: w}
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		data := strings.TrimPrefix(line, "data: ")
+		if data == "[DONE]" {
+			break
+		}
+
+		var event struct {
+			Type  string `json:"type"`
+			Delta struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"delta"`
+			Message struct {
+				Usage Usage `json:"usage"`
+			} `json:"message"`
+			Usage Usage `json:"usage"`
+			errorBody
+		}
+
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			continue
+		}
+
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" {
+				fmt.Fprint(tw, event.Delta.Text)
+			}
+		case "message_start":
+			usage = event.Message.Usage
+		case "message_delta":
+			// Output tokens are cumulative; input tokens only appear here
+			// in some API versions.
+			usage.OutputTokens = event.Usage.OutputTokens
+			if event.Usage.InputTokens > 0 {
+				usage.InputTokens = event.Usage.InputTokens
+			}
+		case "error":
+			if tw.written {
+				fmt.Fprintln(w)
+			}
+			e := &APIError{Provider: "Anthropic", Type: "error", Message: data, partial: tw.written}
+			if event.Error != nil {
+				e.Type, e.Message = event.Error.Type, event.Error.Message
+			}
+			return usage, e
+		}
+	}
+
+	fmt.Fprintln(w)
+	return usage, scanner.Err()
+}
@@ -0,0 +1,134 @@
+package ai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// DefaultCacheTTL is how long cached answers are reused when cache.ttl isn't
+// set.
+const DefaultCacheTTL = time.Hour
+
+// CacheDir returns the default cache location, ~/.argus/cache.
+func CacheDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".argus", "cache")
+}
+
+// Cache stores answers on disk, addressed by a hash of everything that shaped
+// them: the model settings, system prompt, messages and tools. A nil *Cache
+// stores nothing.
+type Cache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewCache creates a cache in dir whose entries expire after ttl.
+func NewCache(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// newCache creates the cache a profile asks for, or nil when it has no cache
+// directory or caching is disabled.
+func newCache(p types.AIProfile) (*Cache, error) {
+	if p.CacheDir == "" || (p.Cache != nil && p.Cache.Disabled) {
+		return nil, nil
+	}
+	ttl := DefaultCacheTTL
+	if p.Cache != nil && p.Cache.TTL != "" {
+		d, err := time.ParseDuration(p.Cache.TTL)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("cache ttl %q: want a positive duration such as 30m", p.Cache.TTL)
+		}
+		ttl = d
+	}
+	return NewCache(p.CacheDir, ttl), nil
+}
+
+type cacheEntry struct {
+	StoredAt time.Time       `json:"stored_at"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// cacheKey hashes the parts of a request.
+func cacheKey(parts ...interface{}) string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, p := range parts {
+		enc.Encode(p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// get decodes the entry for key into v. Expired entries are removed.
+func (c *Cache) get(key string, v interface{}) bool {
+	if c == nil {
+		return false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return false
+	}
+	if c.now().Sub(e.StoredAt) > c.ttl {
+		os.Remove(c.path(key))
+		return false
+	}
+	return json.Unmarshal(e.Value, v) == nil
+}
+
+// put stores v under key, replacing any previous entry.
+func (c *Cache) put(key string, v interface{}) error {
+	if c == nil {
+		return nil
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(cacheEntry{StoredAt: c.now(), Value: value})
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(c.path(key))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	// Write and rename, so a concurrent run never reads half an entry.
+	f, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), c.path(key))
+}
+
+// remove deletes the entry for key, if any.
+func (c *Cache) remove(key string) {
+	if c == nil || key == "" {
+		return
+	}
+	os.Remove(c.path(key))
+}
@@ -0,0 +1,187 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// cachedAnalyzer returns an analyzer with a cache in a temp dir and a count of
+// the requests that reached the provider.
+func cachedAnalyzer(t *testing.T, p types.AIProfile, body string) (*Analyzer, *int) {
+	calls := new(int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	p.APIKey, p.BaseURL = "k", server.URL
+	if p.CacheDir == "" {
+		p.CacheDir = t.TempDir()
+	}
+	a, err := FromProfile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, calls
+}
+
+func TestCacheReusesAnswers(t *testing.T) {
+	a, calls := cachedAnalyzer(t, types.AIProfile{}, okStream)
+	for i := 0; i < 2; i++ {
+		var buf bytes.Buffer
+		if err := a.Analyze(context.Background(), "why?", &buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != "ok\n" {
+			t.Errorf("call %d: got %q", i, buf.String())
+		}
+	}
+	if *calls != 1 {
+		t.Errorf("expected the second call to come from the cache, got %d requests", *calls)
+	}
+	if u := a.Usage(); u.Cached != 1 || u.InputTokens != 120 {
+		t.Errorf("unexpected usage %+v", u)
+	}
+	if s := a.Usage().String(); !strings.HasSuffix(s, " · 1 from cache") {
+		t.Errorf("got %q", s)
+	}
+
+	// A different prompt is a different entry.
+	a.Analyze(context.Background(), "and now?", &bytes.Buffer{})
+	if *calls != 2 {
+		t.Errorf("expected a new prompt to reach the provider, got %d requests", *calls)
+	}
+}
+
+func TestCacheKeyedOnModel(t *testing.T) {
+	dir := t.TempDir()
+	a, calls := cachedAnalyzer(t, types.AIProfile{CacheDir: dir, Model: "small"}, okStream)
+	a.Analyze(context.Background(), "why?", &bytes.Buffer{})
+	b, _ := cachedAnalyzer(t, types.AIProfile{CacheDir: dir, Model: "large"}, okStream)
+	b.Analyze(context.Background(), "why?", &bytes.Buffer{})
+	if *calls != 1 || b.Usage().Cached != 0 {
+		t.Error("another model should not reuse the answer")
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	a, calls := cachedAnalyzer(t, types.AIProfile{Cache: &types.CacheConfig{TTL: "30m"}}, okStream)
+	a.Analyze(context.Background(), "why?", &bytes.Buffer{})
+	a.cache.now = func() time.Time { return time.Now().Add(31 * time.Minute) }
+	a.Analyze(context.Background(), "why?", &bytes.Buffer{})
+	if *calls != 2 {
+		t.Errorf("expected an expired answer to be asked for again, got %d requests", *calls)
+	}
+}
+
+func TestNoCacheRefreshes(t *testing.T) {
+	dir := t.TempDir()
+	a, calls := cachedAnalyzer(t, types.AIProfile{CacheDir: dir}, okStream)
+	a.Analyze(context.Background(), "why?", &bytes.Buffer{})
+	a.refresh = true
+	a.Analyze(context.Background(), "why?", &bytes.Buffer{})
+	if *calls != 2 {
+		t.Errorf("--no-cache should ask the provider, got %d requests", *calls)
+	}
+	a.refresh = false
+	a.Analyze(context.Background(), "why?", &bytes.Buffer{})
+	if *calls != 2 {
+		t.Errorf("the fresh answer should still be stored, got %d requests", *calls)
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	a, calls := cachedAnalyzer(t, types.AIProfile{Cache: &types.CacheConfig{Disabled: true}}, okStream)
+	a.Analyze(context.Background(), "why?", &bytes.Buffer{})
+	a.Analyze(context.Background(), "why?", &bytes.Buffer{})
+	if *calls != 2 || a.cache != nil {
+		t.Errorf("expected no caching, got %d requests", *calls)
+	}
+
+	if _, err := FromProfile(types.AIProfile{APIKey: "k", CacheDir: t.TempDir(), Cache: &types.CacheConfig{TTL: "soon"}}); err == nil {
+		t.Error("expected an error for an invalid ttl")
+	}
+}
+
+func TestConverseCached(t *testing.T) {
+	var rec types.Recording
+	body := `{"stop_reason":"end_turn","content":[{"type":"text","text":"done"}],"usage":{"input_tokens":50,"output_tokens":5}}`
+	a, calls := cachedAnalyzer(t, types.AIProfile{Recording: &rec}, body)
+	msgs := []ToolMessage{{Role: "user", Content: []ContentBlock{{Type: "text", Text: "hi"}}}}
+	for i := 0; i < 2; i++ {
+		resp, err := a.Converse(context.Background(), "sys", msgs, nil, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text() != "done" {
+			t.Errorf("call %d: got %q", i, resp.Text())
+		}
+	}
+	if *calls != 1 {
+		t.Errorf("expected one request, got %d", *calls)
+	}
+	if u := a.Usage(); u.InputTokens != 50 || u.Cached != 1 {
+		t.Errorf("cached turns should not count tokens, got %+v", u)
+	}
+
+	if len(rec.Exchanges) != 2 || rec.Exchanges[0].Cached || !rec.Exchanges[1].Cached {
+		t.Fatalf("expected a fresh and a cached exchange, got %+v", rec.Exchanges)
+	}
+	var resp ToolResponse
+	if err := json.Unmarshal(rec.Exchanges[0].Response, &resp); err != nil || resp.Text() != "done" {
+		t.Errorf("unexpected recorded response %s", rec.Exchanges[0].Response)
+	}
+	if rec.Exchanges[0].System != "sys" || !strings.Contains(string(rec.Exchanges[0].Messages), `"hi"`) {
+		t.Errorf("unexpected recorded request %+v", rec.Exchanges[0])
+	}
+}
+
+func TestRecordingWithoutCache(t *testing.T) {
+	var rec types.Recording
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(okStream))
+	}))
+	defer server.Close()
+
+	a, err := FromProfile(types.AIProfile{APIKey: "k", BaseURL: server.URL, Recording: &rec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.cache != nil {
+		t.Error("no cache directory should mean no cache")
+	}
+	a.Analyze(context.Background(), "why?", &bytes.Buffer{})
+	if len(rec.Exchanges) != 1 || rec.Exchanges[0].Answer != "ok\n" || rec.Exchanges[0].Response != nil {
+		t.Errorf("unexpected recording %+v", rec.Exchanges)
+	}
+}
+
+func TestCacheSkipsInvalidAssessments(t *testing.T) {
+	server, sent := assessServer(t, "I think it's the database.", `{"severity": "meh"}`, validAssessment)
+	a, err := FromProfile(types.AIProfile{APIKey: "k", BaseURL: server.URL, CacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.Assess(context.Background(), "why?"); err == nil {
+		t.Fatal("expected an invalid assessment")
+	}
+	if _, err := a.Assess(context.Background(), "why?"); err != nil {
+		t.Fatalf("a rejected answer should not be served from the cache: %v", err)
+	}
+	if len(*sent) != 3 {
+		t.Errorf("expected the first question to be asked again, got %d requests", len(*sent))
+	}
+	if _, err := a.Assess(context.Background(), "why?"); err != nil || len(*sent) != 3 {
+		t.Errorf("expected the valid answer from the cache, got %d requests, %v", len(*sent), err)
+	}
+}
@@ -0,0 +1,308 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+const openAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAI is an LLMProvider for OpenAI-compatible chat completions APIs,
+// which includes local servers such as Ollama, llama.cpp and vLLM.
+type OpenAI struct {
+	profile types.AIProfile
+	url     string
+	client  *http.Client
+	retry   retrier
+}
+
+// NewOpenAI creates an OpenAI-compatible provider. The base URL defaults to
+// OpenAI's; for local servers it is usually something like
+// http://localhost:11434/v1. The API key is optional.
+func NewOpenAI(p types.AIProfile) *OpenAI {
+	return &OpenAI{
+		profile: p,
+		url:     endpoint(p.BaseURL, openAIBaseURL, "/chat/completions"),
+		client:  &http.Client{},
+		retry:   defaultRetrier(),
+	}
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"` // always "function"
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON-encoded
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string `json:"type"` // always "function"
+	Function struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Parameters  json.RawMessage `json:"parameters"`
+	} `json:"function"`
+}
+
+type openAIRequest struct {
+	Model         string          `json:"model"`
+	Messages      []openAIMessage `json:"messages"`
+	MaxTokens     int             `json:"max_tokens"`
+	Temperature   *float64        `json:"temperature,omitempty"`
+	Stream        bool            `json:"stream,omitempty"`
+	StreamOptions *streamOptions  `json:"stream_options,omitempty"`
+	Tools         []openAITool    `json:"tools,omitempty"`
+	ToolChoice    string          `json:"tool_choice,omitempty"` // "auto" or "none"
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (u *openAIUsage) usage() Usage {
+	if u == nil {
+		return Usage{}
+	}
+	return Usage{InputTokens: u.PromptTokens, OutputTokens: u.CompletionTokens}
+}
+
+// Stream implements LLMProvider. Rate-limited or overloaded requests are
+// retried, as long as no part of the answer was written yet.
+func (o *OpenAI) Stream(ctx context.Context, system string, messages []Message, w io.Writer) (Usage, error) {
+	msgs := []openAIMessage{{Role: "system", Content: system}}
+	for _, m := range messages {
+		msgs = append(msgs, openAIMessage{Role: m.Role, Content: m.Content})
+	}
+	reqBody := openAIRequest{
+		Model:         o.profile.Model,
+		Messages:      msgs,
+		MaxTokens:     maxTokens(o.profile),
+		Temperature:   o.profile.Temperature,
+		Stream:        true,
+		StreamOptions: &streamOptions{IncludeUsage: true},
+	}
+	var usage Usage
+	err := o.retry.do(ctx, func() error {
+		resp, err := o.post(ctx, reqBody)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		usage, err = o.streamResponse(resp.Body, w)
+		return err
+	})
+	return usage, err
+}
+
+// streamResponse writes the content deltas of an SSE stream to w and returns
+// the usage of the final chunk, if the server sends one. An error object in
+// the stream ends it with an *APIError.
+func (o *OpenAI) streamResponse(body io.Reader, w io.Writer) (Usage, error) {
+	var usage Usage
+	tw := &trackingWriter{w: w}
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
+			errorBody
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		if chunk.Error != nil {
+			if tw.written {
+				fmt.Fprintln(w)
+			}
+			return usage, &APIError{Provider: o.url, Type: chunk.Error.Type, Message: chunk.Error.Message, partial: tw.written}
+		}
+		for _, c := range chunk.Choices {
+			fmt.Fprint(tw, c.Delta.Content)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.usage()
+		}
+	}
+
+	fmt.Fprintln(w)
+	return usage, scanner.Err()
+}
+
+// Converse implements LLMProvider by translating between Anthropic-style
+// content blocks and OpenAI function calling.
+func (o *OpenAI) Converse(ctx context.Context, system string, messages []ToolMessage, tools []Tool, allowTools bool) (*ToolResponse, error) {
+	reqBody := openAIRequest{
+		Model:       o.profile.Model,
+		Messages:    append([]openAIMessage{{Role: "system", Content: system}}, toOpenAIMessages(messages)...),
+		MaxTokens:   maxTokens(o.profile),
+		Temperature: o.profile.Temperature,
+		ToolChoice:  "auto",
+	}
+	for _, t := range tools {
+		var ot openAITool
+		ot.Type = "function"
+		ot.Function.Name = t.Name
+		ot.Function.Description = t.Description
+		ot.Function.Parameters = t.InputSchema
+		reqBody.Tools = append(reqBody.Tools, ot)
+	}
+	if !allowTools {
+		reqBody.ToolChoice = "none"
+	}
+
+	var out struct {
+		Choices []struct {
+			Message      openAIMessage `json:"message"`
+			FinishReason string        `json:"finish_reason"`
+		} `json:"choices"`
+		Usage *openAIUsage `json:"usage"`
+	}
+	err := o.retry.do(ctx, func() error {
+		resp, err := o.post(ctx, reqBody)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return fmt.Errorf("parsing response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Choices) == 0 {
+		return nil, fmt.Errorf("parsing response: no choices returned")
+	}
+	resp := fromOpenAIMessage(out.Choices[0].Message, out.Choices[0].FinishReason)
+	resp.Usage = out.Usage.usage()
+	return resp, nil
+}
+
+// toOpenAIMessages flattens content blocks: text becomes message content,
+// tool_use blocks become tool_calls and each tool_result its own "tool"
+// message.
+func toOpenAIMessages(messages []ToolMessage) []openAIMessage {
+	var out []openAIMessage
+	for _, m := range messages {
+		msg := openAIMessage{Role: m.Role}
+		var results []openAIMessage
+		for _, b := range m.Content {
+			switch b.Type {
+			case "text":
+				msg.Content += b.Text
+			case "tool_use":
+				var call openAIToolCall
+				call.ID = b.ID
+				call.Type = "function"
+				call.Function.Name = b.Name
+				call.Function.Arguments = string(b.Input)
+				msg.ToolCalls = append(msg.ToolCalls, call)
+			case "tool_result":
+				content := b.Content
+				if b.IsError {
+					content = "error: " + content
+				}
+				results = append(results, openAIMessage{Role: "tool", ToolCallID: b.ToolUseID, Content: content})
+			}
+		}
+		if msg.Content != "" || len(msg.ToolCalls) > 0 {
+			out = append(out, msg)
+		}
+		out = append(out, results...)
+	}
+	return out
+}
+
+func fromOpenAIMessage(m openAIMessage, finishReason string) *ToolResponse {
+	resp := &ToolResponse{StopReason: "end_turn"}
+	switch finishReason {
+	case "tool_calls":
+		resp.StopReason = "tool_use"
+	case "length":
+		resp.StopReason = "max_tokens"
+	}
+	if m.Content != "" {
+		resp.Content = append(resp.Content, ContentBlock{Type: "text", Text: m.Content})
+	}
+	for _, c := range m.ToolCalls {
+		input := json.RawMessage(c.Function.Arguments)
+		if strings.TrimSpace(c.Function.Arguments) == "" {
+			input = json.RawMessage(`{}`)
+		} else if !json.Valid(input) {
+			// Keep malformed arguments as a string so the tool reports the
+			// problem back to the model instead of the request failing.
+			input, _ = json.Marshal(c.Function.Arguments)
+		}
+		resp.Content = append(resp.Content, ContentBlock{Type: "tool_use", ID: c.ID, Name: c.Function.Name, Input: input})
+	}
+	if len(m.ToolCalls) > 0 {
+		resp.StopReason = "tool_use"
+	}
+	return resp
+}
+
+func (o *OpenAI) post(ctx context.Context, reqBody openAIRequest) (*http.Response, error) {
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if o.profile.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.profile.APIKey)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calling %s: %w", o.url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, statusError(o.url, resp)
+	}
+	return resp, nil
+}
@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+func TestOpenAIStream(t *testing.T) {
+	var got openAIRequest
+	var path, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"Hello \"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"world\"}}]}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	temp := 0.1
+	a, err := FromProfile(types.AIProfile{Provider: types.ProviderOpenAI, BaseURL: server.URL + "/v1", Model: "llama3.1", MaxTokens: 512, Temperature: &temp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := a.AnalyzeWithHistory(context.Background(), "sys", []Message{{Role: "user", Content: "hi"}}, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if buf.String() != "Hello world\n" {
+		t.Errorf("got %q", buf.String())
+	}
+	if path != "/v1/chat/completions" {
+		t.Errorf("expected /v1/chat/completions, got %s", path)
+	}
+	if auth != "" {
+		t.Errorf("no Authorization header expected without a key, got %q", auth)
+	}
+	if got.Model != "llama3.1" || got.MaxTokens != 512 || *got.Temperature != 0.1 || !got.Stream {
+		t.Errorf("unexpected request %+v", got)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Role != "system" || got.Messages[0].Content != "sys" || got.Messages[1].Content != "hi" {
+		t.Errorf("unexpected messages %+v", got.Messages)
+	}
+}
+
+func TestOpenAIConverse(t *testing.T) {
+	var got openAIRequest
+	var auth string
+	reply := `{"choices":[{"finish_reason":"tool_calls","message":{"role":"assistant","content":"looking","tool_calls":[{"id":"c1","type":"function","function":{"name":"list_services","arguments":"{\"limit\":5}"}}]}}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(reply))
+	}))
+	defer server.Close()
+
+	a, _ := FromProfile(types.AIProfile{Provider: types.ProviderOpenAI, APIKey: "sk", BaseURL: server.URL, Model: "gpt-4o"})
+	tools := []Tool{{Name: "list_services", Description: "d", InputSchema: json.RawMessage(`{"type":"object"}`)}}
+	msgs := []ToolMessage{
+		{Role: "user", Content: []ContentBlock{{Type: "text", Text: "hi"}}},
+		{Role: "assistant", Content: []ContentBlock{{Type: "text", Text: "checking"}, {Type: "tool_use", ID: "c0", Name: "list_services", Input: json.RawMessage(`{}`)}}},
+		{Role: "user", Content: []ContentBlock{{Type: "tool_result", ToolUseID: "c0", Content: "boom", IsError: true}}},
+	}
+	resp, err := a.Converse(context.Background(), "sys", msgs, tools, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if auth != "Bearer sk" {
+		t.Errorf("expected bearer auth, got %q", auth)
+	}
+	if resp.StopReason != "tool_use" || resp.Text() != "looking" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if calls := resp.ToolCalls(); len(calls) != 1 || calls[0].ID != "c1" || string(calls[0].Input) != `{"limit":5}` {
+		t.Errorf("unexpected tool calls %+v", calls)
+	}
+
+	if got.ToolChoice != "auto" || len(got.Tools) != 1 || got.Tools[0].Function.Name != "list_services" {
+		t.Errorf("unexpected tools %+v", got)
+	}
+	if len(got.Messages) != 4 {
+		t.Fatalf("expected system, user, assistant and tool messages, got %+v", got.Messages)
+	}
+	if m := got.Messages[2]; m.Content != "checking" || len(m.ToolCalls) != 1 || m.ToolCalls[0].Function.Arguments != "{}" {
+		t.Errorf("unexpected assistant message %+v", m)
+	}
+	if m := got.Messages[3]; m.Role != "tool" || m.ToolCallID != "c0" || m.Content != "error: boom" {
+		t.Errorf("unexpected tool message %+v", m)
+	}
+
+	reply = `{"choices":[{"finish_reason":"stop","message":{"role":"assistant","content":"done"}}]}`
+	resp, err = a.Converse(context.Background(), "sys", msgs, tools, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ToolChoice != "none" || resp.StopReason != "end_turn" || resp.Text() != "done" {
+		t.Errorf("expected a forced text answer, got %+v / %+v", got.ToolChoice, resp)
+	}
+}
@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetries = 3
+	baseBackoff    = time.Second
+	maxBackoff     = 30 * time.Second
+)
+
+// APIError is an error returned by a model API, either as an HTTP status or
+// as an error event in a stream.
+type APIError struct {
+	Provider   string // "Anthropic" or the chat completions endpoint
+	Status     int    // HTTP status, 0 for stream errors
+	Type       string // e.g. "overloaded_error", "rate_limit_error"
+	Message    string
+	RetryAfter time.Duration // from the retry-after header, if any
+
+	// partial is set when the error arrived after part of the answer was
+	// written, which makes the call unsafe to retry.
+	partial bool
+}
+
+func (e *APIError) Error() string {
+	switch {
+	case e.Status != 0 && e.Type != "":
+		return fmt.Sprintf("%s API error (status %d, %s): %s", e.Provider, e.Status, e.Type, e.Message)
+	case e.Status != 0:
+		return fmt.Sprintf("%s API error (status %d): %s", e.Provider, e.Status, e.Message)
+	default:
+		return fmt.Sprintf("%s stream error (%s): %s", e.Provider, e.Type, e.Message)
+	}
+}
+
+// Retryable reports whether the request can be repeated: the API was rate
+// limited or overloaded, and nothing of the answer was written yet.
+func (e *APIError) Retryable() bool {
+	if e.partial {
+		return false
+	}
+	switch e.Status {
+	case http.StatusTooManyRequests, 529, http.StatusServiceUnavailable:
+		return true
+	}
+	return e.Type == "overloaded_error" || e.Type == "rate_limit_error"
+}
+
+// errorBody is the error object both APIs use in error responses and
+// stream events.
+type errorBody struct {
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// statusError reads a failed response into an APIError.
+func statusError(provider string, resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	e := &APIError{Provider: provider, Status: resp.StatusCode, Message: string(body), RetryAfter: retryAfter(resp.Header.Get("retry-after"))}
+	var eb errorBody
+	if json.Unmarshal(body, &eb) == nil && eb.Error != nil && eb.Error.Message != "" {
+		e.Type, e.Message = eb.Error.Type, eb.Error.Message
+	}
+	return e
+}
+
+// retryAfter parses a retry-after header, in seconds or as an HTTP date.
+func retryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// retrier repeats calls that fail with a retryable APIError, waiting for the
+// server's retry-after or an exponential backoff.
+type retrier struct {
+	retries int
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+func defaultRetrier() retrier {
+	return retrier{retries: defaultRetries, sleep: sleepCtx}
+}
+
+func (r retrier) do(ctx context.Context, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := call()
+		var apiErr *APIError
+		if err == nil || !errors.As(err, &apiErr) || !apiErr.Retryable() || attempt >= r.retries {
+			return err
+		}
+		wait := apiErr.RetryAfter
+		if wait <= 0 {
+			wait = baseBackoff << attempt
+		}
+		if wait > maxBackoff {
+			wait = maxBackoff
+		}
+		if err := r.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// trackingWriter remembers whether anything was written through it.
+type trackingWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		t.written = true
+	}
+	return t.w.Write(p)
+}
+
+// Usage counts the tokens of one or more model calls.
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	Cached       int `json:"cached,omitempty"` // answers served from the cache
+}
+
+// Add accumulates another call's usage.
+func (u *Usage) Add(o Usage) {
+	u.InputTokens += o.InputTokens
+	u.OutputTokens += o.OutputTokens
+	u.Cached += o.Cached
+}
+
+// Empty reports whether no usage was reported.
+func (u Usage) Empty() bool {
+	return u.InputTokens == 0 && u.OutputTokens == 0 && u.Cached == 0
+}
+
+func (u Usage) String() string {
+	s := fmt.Sprintf("%d input · %d output tokens", u.InputTokens, u.OutputTokens)
+	if u.Cached > 0 {
+		s += fmt.Sprintf(" · %d from cache", u.Cached)
+	}
+	return s
+}
@@ -0,0 +1,262 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// fakeSleep records the waits of a retrier instead of sleeping.
+func fakeSleep(waits *[]time.Duration) func(context.Context, time.Duration) error {
+	return func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return ctx.Err()
+	}
+}
+
+func testAnthropic(url string, waits *[]time.Duration) *Analyzer {
+	p := NewAnthropic(types.AIProfile{APIKey: "k", BaseURL: url})
+	p.retry.sleep = fakeSleep(waits)
+	return NewWithProvider(p)
+}
+
+const okStream = "data: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":120,\"output_tokens\":1}}}\n\n" +
+	"data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"ok\"}}\n\n" +
+	"data: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":15}}\n\n" +
+	"data: {\"type\":\"message_stop\"}\n\n"
+
+// ──────────────────────────────────────────────
+// Retries
+// ──────────────────────────────────────────────
+
+func TestRetryRateLimitHonoursRetryAfter(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("retry-after", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`))
+			return
+		}
+		w.Write([]byte(okStream))
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	var buf bytes.Buffer
+	if err := testAnthropic(server.URL, &waits).Analyze(context.Background(), "hi", &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 || buf.String() != "ok\n" {
+		t.Errorf("expected a retried answer, got %d calls and %q", calls, buf.String())
+	}
+	if len(waits) != 1 || waits[0] != 7*time.Second {
+		t.Errorf("expected to wait the retry-after of 7s, got %v", waits)
+	}
+}
+
+func TestRetryOverloadedBacksOff(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= 2 {
+			w.WriteHeader(529)
+			w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+			return
+		}
+		w.Write([]byte(`{"stop_reason":"end_turn","content":[{"type":"text","text":"done"}]}`))
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	resp, err := testAnthropic(server.URL, &waits).Converse(context.Background(), "sys", nil, nil, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Text() != "done" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if len(waits) != 2 || waits[0] != baseBackoff || waits[1] != 2*baseBackoff {
+		t.Errorf("expected exponential backoff, got %v", waits)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(529)
+		w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	err := testAnthropic(server.URL, &waits).Analyze(context.Background(), "hi", &bytes.Buffer{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != 529 || apiErr.Type != "overloaded_error" {
+		t.Fatalf("expected an overloaded APIError, got %v", err)
+	}
+	if calls != defaultRetries+1 {
+		t.Errorf("expected %d attempts, got %d", defaultRetries+1, calls)
+	}
+}
+
+func TestNoRetryOnBadRequest(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens too large"}}`))
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	err := testAnthropic(server.URL, &waits).Analyze(context.Background(), "hi", &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "max_tokens too large") {
+		t.Fatalf("expected the API's error message, got %v", err)
+	}
+	if calls != 1 || len(waits) != 0 {
+		t.Errorf("expected no retries, got %d calls", calls)
+	}
+}
+
+func TestRetryStopsOnCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a := NewAnthropic(types.AIProfile{APIKey: "k", BaseURL: server.URL})
+	a.retry.sleep = func(context.Context, time.Duration) error {
+		cancel()
+		return sleepCtx(ctx, time.Hour)
+	}
+	err := NewWithProvider(a).Analyze(ctx, "hi", &bytes.Buffer{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d := retryAfter("2.5"); d != 2500*time.Millisecond {
+		t.Errorf("got %v", d)
+	}
+	if d := retryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); d < 58*time.Second || d > time.Minute {
+		t.Errorf("expected about a minute, got %v", d)
+	}
+	if d := retryAfter("soon"); d != 0 {
+		t.Errorf("got %v", d)
+	}
+}
+
+// ──────────────────────────────────────────────
+// Stream errors and usage
+// ──────────────────────────────────────────────
+
+func TestStreamErrorEvent(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Write([]byte("event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n"))
+			return
+		}
+		w.Write([]byte(okStream))
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	var buf bytes.Buffer
+	if err := testAnthropic(server.URL, &waits).Analyze(context.Background(), "hi", &buf); err != nil {
+		t.Fatalf("expected an overloaded stream to be retried, got %v", err)
+	}
+	if calls != 2 || buf.String() != "ok\n" {
+		t.Errorf("got %d calls and %q", calls, buf.String())
+	}
+}
+
+func TestStreamErrorAfterOutputNotRetried(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte("data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"The cause\"}}\n\n"))
+		w.Write([]byte("data: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n"))
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	var buf bytes.Buffer
+	err := testAnthropic(server.URL, &waits).Analyze(context.Background(), "hi", &buf)
+	if err == nil || !strings.Contains(err.Error(), "Anthropic stream error (overloaded_error): Overloaded") {
+		t.Fatalf("expected the stream error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("a partly written answer must not be retried, got %d calls", calls)
+	}
+	if buf.String() != "The cause\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestStreamUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(okStream))
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	a := testAnthropic(server.URL, &waits)
+	for i := 0; i < 2; i++ {
+		if err := a.Analyze(context.Background(), "hi", &bytes.Buffer{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if u := a.Usage(); u != (Usage{InputTokens: 240, OutputTokens: 30}) {
+		t.Errorf("expected usage summed over both calls, got %+v", u)
+	}
+	if s := a.Usage().String(); s != "240 input · 30 output tokens" {
+		t.Errorf("got %q", s)
+	}
+}
+
+func TestOpenAIStreamUsageAndError(t *testing.T) {
+	var got openAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "broken") {
+			w.Write([]byte("data: {\"error\":{\"type\":\"server_error\",\"message\":\"model crashed\"}}\n\n"))
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\":[],\"usage\":{\"prompt_tokens\":11,\"completion_tokens\":3}}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	a, _ := FromProfile(types.AIProfile{Provider: types.ProviderOpenAI, BaseURL: server.URL, Model: "m"})
+	if err := a.Analyze(context.Background(), "hi", &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	if got.StreamOptions == nil || !got.StreamOptions.IncludeUsage {
+		t.Errorf("expected stream_options.include_usage, got %+v", got)
+	}
+	if u := a.Usage(); u != (Usage{InputTokens: 11, OutputTokens: 3}) {
+		t.Errorf("unexpected usage %+v", u)
+	}
+
+	a, _ = FromProfile(types.AIProfile{Provider: types.ProviderOpenAI, BaseURL: server.URL + "/broken", Model: "m"})
+	err := a.Analyze(context.Background(), "hi", &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "model crashed") {
+		t.Errorf("expected the stream error, got %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Severities of an Assessment, most severe first.
+var Severities = []string{"critical", "high", "medium", "low", "none"}
+
+// Assessment is the structured answer requested by --format json.
+type Assessment struct {
+	Severity         string      `json:"severity"`
+	Summary          string      `json:"summary"`
+	RootCauses       []RootCause `json:"root_causes"`
+	AffectedServices []string    `json:"affected_services"`
+	Evidence         []Evidence  `json:"evidence"`
+	Actions          []Action    `json:"recommended_actions"`
+}
+
+// RootCause is one suspected cause, most likely first.
+type RootCause struct {
+	Description string   `json:"description"`
+	Confidence  float64  `json:"confidence"` // 0-1
+	Services    []string `json:"services,omitempty"`
+}
+
+// Evidence points at the data an answer relies on.
+type Evidence struct {
+	Type      string `json:"type"` // "log", "trace" or "metric"
+	Service   string `json:"service,omitempty"`
+	Timestamp string `json:"timestamp,omitempty"` // as it appeared in the prompt
+	TraceID   string `json:"trace_id,omitempty"`
+	Ref       string `json:"ref,omitempty"` // reference ID from the prompt, e.g. "L3"
+	Detail    string `json:"detail"`
+	Link      string `json:"link,omitempty"` // Signoz UI link, filled in by argus
+}
+
+// Action is a recommended next step, most urgent first.
+type Action struct {
+	Action   string `json:"action"`
+	Priority string `json:"priority"` // "high", "medium" or "low"
+}
+
+// AssessmentSchema is the JSON Schema the model is asked to follow;
+// ParseAssessment enforces it.
+const AssessmentSchema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["severity", "summary", "root_causes", "affected_services", "evidence", "recommended_actions"],
+  "properties": {
+    "severity": {"enum": ["critical", "high", "medium", "low", "none"]},
+    "summary": {"type": "string", "minLength": 1},
+    "root_causes": {"type": "array", "items": {
+      "type": "object", "additionalProperties": false, "required": ["description", "confidence"],
+      "properties": {
+        "description": {"type": "string", "minLength": 1},
+        "confidence": {"type": "number", "minimum": 0, "maximum": 1},
+        "services": {"type": "array", "items": {"type": "string"}}
+      }}},
+    "affected_services": {"type": "array", "items": {"type": "string"}},
+    "evidence": {"type": "array", "items": {
+      "type": "object", "additionalProperties": false, "required": ["type", "detail"],
+      "properties": {
+        "type": {"enum": ["log", "trace", "metric"]},
+        "service": {"type": "string"},
+        "timestamp": {"type": "string", "description": "required for logs"},
+        "trace_id": {"type": "string", "description": "required for traces"},
+        "ref": {"type": "string", "description": "reference ID such as L3 or T1, when the data is tagged with one"},
+        "detail": {"type": "string", "minLength": 1}
+      }}},
+    "recommended_actions": {"type": "array", "items": {
+      "type": "object", "additionalProperties": false, "required": ["action", "priority"],
+      "properties": {
+        "action": {"type": "string", "minLength": 1},
+        "priority": {"enum": ["high", "medium", "low"]}
+      }}}
+  }
+}`
+
+// StructuredRequest asks for an Assessment instead of prose. It goes at the
+// end of a prompt, after any other answer instructions.
+const StructuredRequest = `
+
+## Answer Format
+
+Instead of markdown, answer with a single JSON object that follows this JSON Schema. Cover the same analysis: severity, suspected root causes with your confidence (0-1), affected services, evidence that references log timestamps, trace IDs and reference IDs exactly as they appear above, and recommended actions. Output only the JSON object, with no code fence or other text.
+
+` + AssessmentSchema
+
+// RetryRequest is the follow-up message asking the model to fix an answer
+// that failed ParseAssessment.
+func RetryRequest(err error) string {
+	return fmt.Sprintf("That answer is not valid: %v. Reply with only the corrected JSON object, following the schema.", err)
+}
+
+// ParseAssessment extracts the JSON object from a model's answer, tolerating
+// a code fence or text around it, and validates it against AssessmentSchema.
+func ParseAssessment(answer string) (*Assessment, error) {
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object in the answer")
+	}
+	dec := json.NewDecoder(strings.NewReader(answer[start : end+1]))
+	dec.DisallowUnknownFields()
+	var a Assessment
+	if err := dec.Decode(&a); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (a *Assessment) validate() error {
+	if !oneOf(a.Severity, Severities...) {
+		return fmt.Errorf("severity %q must be one of %s", a.Severity, strings.Join(Severities, ", "))
+	}
+	if strings.TrimSpace(a.Summary) == "" {
+		return fmt.Errorf("summary is required")
+	}
+	for i, rc := range a.RootCauses {
+		if strings.TrimSpace(rc.Description) == "" {
+			return fmt.Errorf("root_causes[%d].description is required", i)
+		}
+		if rc.Confidence < 0 || rc.Confidence > 1 {
+			return fmt.Errorf("root_causes[%d].confidence %g must be between 0 and 1", i, rc.Confidence)
+		}
+	}
+	for i, e := range a.Evidence {
+		switch {
+		case !oneOf(e.Type, "log", "trace", "metric"):
+			return fmt.Errorf("evidence[%d].type %q must be log, trace or metric", i, e.Type)
+		case strings.TrimSpace(e.Detail) == "":
+			return fmt.Errorf("evidence[%d].detail is required", i)
+		case e.Type == "log" && e.Timestamp == "":
+			return fmt.Errorf("evidence[%d] is a log and needs a timestamp", i)
+		case e.Type == "trace" && e.TraceID == "":
+			return fmt.Errorf("evidence[%d] is a trace and needs a trace_id", i)
+		}
+	}
+	for i, act := range a.Actions {
+		if strings.TrimSpace(act.Action) == "" {
+			return fmt.Errorf("recommended_actions[%d].action is required", i)
+		}
+		if !oneOf(act.Priority, "high", "medium", "low") {
+			return fmt.Errorf("recommended_actions[%d].priority %q must be high, medium or low", i, act.Priority)
+		}
+	}
+	// Lists the schema requires may be empty but not missing.
+	if a.RootCauses == nil || a.AffectedServices == nil || a.Evidence == nil || a.Actions == nil {
+		return fmt.Errorf("root_causes, affected_services, evidence and recommended_actions are required (use [] when empty)")
+	}
+	return nil
+}
+
+func oneOf(v string, options ...string) bool {
+	for _, o := range options {
+		if v == o {
+			return true
+		}
+	}
+	return false
+}
+
+// Assess sends prompt with StructuredRequest appended and returns the
+// validated Assessment. An answer that fails validation is sent back once
+// with the error so the model can correct it, and is never kept in the cache.
+func (a *Analyzer) Assess(ctx context.Context, prompt string) (*Assessment, error) {
+	messages := []Message{{Role: "user", Content: prompt + StructuredRequest}}
+	var buf bytes.Buffer
+	if err := a.AnalyzeWithHistory(ctx, systemPrompt, messages, &buf); err != nil {
+		return nil, err
+	}
+	assessment, err := ParseAssessment(buf.String())
+	if err == nil {
+		return assessment, nil
+	}
+	a.Forget()
+
+	answer := strings.TrimSpace(buf.String())
+	if answer == "" {
+		answer = "(empty answer)"
+	}
+	messages = append(messages,
+		Message{Role: "assistant", Content: answer},
+		Message{Role: "user", Content: RetryRequest(err)})
+	buf.Reset()
+	if err := a.AnalyzeWithHistory(ctx, systemPrompt, messages, &buf); err != nil {
+		return nil, err
+	}
+	assessment, err = ParseAssessment(buf.String())
+	if err != nil {
+		a.Forget()
+		return nil, fmt.Errorf("model returned an invalid assessment twice: %w", err)
+	}
+	return assessment, nil
+}
@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const validAssessment = `{
+  "severity": "high",
+  "summary": "checkout fails because payments times out",
+  "root_causes": [{"description": "payments DB pool exhausted", "confidence": 0.7, "services": ["payments"]}],
+  "affected_services": ["checkout", "payments"],
+  "evidence": [
+    {"type": "log", "service": "payments", "timestamp": "14:02:11", "detail": "pool exhausted"},
+    {"type": "trace", "trace_id": "abc123", "detail": "POST /pay 5s"}
+  ],
+  "recommended_actions": [{"action": "raise the pool size", "priority": "high"}]
+}`
+
+func TestParseAssessment(t *testing.T) {
+	a, err := ParseAssessment("Here you go:\n```json\n" + validAssessment + "\n```")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.Severity != "high" || len(a.RootCauses) != 1 || a.RootCauses[0].Confidence != 0.7 ||
+		a.Evidence[1].TraceID != "abc123" || a.Actions[0].Priority != "high" {
+		t.Errorf("unexpected assessment %+v", a)
+	}
+}
+
+func TestParseAssessmentInvalid(t *testing.T) {
+	tests := []struct {
+		name, from, to, want string
+	}{
+		{"not json", validAssessment, "no idea", "no JSON object"},
+		{"severity", `"severity": "high"`, `"severity": "bad"`, "severity"},
+		{"confidence", `0.7`, `70`, "confidence"},
+		{"log timestamp", `"timestamp": "14:02:11", `, ``, "needs a timestamp"},
+		{"trace id", `"trace_id": "abc123", `, ``, "needs a trace_id"},
+		{"evidence type", `"type": "log"`, `"type": "dashboard"`, "evidence[0].type"},
+		{"priority", `"priority": "high"`, `"priority": "asap"`, "priority"},
+		{"unknown field", `"severity"`, `"mood": "grim", "severity"`, "unknown field"},
+		{"missing list", `"affected_services": ["checkout", "payments"],`, ``, "required"},
+		{"truncated", `}]
+}`, `}]`, "invalid JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer := strings.Replace(validAssessment, tt.from, tt.to, 1)
+			if _, err := ParseAssessment(answer); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// assessServer answers each request with the next reply as a stream and
+// records the messages it was sent.
+func assessServer(t *testing.T, replies ...string) (*httptest.Server, *[][]Message) {
+	t.Helper()
+	var sent [][]Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		json.NewDecoder(r.Body).Decode(&req)
+		sent = append(sent, req.Messages)
+		text, _ := json.Marshal(replies[0])
+		replies = replies[1:]
+		w.Write([]byte(`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":` + string(text) + "}}\n\n"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &sent
+}
+
+func TestAssessRetriesOnce(t *testing.T) {
+	server, sent := assessServer(t, `{"severity": "high"`, validAssessment)
+
+	a, err := NewWithURL("k", server.URL).Assess(context.Background(), "why is checkout failing?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.Summary == "" {
+		t.Errorf("unexpected assessment %+v", a)
+	}
+	if len(*sent) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(*sent))
+	}
+	if first := (*sent)[0]; !strings.Contains(first[0].Content, "Answer Format") {
+		t.Errorf("expected the schema in the prompt, got %q", first[0].Content)
+	}
+	retry := (*sent)[1]
+	if len(retry) != 3 || retry[1].Role != "assistant" || !strings.Contains(retry[2].Content, "not valid") {
+		t.Errorf("expected the invalid answer and the error to be sent back, got %+v", retry)
+	}
+}
+
+func TestAssessGivesUpAfterRetry(t *testing.T) {
+	server, sent := assessServer(t, "I think it's the database.", `{"severity": "meh"}`)
+
+	_, err := NewWithURL("k", server.URL).Assess(context.Background(), "why?")
+	if err == nil || !strings.Contains(err.Error(), "invalid assessment twice") {
+		t.Errorf("expected an error after the retry, got %v", err)
+	}
+	if len(*sent) != 2 {
+		t.Errorf("expected exactly one retry, got %d requests", len(*sent))
+	}
+}
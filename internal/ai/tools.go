@@ -0,0 +1,64 @@
+package ai
+
+import "encoding/json"
+
+// Tool describes a function the model may call. InputSchema is a JSON Schema
+// object describing the tool's input.
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// ContentBlock is one block of a tool-use conversation message: text, a
+// tool call made by the model, or the result the caller sends back.
+type ContentBlock struct {
+	Type string `json:"type"` // "text", "tool_use" or "tool_result"
+
+	Text string `json:"text,omitempty"`
+
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// tool_result
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+	IsError   bool   `json:"is_error,omitempty"`
+}
+
+// ToolMessage is a conversation message made of content blocks.
+type ToolMessage struct {
+	Role    string         `json:"role"`
+	Content []ContentBlock `json:"content"`
+}
+
+// ToolResponse is the model's reply to a tool-use request.
+type ToolResponse struct {
+	Content    []ContentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"` // "tool_use" when the model wants tool results
+	Usage      Usage          `json:"usage"`
+}
+
+// Text joins the response's text blocks.
+func (r *ToolResponse) Text() string {
+	var s string
+	for _, b := range r.Content {
+		if b.Type == "text" {
+			s += b.Text
+		}
+	}
+	return s
+}
+
+// ToolCalls returns the tool_use blocks of the response.
+func (r *ToolResponse) ToolCalls() []ContentBlock {
+	var calls []ContentBlock
+	for _, b := range r.Content {
+		if b.Type == "tool_use" {
+			calls = append(calls, b)
+		}
+	}
+	return calls
+}
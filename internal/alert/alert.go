@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lbarahona/argus/internal/ownership"
 	"github.com/lbarahona/argus/internal/signoz"
 	"github.com/lbarahona/argus/pkg/types"
 	"gopkg.in/yaml.v3"
@@ -110,6 +111,7 @@ type CheckResult struct {
 	Value    float64  `json:"value"`
 	Message  string   `json:"message"`
 	Labels   map[string]string `json:"labels,omitempty"`
+	Owner    *ownership.Owner  `json:"owner,omitempty"`
 }
 
 // Report holds all check results.
@@ -517,6 +519,16 @@ const (
 	colorBold   = "\033[1m"
 )
 
+// AssignOwners attaches the owning team from the catalog to each result for
+// a specific service.
+func (r *Report) AssignOwners(c *ownership.Catalog) {
+	for i := range r.Results {
+		if o, ok := c.Owner(r.Results[i].Service); ok {
+			r.Results[i].Owner = &o
+		}
+	}
+}
+
 // FormatText returns a human-readable colored output.
 func FormatText(report *Report) string {
 	var b strings.Builder
@@ -551,6 +563,16 @@ func FormatText(report *Report) string {
 		b.WriteString(fmt.Sprintf("  %s%s %s%s", color, result.Severity.Icon(), result.Status, colorReset))
 		b.WriteString(fmt.Sprintf("  %s[%s]%s", colorCyan, svc, colorReset))
 		b.WriteString(fmt.Sprintf("  %s\n", result.Message))
+		if o := result.Owner; o != nil && result.Severity > SeverityOK {
+			b.WriteString(fmt.Sprintf("      %steam %s", colorGray, o.Team))
+			if o.Escalation != "" {
+				b.WriteString(" · escalate: " + o.Escalation)
+			}
+			if o.Runbook != "" {
+				b.WriteString(" · runbook: " + o.Runbook)
+			}
+			b.WriteString(colorReset + "\n")
+		}
 	}
 
 	// Summary line
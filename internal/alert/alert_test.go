@@ -3,9 +3,11 @@ package alert
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/lbarahona/argus/internal/ownership"
 	"github.com/lbarahona/argus/pkg/types"
 )
 
@@ -44,6 +46,18 @@ func (m *mockSignozClient) QueryMetrics(ctx context.Context, metricName string,
 	return &types.QueryResult{}, nil
 }
 
+func (m *mockSignozClient) ListServicesRange(ctx context.Context, start, end time.Time) ([]types.Service, error) {
+	return m.ListServices(ctx)
+}
+
+func (m *mockSignozClient) QueryLogsRange(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+	return &types.QueryResult{}, nil
+}
+
+func (m *mockSignozClient) QueryTracesRange(ctx context.Context, service string, start, end time.Time, limit int) (*types.QueryResult, error) {
+	return &types.QueryResult{}, nil
+}
+
 // ──────────────────────────────────────────────
 // Rule Tests
 // ──────────────────────────────────────────────
@@ -389,6 +403,30 @@ func TestFormatText(t *testing.T) {
 	}
 }
 
+func TestAssignOwners(t *testing.T) {
+	owners, err := ownership.New(map[string]types.Team{
+		"core": {Services: []string{"api"}, Escalation: "pager:core", Runbook: "https://wiki/api"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpt := &Report{
+		Instance: "prod",
+		Results: []CheckResult{
+			{Rule: "r1", Service: "api", Severity: SeverityCritical, Status: "critical", Message: "bad"},
+			{Rule: "r2", Service: "web", Severity: SeverityWarning, Status: "warning", Message: "meh"},
+		},
+	}
+	rpt.AssignOwners(owners)
+	if rpt.Results[0].Owner == nil || rpt.Results[0].Owner.Team != "core" || rpt.Results[1].Owner != nil {
+		t.Fatalf("unexpected owners %+v / %+v", rpt.Results[0].Owner, rpt.Results[1].Owner)
+	}
+	out := FormatText(rpt)
+	if !strings.Contains(out, "escalate: pager:core") || !strings.Contains(out, "https://wiki/api") {
+		t.Errorf("expected owner metadata in output:\n%s", out)
+	}
+}
+
 func TestFormatJSON(t *testing.T) {
 	rpt := &Report{
 		Instance: "prod",
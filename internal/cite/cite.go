@@ -0,0 +1,218 @@
+// Package cite tags the logs and traces in AI prompts with short reference
+// IDs, such as [L3] and [T1], and turns the IDs the model cites in its answer
+// into deep links to the Signoz UI.
+package cite
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lbarahona/argus/internal/ai"
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// logMargin widens the log explorer range around a cited log, so the lines
+// before and after it are in view.
+const logMargin = time.Minute
+
+// Instruction tells the model how to cite. It goes before the answer
+// instructions.
+const Instruction = `
+Log lines and traces are tagged with reference IDs such as [L1] and [T1]. When a statement relies on one, cite it by writing its ID in square brackets right after the statement, e.g. "the pool ran out at 14:03 [L4]". Cite only IDs you were given.
+`
+
+// Refs assigns reference IDs and resolves them to Signoz UI links. A nil
+// *Refs tags nothing and leaves answers as they are, so callers don't need
+// to check whether citations are enabled.
+type Refs struct {
+	base  string
+	refs  map[string]*ref
+	index map[string]string // log or trace key → ID
+	logs  int
+	trace int
+}
+
+type ref struct {
+	traceID    string    // set for traces
+	start, end time.Time // first and last occurrence, for logs
+}
+
+// New creates Refs linking to the Signoz UI at instanceURL. It returns nil
+// when the URL is empty.
+func New(instanceURL string) *Refs {
+	base := strings.TrimRight(instanceURL, "/")
+	if base == "" {
+		return nil
+	}
+	return &Refs{base: base, refs: map[string]*ref{}, index: map[string]string{}}
+}
+
+// Log returns the tag for a log line, e.g. "[L3] ", or "" when r is nil.
+// Lines with the same service and body share an ID whose link covers every
+// occurrence, matching how prompts collapse repeated lines.
+func (r *Refs) Log(l types.LogEntry) string {
+	if r == nil {
+		return ""
+	}
+	key := "log\x00" + l.ServiceName + "\x00" + l.Body
+	if id, ok := r.index[key]; ok {
+		rf := r.refs[id]
+		if l.Timestamp.Before(rf.start) {
+			rf.start = l.Timestamp
+		}
+		if l.Timestamp.After(rf.end) {
+			rf.end = l.Timestamp
+		}
+		return tag(id)
+	}
+	r.logs++
+	id := fmt.Sprintf("L%d", r.logs)
+	r.index[key] = id
+	r.refs[id] = &ref{start: l.Timestamp, end: l.Timestamp}
+	return tag(id)
+}
+
+// Trace returns the tag for a span's trace, e.g. "[T1] ", or "" when r is
+// nil or the span has no trace ID. Spans of one trace share an ID.
+func (r *Refs) Trace(t types.TraceEntry) string {
+	if r == nil || t.TraceID == "" {
+		return ""
+	}
+	key := "trace\x00" + t.TraceID
+	if id, ok := r.index[key]; ok {
+		return tag(id)
+	}
+	r.trace++
+	id := fmt.Sprintf("T%d", r.trace)
+	r.index[key] = id
+	r.refs[id] = &ref{traceID: t.TraceID}
+	return tag(id)
+}
+
+func tag(id string) string {
+	return "[" + id + "] "
+}
+
+// Len returns the number of IDs handed out.
+func (r *Refs) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.refs)
+}
+
+// Instruction returns Instruction when any ID was handed out, "" otherwise.
+func (r *Refs) Instruction() string {
+	if r.Len() == 0 {
+		return ""
+	}
+	return Instruction
+}
+
+// URL returns the Signoz UI link for an ID: the trace view for traces, the
+// log explorer around the log's occurrences for logs.
+func (r *Refs) URL(id string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	rf, ok := r.refs[id]
+	if !ok {
+		return "", false
+	}
+	if rf.traceID != "" {
+		return r.TraceURL(rf.traceID), true
+	}
+	q := url.Values{}
+	q.Set("startTime", fmt.Sprint(rf.start.Add(-logMargin).UnixMilli()))
+	q.Set("endTime", fmt.Sprint(rf.end.Add(logMargin).UnixMilli()))
+	return r.base + "/logs/logs-explorer?" + q.Encode(), true
+}
+
+// TraceURL returns the Signoz trace view for a trace ID.
+func (r *Refs) TraceURL(traceID string) string {
+	return r.base + "/trace/" + url.PathEscape(traceID)
+}
+
+// citation matches a cited ID or a list of them, e.g. [L3] or [L3, T1].
+var citation = regexp.MustCompile(`\[\s*([LT]\d+(?:\s*,\s*[LT]\d+)*)\s*\]`)
+
+// Link rewrites the citations in text as markdown links, [L3](url). IDs
+// that were never handed out are left as they are.
+func (r *Refs) Link(text string) string {
+	if r.Len() == 0 {
+		return text
+	}
+	return citation.ReplaceAllStringFunc(text, func(m string) string {
+		ids := strings.Split(citation.FindStringSubmatch(m)[1], ",")
+		links := make([]string, len(ids))
+		for i, id := range ids {
+			id = strings.TrimSpace(id)
+			u, ok := r.URL(id)
+			if !ok {
+				return m
+			}
+			links[i] = fmt.Sprintf("[%s](%s)", id, u)
+		}
+		return strings.Join(links, ", ")
+	})
+}
+
+// LinkAssessment fills in the Link of each piece of evidence from its ref,
+// or from its trace ID when it has no known ref.
+func (r *Refs) LinkAssessment(a *ai.Assessment) {
+	if r == nil || a == nil {
+		return
+	}
+	for i := range a.Evidence {
+		e := &a.Evidence[i]
+		e.Link = ""
+		if u, ok := r.URL(strings.Trim(e.Ref, "[] ")); ok {
+			e.Link = u
+		} else if e.TraceID != "" {
+			e.Link = r.TraceURL(e.TraceID)
+		}
+	}
+}
+
+// maxCitation is the longest citation Writer holds back waiting for its
+// closing bracket.
+const maxCitation = 64
+
+// Writer rewrites citations in a stream with Link. A citation split across
+// writes is held back until it is complete; Flush writes whatever is left.
+type Writer struct {
+	w       io.Writer
+	refs    *Refs
+	pending string
+}
+
+// NewWriter returns a Writer linking citations written to w.
+func (r *Refs) NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, refs: r}
+}
+
+func (lw *Writer) Write(p []byte) (int, error) {
+	lw.pending += string(p)
+	cut := len(lw.pending)
+	if i := strings.LastIndexByte(lw.pending, '['); i >= 0 && !strings.Contains(lw.pending[i:], "]") && cut-i < maxCitation {
+		cut = i
+	}
+	out := lw.refs.Link(lw.pending[:cut])
+	lw.pending = lw.pending[cut:]
+	if _, err := io.WriteString(lw.w, out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes any text held back.
+func (lw *Writer) Flush() error {
+	out := lw.refs.Link(lw.pending)
+	lw.pending = ""
+	_, err := io.WriteString(lw.w, out)
+	return err
+}
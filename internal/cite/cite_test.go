@@ -0,0 +1,144 @@
+package cite
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbarahona/argus/internal/ai"
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+var t0 = time.Date(2026, 3, 4, 14, 3, 0, 0, time.UTC)
+
+func TestRefsAssignIDs(t *testing.T) {
+	r := New("https://signoz.example.com/")
+	log := types.LogEntry{Timestamp: t0, ServiceName: "api", Body: "timeout"}
+	if tag := r.Log(log); tag != "[L1] " {
+		t.Errorf("expected [L1], got %q", tag)
+	}
+	if tag := r.Log(types.LogEntry{Timestamp: t0, ServiceName: "db", Body: "timeout"}); tag != "[L2] " {
+		t.Errorf("another service's line needs its own ID, got %q", tag)
+	}
+	log.Timestamp = t0.Add(5 * time.Minute)
+	if tag := r.Log(log); tag != "[L1] " {
+		t.Errorf("a repeated line should share its ID, got %q", tag)
+	}
+
+	span := types.TraceEntry{TraceID: "abc123", SpanID: "s1"}
+	if tag := r.Trace(span); tag != "[T1] " {
+		t.Errorf("expected [T1], got %q", tag)
+	}
+	span.SpanID = "s2"
+	if tag := r.Trace(span); tag != "[T1] " {
+		t.Errorf("spans of one trace should share an ID, got %q", tag)
+	}
+	if tag := r.Trace(types.TraceEntry{}); tag != "" {
+		t.Errorf("a span without a trace ID can't be cited, got %q", tag)
+	}
+	if r.Len() != 3 {
+		t.Errorf("expected 3 IDs, got %d", r.Len())
+	}
+
+	if u, _ := r.URL("T1"); u != "https://signoz.example.com/trace/abc123" {
+		t.Errorf("unexpected trace link %s", u)
+	}
+	// The log link covers both occurrences, a minute either side.
+	u, _ := r.URL("L1")
+	start, end := t0.Add(-time.Minute).UnixMilli(), t0.Add(6*time.Minute).UnixMilli()
+	want := "https://signoz.example.com/logs/logs-explorer?endTime=" + fmt.Sprint(end) + "&startTime=" + fmt.Sprint(start)
+	if u != want {
+		t.Errorf("got %s, want %s", u, want)
+	}
+	if _, ok := r.URL("L9"); ok {
+		t.Error("unknown IDs should not resolve")
+	}
+}
+
+func TestNilRefs(t *testing.T) {
+	var r *Refs
+	if New("") != nil {
+		t.Error("no URL should disable citations")
+	}
+	if r.Log(types.LogEntry{Body: "x"}) != "" || r.Trace(types.TraceEntry{TraceID: "a"}) != "" || r.Instruction() != "" {
+		t.Error("nil Refs should tag nothing")
+	}
+	if got := r.Link("see [L1]"); got != "see [L1]" {
+		t.Errorf("nil Refs should leave answers alone, got %q", got)
+	}
+	var buf bytes.Buffer
+	w := r.NewWriter(&buf)
+	w.Write([]byte("a [L1"))
+	w.Write([]byte("] b"))
+	w.Flush()
+	if buf.String() != "a [L1] b" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func testRefs() *Refs {
+	r := New("http://signoz:3301")
+	r.Log(types.LogEntry{Timestamp: t0, ServiceName: "api", Body: "pool exhausted"})
+	r.Trace(types.TraceEntry{TraceID: "abc"})
+	return r
+}
+
+func TestLink(t *testing.T) {
+	r := testRefs()
+	logURL, _ := r.URL("L1")
+	tests := []struct{ in, want string }{
+		{"the pool ran out [L1].", "the pool ran out [L1](" + logURL + ")."},
+		{"slow call [T1]", "slow call [T1](http://signoz:3301/trace/abc)"},
+		{"both [L1, T1]", "both [L1](" + logURL + "), [T1](http://signoz:3301/trace/abc)"},
+		{"unknown [L7] and [L1, L7]", "unknown [L7] and [L1, L7]"},
+		{"an array [1] or [Lx]", "an array [1] or [Lx]"},
+	}
+	for _, tt := range tests {
+		if got := r.Link(tt.in); got != tt.want {
+			t.Errorf("Link(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if !strings.Contains(r.Instruction(), "[L1]") {
+		t.Error("expected the citation instruction once IDs are handed out")
+	}
+}
+
+func TestWriterJoinsSplitCitations(t *testing.T) {
+	r := testRefs()
+	var buf bytes.Buffer
+	w := r.NewWriter(&buf)
+	for _, chunk := range []string{"The trace [", "T", "1] shows it", ", and [unclosed"} {
+		if n, err := w.Write([]byte(chunk)); err != nil || n != len(chunk) {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	if strings.Contains(buf.String(), "[unclosed") {
+		t.Error("an unterminated bracket should be held back until Flush")
+	}
+	w.Flush()
+	want := "The trace [T1](http://signoz:3301/trace/abc) shows it, and [unclosed"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestLinkAssessment(t *testing.T) {
+	r := testRefs()
+	a := &ai.Assessment{Evidence: []ai.Evidence{
+		{Type: "log", Ref: "L1", Timestamp: "14:03:00", Detail: "pool exhausted"},
+		{Type: "trace", TraceID: "def", Detail: "not tagged"},
+		{Type: "metric", Detail: "cpu", Link: "http://made.up"},
+	}}
+	r.LinkAssessment(a)
+	if u, _ := r.URL("L1"); a.Evidence[0].Link != u {
+		t.Errorf("expected the log link, got %q", a.Evidence[0].Link)
+	}
+	if a.Evidence[1].Link != "http://signoz:3301/trace/def" {
+		t.Errorf("expected a link from the trace ID, got %q", a.Evidence[1].Link)
+	}
+	if a.Evidence[2].Link != "" {
+		t.Errorf("links from the model should be dropped, got %q", a.Evidence[2].Link)
+	}
+}
@@ -0,0 +1,223 @@
+// Package cron parses standard five-field cron expressions.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression. Times are matched in the location of
+// the time passed to Next/Prev.
+type Schedule struct {
+	expr   string
+	minute uint64 // bit i set when minute i matches
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// Like classic cron, when both day-of-month and day-of-week are
+	// restricted a day matches if either does.
+	domStar, dowStar bool
+}
+
+// searchLimit bounds Next/Prev, so an expression that can never match
+// (e.g. "0 0 30 2 *") returns the zero time instead of looping forever.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// Parse parses "minute hour day-of-month month day-of-week" with *, lists,
+// ranges, steps and month/day names, or one of the @daily-style macros.
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if m, ok := macros[strings.ToLower(spec)]; ok {
+		spec = m
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields, got %d", expr, len(parts))
+	}
+
+	s := &Schedule{expr: expr}
+	var err error
+	if s.minute, err = minuteField.parse(parts[0]); err != nil {
+		return nil, fmt.Errorf("cron %q: %w", expr, err)
+	}
+	if s.hour, err = hourField.parse(parts[1]); err != nil {
+		return nil, fmt.Errorf("cron %q: %w", expr, err)
+	}
+	if s.dom, err = domField.parse(parts[2]); err != nil {
+		return nil, fmt.Errorf("cron %q: %w", expr, err)
+	}
+	if s.month, err = monthField.parse(parts[3]); err != nil {
+		return nil, fmt.Errorf("cron %q: %w", expr, err)
+	}
+	if s.dow, err = dowField.parse(parts[4]); err != nil {
+		return nil, fmt.Errorf("cron %q: %w", expr, err)
+	}
+	// 7 is an alias for Sunday.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = parts[2] == "*" || parts[2] == "?"
+	s.dowStar = parts[4] == "*" || parts[4] == "?"
+	return s, nil
+}
+
+// String returns the expression the schedule was parsed from.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+func (f field) parse(spec string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(spec, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepSpec)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", f.name, stepSpec)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangeSpec == "*" || rangeSpec == "?":
+		case strings.Contains(rangeSpec, "-"):
+			a, b, _ := strings.Cut(rangeSpec, "-")
+			var err error
+			if lo, err = f.value(a); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s: range %q is backwards", f.name, rangeSpec)
+			}
+		default:
+			v, err := f.value(rangeSpec)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if !hasStep {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value %q", f.name, s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: %d out of range %d-%d", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Matches reports whether t (to the minute) is a scheduled time.
+func (s *Schedule) Matches(t time.Time) bool {
+	return s.minute&(1<<uint(t.Minute())) != 0 && s.hour&(1<<uint(t.Hour())) != 0 &&
+		s.month&(1<<uint(t.Month())) != 0 && s.dayMatches(t)
+}
+
+// Next returns the first scheduled time strictly after t, or the zero time if
+// there is none within five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	end := t.Add(searchLimit)
+	for c := t.Truncate(time.Minute).Add(time.Minute); c.Before(end); {
+		switch {
+		case s.month&(1<<uint(c.Month())) == 0:
+			c = time.Date(c.Year(), c.Month()+1, 1, 0, 0, 0, 0, c.Location())
+		case !s.dayMatches(c):
+			c = time.Date(c.Year(), c.Month(), c.Day()+1, 0, 0, 0, 0, c.Location())
+		case s.hour&(1<<uint(c.Hour())) == 0:
+			c = startOfHour(c).Add(time.Hour)
+		case s.minute&(1<<uint(c.Minute())) == 0:
+			c = c.Add(time.Minute)
+		default:
+			return c
+		}
+	}
+	return time.Time{}
+}
+
+// Prev returns the latest scheduled time at or before t, or the zero time if
+// there is none within five years.
+func (s *Schedule) Prev(t time.Time) time.Time {
+	end := t.Add(-searchLimit)
+	for c := t.Truncate(time.Minute); c.After(end); {
+		switch {
+		case s.month&(1<<uint(c.Month())) == 0:
+			// Last minute of the previous month.
+			c = time.Date(c.Year(), c.Month(), 1, 0, 0, 0, 0, c.Location()).Add(-time.Minute)
+		case !s.dayMatches(c):
+			c = time.Date(c.Year(), c.Month(), c.Day(), 0, 0, 0, 0, c.Location()).Add(-time.Minute)
+		case s.hour&(1<<uint(c.Hour())) == 0:
+			c = startOfHour(c).Add(-time.Minute)
+		case s.minute&(1<<uint(c.Minute())) == 0:
+			c = c.Add(-time.Minute)
+		default:
+			return c
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domOK := s.dom&(1<<uint(t.Day())) != 0
+	dowOK := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+// startOfHour truncates in t's own location, which time.Truncate does not do
+// for zones with non-hour offsets.
+func startOfHour(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+}
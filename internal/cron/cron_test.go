@@ -0,0 +1,75 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, expr string) *Schedule {
+	t.Helper()
+	s, err := Parse(expr)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", expr, err)
+	}
+	return s
+}
+
+func TestNext(t *testing.T) {
+	// Sunday 2026-03-01 10:17
+	from := time.Date(2026, 3, 1, 10, 17, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 1, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)},
+		{"0 7,19 * * *", time.Date(2026, 3, 1, 19, 0, 0, 0, time.UTC)},
+		{"30 8 * * mon-fri", time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2026, 3, 8, 9, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Day-of-month and day-of-week both restricted: either matches.
+		{"0 0 15 * fri", time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.expr).Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+
+	if got := mustParse(t, "0 0 30 2 *").Next(from); !got.IsZero() {
+		t.Errorf("impossible schedule should return zero time, got %s", got)
+	}
+}
+
+func TestPrev(t *testing.T) {
+	s := mustParse(t, "0 7,19 * * *")
+	at := time.Date(2026, 3, 1, 7, 0, 0, 0, time.UTC)
+	if got := s.Prev(at); !got.Equal(at) {
+		t.Errorf("Prev should include t itself, got %s", got)
+	}
+	if got := s.Prev(at.Add(-time.Minute)); !got.Equal(time.Date(2026, 2, 28, 19, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected Prev %s", got)
+	}
+	if got := mustParse(t, "@weekly").Prev(at); !got.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected weekly Prev %s", got)
+	}
+}
+
+func TestNextInZoneWithHalfHourOffset(t *testing.T) {
+	loc := time.FixedZone("IST", 5*3600+1800)
+	from := time.Date(2026, 3, 1, 10, 45, 0, 0, loc)
+	got := mustParse(t, "0 12 * * *").Next(from)
+	if !got.Equal(time.Date(2026, 3, 1, 12, 0, 0, 0, loc)) {
+		t.Errorf("unexpected Next %s", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "5-1 * * * *", "*/0 * * * *", "* * * foo *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lbarahona/argus/internal/signoz"
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// Thresholds define when a before/after change counts as a regression.
+// A zero value disables the corresponding check.
+type Thresholds struct {
+	ErrorRateIncrease float64 // max allowed error rate increase, in percentage points
+	P99Increase       float64 // max allowed p99 latency increase, in percent
+	ThroughputDrop    float64 // max allowed throughput drop, in percent
+	NewPatterns       bool    // fail when error patterns appear that were not seen before
+}
+
+// DefaultThresholds returns sensible regression gates for deploy checks.
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		ErrorRateIncrease: 1.0,
+		P99Increase:       25.0,
+		ThroughputDrop:    50.0,
+		NewPatterns:       true,
+	}
+}
+
+// windowStats holds everything collected for one side of the comparison.
+type windowStats struct {
+	minutes   float64
+	services  map[string]types.Service
+	logErrors map[string]int
+	latencies map[string][]float64 // span durations in ms
+	logs      []types.LogEntry
+}
+
+// CompareAround compares equal-length windows immediately before and after
+// opts.Around. If the after window would extend past now, both windows are
+// shortened so they stay the same length.
+func CompareAround(ctx context.Context, client signoz.SignozQuerier, instKey string, opts Options) (*DiffResult, error) {
+	opts = opts.withDefaults()
+	window := opts.Window
+	if window <= 0 {
+		window = 30 * time.Minute
+	}
+
+	now := time.Now()
+	around := opts.Around
+	afterEnd := around.Add(window)
+	if afterEnd.After(now) {
+		afterEnd = now
+	}
+	if !afterEnd.After(around) {
+		return nil, fmt.Errorf("--around %s is in the future", around.Format(time.RFC3339))
+	}
+	span := afterEnd.Sub(around)
+	beforeStart := around.Add(-span)
+
+	before, err := collectWindow(ctx, client, beforeStart, around, baselineLogs)
+	if err != nil {
+		return nil, fmt.Errorf("collecting before window: %w", err)
+	}
+	after, err := collectWindow(ctx, client, around, afterEnd, windowLogs)
+	if err != nil {
+		return nil, fmt.Errorf("collecting after window: %w", err)
+	}
+
+	result := &DiffResult{
+		Instance:    instKey,
+		WindowA:     fmt.Sprintf("%s → %s", beforeStart.Format("15:04:05"), around.Format("15:04:05")),
+		WindowB:     fmt.Sprintf("%s → %s", around.Format("15:04:05"), afterEnd.Format("15:04:05")),
+		DurationMin: int(span.Minutes()),
+		GeneratedAt: now,
+		Around:      around,
+	}
+
+	names := make(map[string]bool)
+	for _, w := range []*windowStats{before, after} {
+		for name := range w.services {
+			names[name] = true
+		}
+		for name := range w.logErrors {
+			names[name] = true
+		}
+	}
+
+	for name := range names {
+		d := buildAroundDiff(name, before, after)
+		classify(&d, &result.Summary, opts)
+		result.Services = append(result.Services, d)
+		result.Summary.TotalCallsBefore += d.CallsBefore
+		result.Summary.TotalCallsAfter += d.CallsAfter
+		result.Summary.TotalErrorsBefore += d.ErrorsBefore
+		result.Summary.TotalErrorsAfter += d.ErrorsAfter
+	}
+	sortServices(result.Services)
+
+	result.Patterns = comparePatterns(before.logs, after.logs, opts)
+	if len(before.logs) >= baselineLogs {
+		result.PatternBaseline = len(before.logs)
+	}
+	result.Regressions = findRegressions(result, opts.Thresholds)
+
+	return result, nil
+}
+
+// Sample sizes for one window. Results come newest first, so a full sample
+// only covers the end of the window. The earlier window's error logs get a
+// larger sample: a pattern is only new if none of them match it.
+const (
+	windowLogs   = 500
+	windowSpans  = 1000
+	baselineLogs = 5000
+)
+
+func collectWindow(ctx context.Context, client signoz.SignozQuerier, start, end time.Time, logLimit int) (*windowStats, error) {
+	w := &windowStats{
+		minutes:   end.Sub(start).Minutes(),
+		services:  make(map[string]types.Service),
+		logErrors: make(map[string]int),
+		latencies: make(map[string][]float64),
+	}
+
+	services, err := client.ListServicesRange(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range services {
+		w.services[s.Name] = s
+	}
+
+	logs, err := client.QueryLogsRange(ctx, "", start, end, logLimit, "ERROR")
+	if err != nil {
+		return nil, fmt.Errorf("querying error logs: %w", err)
+	}
+	w.logs = logs.Logs
+	for _, l := range logs.Logs {
+		w.logErrors[l.ServiceName]++
+	}
+
+	spans, err := client.QueryTracesRange(ctx, "", start, end, windowSpans)
+	if err != nil {
+		return nil, fmt.Errorf("querying spans: %w", err)
+	}
+	// A full sample is only the last moments of the window; latencyPercentiles
+	// then uses the p99 the services endpoint reports for all of it.
+	if len(spans.Traces) < windowSpans {
+		for _, t := range spans.Traces {
+			w.latencies[t.ServiceName] = append(w.latencies[t.ServiceName], t.DurationMs())
+		}
+	}
+
+	return w, nil
+}
+
+func buildAroundDiff(name string, before, after *windowStats) ServiceDiff {
+	d := ServiceDiff{Name: name}
+
+	d.CallsBefore, d.ErrorsBefore, d.RateBefore = windowCounts(name, before)
+	d.CallsAfter, d.ErrorsAfter, d.RateAfter = windowCounts(name, after)
+	d.RateChange = d.RateAfter - d.RateBefore
+
+	if d.CallsBefore > 0 {
+		d.CallsChange = (float64(d.CallsAfter) - float64(d.CallsBefore)) / float64(d.CallsBefore) * 100
+	}
+	if d.ErrorsBefore > 0 {
+		d.ErrorsChange = (float64(d.ErrorsAfter) - float64(d.ErrorsBefore)) / float64(d.ErrorsBefore) * 100
+	} else if d.ErrorsAfter > 0 {
+		d.ErrorsChange = 100
+	}
+
+	if before.minutes > 0 {
+		d.ThroughputBefore = float64(d.CallsBefore) / before.minutes
+	}
+	if after.minutes > 0 {
+		d.ThroughputAfter = float64(d.CallsAfter) / after.minutes
+	}
+
+	d.P50Before, d.P95Before, d.P99Before = latencyPercentiles(name, before)
+	d.P50After, d.P95After, d.P99After = latencyPercentiles(name, after)
+
+	return d
+}
+
+// windowCounts prefers span-level call/error counts and falls back to error log
+// counts for services that do not report spans.
+func windowCounts(name string, w *windowStats) (calls, errors int, rate float64) {
+	if s, ok := w.services[name]; ok && s.NumCalls > 0 {
+		return s.NumCalls, s.NumErrors, s.ErrorRate
+	}
+	return 0, w.logErrors[name], 0
+}
+
+// latencyPercentiles returns p50/p95/p99 in ms from span durations, falling
+// back to the p99 reported by the services endpoint when the spans don't cover
+// the whole window.
+func latencyPercentiles(name string, w *windowStats) (p50, p95, p99 float64) {
+	durations := w.latencies[name]
+	if len(durations) == 0 {
+		if s, ok := w.services[name]; ok {
+			return 0, 0, s.P99Ms()
+		}
+		return 0, 0, 0
+	}
+	sorted := append([]float64(nil), durations...)
+	sort.Float64s(sorted)
+	return percentile(sorted, 50), percentile(sorted, 95), percentile(sorted, 99)
+}
+
+// percentile returns the nearest-rank percentile of an ascending slice.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+func findRegressions(r *DiffResult, th Thresholds) []Regression {
+	var regs []Regression
+
+	for _, s := range r.Services {
+		// Only statistically significant error rate changes gate a deploy.
+		if th.ErrorRateIncrease > 0 && s.Significant && s.RateChange > th.ErrorRateIncrease {
+			regs = append(regs, Regression{
+				Service: s.Name,
+				Metric:  "error_rate",
+				Before:  s.RateBefore,
+				After:   s.RateAfter,
+				Limit:   th.ErrorRateIncrease,
+				Message: fmt.Sprintf("error rate %.2f%% → %.2f%% (+%.2fpp, limit +%.2fpp)", s.RateBefore, s.RateAfter, s.RateChange, th.ErrorRateIncrease),
+			})
+		}
+
+		if th.P99Increase > 0 && s.P99Before > 0 && s.P99After > 0 {
+			change := (s.P99After - s.P99Before) / s.P99Before * 100
+			if change > th.P99Increase {
+				regs = append(regs, Regression{
+					Service: s.Name,
+					Metric:  "p99",
+					Before:  s.P99Before,
+					After:   s.P99After,
+					Limit:   th.P99Increase,
+					Message: fmt.Sprintf("p99 %.0fms → %.0fms (%+.0f%%, limit +%.0f%%)", s.P99Before, s.P99After, change, th.P99Increase),
+				})
+			}
+		}
+
+		if th.ThroughputDrop > 0 && s.ThroughputBefore > 0 {
+			drop := (s.ThroughputBefore - s.ThroughputAfter) / s.ThroughputBefore * 100
+			if drop > th.ThroughputDrop {
+				regs = append(regs, Regression{
+					Service: s.Name,
+					Metric:  "throughput",
+					Before:  s.ThroughputBefore,
+					After:   s.ThroughputAfter,
+					Limit:   th.ThroughputDrop,
+					Message: fmt.Sprintf("throughput %.1f → %.1f calls/min (-%.0f%%, limit -%.0f%%)", s.ThroughputBefore, s.ThroughputAfter, drop, th.ThroughputDrop),
+				})
+			}
+		}
+	}
+
+	if th.NewPatterns {
+		for _, p := range r.Patterns {
+			if p.Status != "new" {
+				continue
+			}
+			regs = append(regs, Regression{
+				Service: p.Service,
+				Metric:  "new_error_pattern",
+				After:   float64(p.After),
+				Message: fmt.Sprintf("%s (%dx): %s", r.newPatternLabel(), p.After, truncate(p.Sample, 80)),
+			})
+		}
+	}
+
+	return regs
+}
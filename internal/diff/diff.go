@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/lbarahona/argus/internal/signoz"
+	"github.com/lbarahona/argus/internal/stats"
 	"github.com/lbarahona/argus/pkg/types"
 )
 
@@ -25,7 +26,23 @@ type ServiceDiff struct {
 	CallsChange   float64 // percentage
 	ErrorsChange  float64 // percentage
 	RateChange    float64 // absolute change in error rate
-	Status        string  // "improved", "degraded", "stable", "new", "gone"
+	Status        string  // "improved", "degraded", "stable", "new", "gone", "insufficient"
+
+	// Significance of the error change. Test is "z-test" when call counts are
+	// known for both windows (errors/calls), otherwise "poisson" (error counts).
+	PValue      float64
+	Test        string
+	Significant bool
+
+	// Latency (ms) and throughput (calls/min) per window; only populated by CompareAround.
+	P50Before        float64
+	P50After         float64
+	P95Before        float64
+	P95After         float64
+	P99Before        float64
+	P99After         float64
+	ThroughputBefore float64
+	ThroughputAfter  float64
 }
 
 // DiffResult holds comparison data between two time windows.
@@ -37,6 +54,35 @@ type DiffResult struct {
 	Services     []ServiceDiff
 	Summary      DiffSummary
 	GeneratedAt  time.Time
+
+	// Error log patterns that appeared, disappeared or grew between windows.
+	// PatternBaseline is set when the earlier window had more error lines than
+	// were sampled, so "new" only means not seen in that many lines.
+	Patterns        []PatternChange
+	PatternBaseline int
+
+	// Set when comparing around a point in time (e.g. a deploy).
+	Around      time.Time
+	Regressions []Regression
+}
+
+// Regression is a change that exceeded a configured threshold.
+type Regression struct {
+	Service string
+	Metric  string // "error_rate", "p99", "throughput", "new_error_pattern"
+	Before  float64
+	After   float64
+	Limit   float64
+	Message string
+}
+
+// ExitCode returns 2 when any regression exceeded its threshold, 0 otherwise.
+// (1 is left for command failures, which cobra reports on its own.)
+func (r *DiffResult) ExitCode() int {
+	if len(r.Regressions) > 0 {
+		return 2
+	}
+	return 0
 }
 
 // DiffSummary provides a high-level overview.
@@ -50,11 +96,35 @@ type DiffSummary struct {
 	Stable            int
 	New               int
 	Gone              int
+	Insufficient      int // not enough data to judge
 }
 
 // Options configures the diff comparison.
 type Options struct {
 	Duration int // minutes per window (default 60, so compares last hour vs previous hour)
+
+	// Around/Window are used by CompareAround.
+	Around     time.Time
+	Window     time.Duration // length of each window (default 30m)
+	Thresholds Thresholds
+
+	// Significance settings; zero values use the defaults below.
+	Confidence float64 // e.g. 0.95 — changes with p >= 1-Confidence are "stable"
+	MinCalls   int     // minimum calls per window for the z-test (default 30)
+	MinErrors  int     // minimum errors across both windows to judge at all (default 5)
+}
+
+func (o Options) withDefaults() Options {
+	if o.Confidence <= 0 || o.Confidence >= 1 {
+		o.Confidence = 0.95
+	}
+	if o.MinCalls <= 0 {
+		o.MinCalls = 30
+	}
+	if o.MinErrors <= 0 {
+		o.MinErrors = 5
+	}
+	return o
 }
 
 // Compare fetches service data for two consecutive time windows and computes diffs.
@@ -63,19 +133,25 @@ func Compare(ctx context.Context, client signoz.SignozQuerier, instKey string, o
 	// For a real diff, we'd need historical data. Since Signoz services endpoint
 	// returns aggregate data, we'll fetch error logs from two time windows to compare.
 
+	opts = opts.withDefaults()
 	dur := opts.Duration
 	if dur <= 0 {
 		dur = 60
 	}
 
+	now := time.Now()
+	cutoff := now.Add(-time.Duration(dur) * time.Minute)
+	start := now.Add(-time.Duration(dur*2) * time.Minute)
+
 	// Window B (recent): 0 to dur minutes ago
-	recentLogs, err := client.QueryLogs(ctx, "", dur, 500, "ERROR")
+	recentLogs, err := client.QueryLogsRange(ctx, "", cutoff, now, 500, "ERROR")
 	if err != nil {
 		return nil, fmt.Errorf("querying recent logs: %w", err)
 	}
 
-	// Window A (previous): dur to 2*dur minutes ago
-	previousLogs, err := client.QueryLogs(ctx, "", dur*2, 500, "ERROR")
+	// Window A (previous): dur to 2*dur minutes ago, queried on its own so a
+	// busy recent window can't use up its row limit
+	previousLogs, err := client.QueryLogsRange(ctx, "", start, cutoff, baselineLogs, "ERROR")
 	if err != nil {
 		return nil, fmt.Errorf("querying previous logs: %w", err)
 	}
@@ -83,12 +159,8 @@ func Compare(ctx context.Context, client signoz.SignozQuerier, instKey string, o
 	// Current services for call counts
 	services, _ := client.ListServices(ctx)
 
-	now := time.Now()
-	cutoff := now.Add(-time.Duration(dur) * time.Minute)
-
-	// Split previous logs into window A (older) and window B (recent)
 	recentErrors := countByService(recentLogs.Logs, cutoff, now)
-	previousErrors := countByService(previousLogs.Logs, now.Add(-time.Duration(dur*2)*time.Minute), cutoff)
+	previousErrors := countByService(previousLogs.Logs, start, cutoff)
 
 	// Build service map from current services
 	serviceMap := make(map[string]types.Service)
@@ -139,41 +211,92 @@ func Compare(ctx context.Context, client signoz.SignozQuerier, instKey string, o
 			d.ErrorsChange = 100
 		}
 
-		// Determine status
-		switch {
-		case before == 0 && after > 0:
-			d.Status = "new"
-			result.Summary.New++
-		case before > 0 && after == 0:
-			d.Status = "gone"
-			result.Summary.Gone++
-		case after > before && d.ErrorsChange > 20:
-			d.Status = "degraded"
-			result.Summary.Degraded++
-		case after < before && d.ErrorsChange < -20:
-			d.Status = "improved"
-			result.Summary.Improved++
-		default:
-			d.Status = "stable"
-			result.Summary.Stable++
-		}
+		classify(&d, &result.Summary, opts)
 
 		result.Services = append(result.Services, d)
 		result.Summary.TotalErrorsBefore += before
 		result.Summary.TotalErrorsAfter += after
 	}
 
-	// Sort: degraded first, then by error count
-	sort.Slice(result.Services, func(i, j int) bool {
-		order := map[string]int{"degraded": 0, "new": 1, "stable": 2, "improved": 3, "gone": 4}
-		oi, oj := order[result.Services[i].Status], order[result.Services[j].Status]
+	sortServices(result.Services)
+
+	previousWindow := filterWindow(previousLogs.Logs, start, cutoff)
+	recentWindow := filterWindow(recentLogs.Logs, cutoff, now)
+	result.Patterns = comparePatterns(previousWindow, recentWindow, opts)
+	if len(previousLogs.Logs) >= baselineLogs {
+		result.PatternBaseline = len(previousLogs.Logs)
+	}
+
+	return result, nil
+}
+
+// classify determines the status of a service diff and updates the summary counts.
+// A change must be both statistically significant and larger than 20% to count
+// as degraded or improved; services with too little data are "insufficient".
+func classify(d *ServiceDiff, summary *DiffSummary, opts Options) {
+	before, after := d.ErrorsBefore, d.ErrorsAfter
+	enough := testSignificance(d, opts)
+	change := d.ErrorsChange
+	if d.Test == "z-test" && d.RateBefore > 0 {
+		change = (d.RateAfter - d.RateBefore) / d.RateBefore * 100
+	}
+
+	switch {
+	case before == 0 && after == 0:
+		d.Status = "stable"
+		summary.Stable++
+	case !enough:
+		d.Status = "insufficient"
+		summary.Insufficient++
+	case before == 0 && after > 0:
+		d.Status = "new"
+		summary.New++
+	case before > 0 && after == 0:
+		d.Status = "gone"
+		summary.Gone++
+	case d.Significant && change > 20:
+		d.Status = "degraded"
+		summary.Degraded++
+	case d.Significant && change < -20:
+		d.Status = "improved"
+		summary.Improved++
+	default:
+		d.Status = "stable"
+		summary.Stable++
+	}
+}
+
+// testSignificance fills in the p-value for a service diff and reports whether
+// there was enough data to run a test at all. Both windows have equal length.
+func testSignificance(d *ServiceDiff, opts Options) bool {
+	d.PValue = 1
+	if d.ErrorsBefore+d.ErrorsAfter < opts.MinErrors {
+		return false
+	}
+	if d.CallsBefore > 0 && d.CallsAfter > 0 {
+		if d.CallsBefore < opts.MinCalls || d.CallsAfter < opts.MinCalls {
+			return false
+		}
+		d.Test = "z-test"
+		_, d.PValue = stats.TwoProportionZTest(d.ErrorsBefore, d.CallsBefore, d.ErrorsAfter, d.CallsAfter)
+	} else {
+		d.Test = "poisson"
+		d.PValue = stats.PoissonRateTest(d.ErrorsBefore, 1, d.ErrorsAfter, 1)
+	}
+	d.Significant = d.PValue < 1-opts.Confidence
+	return true
+}
+
+// sortServices orders diffs degraded first, then by error count.
+func sortServices(services []ServiceDiff) {
+	order := map[string]int{"degraded": 0, "new": 1, "stable": 2, "improved": 3, "gone": 4, "insufficient": 5}
+	sort.Slice(services, func(i, j int) bool {
+		oi, oj := order[services[i].Status], order[services[j].Status]
 		if oi != oj {
 			return oi < oj
 		}
-		return result.Services[i].ErrorsAfter > result.Services[j].ErrorsAfter
+		return services[i].ErrorsAfter > services[j].ErrorsAfter
 	})
-
-	return result, nil
 }
 
 func countByService(logs []types.LogEntry, from, to time.Time) map[string]int {
@@ -191,6 +314,9 @@ func (r *DiffResult) RenderTerminal(w io.Writer) {
 	fmt.Fprintf(w, "\n🔭 ARGUS SERVICE DIFF\n")
 	fmt.Fprintf(w, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 	fmt.Fprintf(w, "  Instance: %s  |  Window: %d min\n", r.Instance, r.DurationMin)
+	if !r.Around.IsZero() {
+		fmt.Fprintf(w, "  Around:    %s\n", r.Around.Format("2006-01-02 15:04:05 MST"))
+	}
 	fmt.Fprintf(w, "  Comparing: [%s] vs [%s]\n\n", r.WindowA, r.WindowB)
 
 	// Summary
@@ -203,17 +329,29 @@ func (r *DiffResult) RenderTerminal(w io.Writer) {
 	}
 	fmt.Fprintf(w, "  📊 Summary: %d errors → %d errors (%s%d)\n",
 		r.Summary.TotalErrorsBefore, r.Summary.TotalErrorsAfter, changeIcon, int(math.Abs(float64(totalChange))))
-	fmt.Fprintf(w, "     🔴 %d degraded  🟢 %d improved  ⚪ %d stable  🆕 %d new  👻 %d gone\n\n",
-		r.Summary.Degraded, r.Summary.Improved, r.Summary.Stable, r.Summary.New, r.Summary.Gone)
+	fmt.Fprintf(w, "     🔴 %d degraded  🟢 %d improved  ⚪ %d stable  🆕 %d new  👻 %d gone  ❔ %d not enough data\n\n",
+		r.Summary.Degraded, r.Summary.Improved, r.Summary.Stable, r.Summary.New, r.Summary.Gone, r.Summary.Insufficient)
 
 	if len(r.Services) == 0 {
 		fmt.Fprintf(w, "  No services with errors found.\n")
-		return
+	} else {
+		r.renderServices(w)
 	}
 
+	r.renderPatterns(w)
+
+	if !r.Around.IsZero() {
+		r.renderAround(w)
+	}
+
+	fmt.Fprintf(w, "\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+}
+
+// renderServices prints the error counts of each service with errors.
+func (r *DiffResult) renderServices(w io.Writer) {
 	// Table header
-	fmt.Fprintf(w, "  %-30s %8s %8s %10s %s\n", "SERVICE", "BEFORE", "AFTER", "CHANGE", "STATUS")
-	fmt.Fprintf(w, "  %s\n", strings.Repeat("─", 70))
+	fmt.Fprintf(w, "  %-30s %8s %8s %10s %8s %s\n", "SERVICE", "BEFORE", "AFTER", "CHANGE", "P-VALUE", "STATUS")
+	fmt.Fprintf(w, "  %s\n", strings.Repeat("─", 79))
 
 	for _, s := range r.Services {
 		if s.ErrorsBefore == 0 && s.ErrorsAfter == 0 {
@@ -226,15 +364,66 @@ func (r *DiffResult) RenderTerminal(w io.Writer) {
 			change = "—"
 		}
 
-		fmt.Fprintf(w, "  %-30s %8d %8d %10s %s %s\n",
-			truncate(s.Name, 30), s.ErrorsBefore, s.ErrorsAfter, change, statusIcon, s.Status)
+		fmt.Fprintf(w, "  %-30s %8d %8d %10s %8s %s %s\n",
+			truncate(s.Name, 30), s.ErrorsBefore, s.ErrorsAfter, change, formatPValue(s), statusIcon, statusLabel(s.Status))
 	}
+}
 
-	fmt.Fprintf(w, "\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+// renderAround prints the latency/throughput comparison and the regression
+// verdict for deploy-style comparisons. The verdict is always printed, so a
+// CD log shows the gate result even when no service had traffic.
+func (r *DiffResult) renderAround(w io.Writer) {
+	if len(r.Services) > 0 {
+		r.renderLatency(w)
+	}
+
+	fmt.Fprintln(w)
+	if len(r.Regressions) == 0 {
+		fmt.Fprintf(w, "  ✅ PASS — no regressions exceeded thresholds\n")
+		return
+	}
+	fmt.Fprintf(w, "  ❌ FAIL — %d regression(s)\n", len(r.Regressions))
+	for _, reg := range r.Regressions {
+		fmt.Fprintf(w, "     • %s: %s\n", reg.Service, reg.Message)
+	}
+}
+
+// renderLatency prints the latency and throughput of each service.
+func (r *DiffResult) renderLatency(w io.Writer) {
+	fmt.Fprintf(w, "\n  ⏱  Latency & Throughput\n")
+	fmt.Fprintf(w, "  %-30s %17s %17s %19s\n", "SERVICE", "P50 ms", "P99 ms", "CALLS/MIN")
+	fmt.Fprintf(w, "  %s\n", strings.Repeat("─", 86))
+	for _, s := range r.Services {
+		if s.CallsBefore == 0 && s.CallsAfter == 0 && s.P99Before == 0 && s.P99After == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "  %-30s %7.0f → %-7.0f %7.0f → %-7.0f %8.1f → %-8.1f\n",
+			truncate(s.Name, 30), s.P50Before, s.P50After, s.P99Before, s.P99After, s.ThroughputBefore, s.ThroughputAfter)
+	}
+}
+
+// formatPValue renders the p-value, or "—" when no test was run.
+func formatPValue(s ServiceDiff) string {
+	if s.Test == "" {
+		return "—"
+	}
+	if s.PValue < 0.001 {
+		return "<0.001"
+	}
+	return fmt.Sprintf("%.3f", s.PValue)
+}
+
+func statusLabel(status string) string {
+	if status == "insufficient" {
+		return "not enough data"
+	}
+	return status
 }
 
 func statusEmoji(status string) string {
 	switch status {
+	case "insufficient":
+		return "❔"
 	case "degraded":
 		return "🔴"
 	case "improved":
@@ -3,6 +3,9 @@ package diff
 import (
 	"bytes"
 	"context"
+	"errors"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,8 +17,11 @@ import (
 // ──────────────────────────────────────────────
 
 type mockSignozClient struct {
-	listServicesFunc func(ctx context.Context) ([]types.Service, error)
-	queryLogsFunc    func(ctx context.Context, service string, durationMinutes, limit int, severityFilter string) (*types.QueryResult, error)
+	listServicesFunc      func(ctx context.Context) ([]types.Service, error)
+	queryLogsFunc         func(ctx context.Context, service string, durationMinutes, limit int, severityFilter string) (*types.QueryResult, error)
+	listServicesRangeFunc func(ctx context.Context, start, end time.Time) ([]types.Service, error)
+	queryLogsRangeFunc    func(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error)
+	queryTracesRangeFunc  func(ctx context.Context, service string, start, end time.Time, limit int) (*types.QueryResult, error)
 }
 
 func (m *mockSignozClient) Health(ctx context.Context) (bool, time.Duration, error) {
@@ -44,6 +50,27 @@ func (m *mockSignozClient) QueryMetrics(ctx context.Context, metricName string,
 	return &types.QueryResult{}, nil
 }
 
+func (m *mockSignozClient) ListServicesRange(ctx context.Context, start, end time.Time) ([]types.Service, error) {
+	if m.listServicesRangeFunc != nil {
+		return m.listServicesRangeFunc(ctx, start, end)
+	}
+	return m.ListServices(ctx)
+}
+
+func (m *mockSignozClient) QueryLogsRange(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+	if m.queryLogsRangeFunc != nil {
+		return m.queryLogsRangeFunc(ctx, service, start, end, limit, severityFilter)
+	}
+	return &types.QueryResult{}, nil
+}
+
+func (m *mockSignozClient) QueryTracesRange(ctx context.Context, service string, start, end time.Time, limit int) (*types.QueryResult, error) {
+	if m.queryTracesRangeFunc != nil {
+		return m.queryTracesRangeFunc(ctx, service, start, end, limit)
+	}
+	return &types.QueryResult{}, nil
+}
+
 // ──────────────────────────────────────────────
 // Compare Tests (mock-based)
 // ──────────────────────────────────────────────
@@ -56,24 +83,15 @@ func TestCompareWithMockClient(t *testing.T) {
 				{Name: "api", NumCalls: 100, NumErrors: 10},
 			}, nil
 		},
-		queryLogsFunc: func(ctx context.Context, service string, durationMinutes, limit int, severityFilter string) (*types.QueryResult, error) {
-			if durationMinutes == 60 {
-				// Recent window
-				return &types.QueryResult{
-					Logs: []types.LogEntry{
-						{ServiceName: "api", Timestamp: now.Add(-10 * time.Minute)},
-						{ServiceName: "api", Timestamp: now.Add(-20 * time.Minute)},
-					},
-				}, nil
+		queryLogsRangeFunc: func(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+			var logs []types.LogEntry
+			for _, ago := range []time.Duration{10, 20, 70} {
+				ts := now.Add(-ago * time.Minute)
+				if !ts.Before(start) && ts.Before(end) {
+					logs = append(logs, types.LogEntry{ServiceName: "api", Timestamp: ts})
+				}
 			}
-			// Full window (includes previous)
-			return &types.QueryResult{
-				Logs: []types.LogEntry{
-					{ServiceName: "api", Timestamp: now.Add(-10 * time.Minute)},
-					{ServiceName: "api", Timestamp: now.Add(-20 * time.Minute)},
-					{ServiceName: "api", Timestamp: now.Add(-70 * time.Minute)},
-				},
-			}, nil
+			return &types.QueryResult{Logs: logs}, nil
 		},
 	}
 
@@ -87,6 +105,40 @@ func TestCompareWithMockClient(t *testing.T) {
 	if result.DurationMin != 60 {
 		t.Errorf("expected duration=60, got %d", result.DurationMin)
 	}
+	if s := result.Summary; s.TotalErrorsBefore != 1 || s.TotalErrorsAfter != 2 {
+		t.Errorf("expected 1 error before and 2 after, got %+v", s)
+	}
+}
+
+func TestCompareQueriesWindowsSeparately(t *testing.T) {
+	// A full recent window must not crowd the previous one out of a shared limit.
+	now := time.Now()
+	var ranges [][2]time.Time
+	mock := &mockSignozClient{
+		queryLogsRangeFunc: func(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+			ranges = append(ranges, [2]time.Time{start, end})
+			if end.Before(now.Add(-59 * time.Minute)) {
+				return &types.QueryResult{Logs: errorLogsAt("checkout", "timeout calling payments", 20, now.Add(-90*time.Minute))}, nil
+			}
+			return &types.QueryResult{Logs: errorLogsAt("checkout", "timeout calling payments", 500, now.Add(-5*time.Minute))}, nil
+		},
+	}
+
+	result, err := Compare(context.Background(), mock, "prod", Options{Duration: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 2 || !ranges[1][1].Equal(ranges[0][0]) {
+		t.Fatalf("expected the previous window to end where the recent one starts, got %v", ranges)
+	}
+	if s := result.Summary; s.TotalErrorsBefore != 20 || s.TotalErrorsAfter != 500 {
+		t.Errorf("expected 20 errors before and 500 after, got %+v", s)
+	}
+	for _, p := range result.Patterns {
+		if p.Status == "new" || p.Status == "gone" {
+			t.Errorf("a pattern seen in both windows is neither new nor gone: %+v", p)
+		}
+	}
 }
 
 func TestCountByService(t *testing.T) {
@@ -146,3 +198,483 @@ func TestStatusEmoji(t *testing.T) {
 		t.Error("wrong emoji for improved")
 	}
 }
+
+// ──────────────────────────────────────────────
+// CompareAround Tests
+// ──────────────────────────────────────────────
+
+// aroundMock returns a client whose before/after windows are split at around.
+func aroundMock(around time.Time, before, after []types.Service, beforeLogs, afterLogs []types.LogEntry, beforeSpans, afterSpans []types.TraceEntry) *mockSignozClient {
+	return &mockSignozClient{
+		listServicesRangeFunc: func(ctx context.Context, start, end time.Time) ([]types.Service, error) {
+			if start.Before(around) {
+				return before, nil
+			}
+			return after, nil
+		},
+		queryLogsRangeFunc: func(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+			if start.Before(around) {
+				return &types.QueryResult{Logs: beforeLogs}, nil
+			}
+			return &types.QueryResult{Logs: afterLogs}, nil
+		},
+		queryTracesRangeFunc: func(ctx context.Context, service string, start, end time.Time, limit int) (*types.QueryResult, error) {
+			if start.Before(around) {
+				return &types.QueryResult{Traces: beforeSpans}, nil
+			}
+			return &types.QueryResult{Traces: afterSpans}, nil
+		},
+	}
+}
+
+func spans(service string, durationsMs ...float64) []types.TraceEntry {
+	var out []types.TraceEntry
+	for _, d := range durationsMs {
+		out = append(out, types.TraceEntry{ServiceName: service, DurationNano: int64(d * 1e6)})
+	}
+	return out
+}
+
+func TestCompareAroundDetectsRegressions(t *testing.T) {
+	around := time.Now().Add(-time.Hour)
+	mock := aroundMock(around,
+		[]types.Service{{Name: "checkout", NumCalls: 3000, NumErrors: 3, ErrorRate: 0.1}},
+		[]types.Service{{Name: "checkout", NumCalls: 1000, NumErrors: 50, ErrorRate: 5.0}},
+		[]types.LogEntry{{ServiceName: "checkout", Body: "timeout calling payments after 3000ms"}},
+		append(errorLogs("checkout", "NullPointerException at CartService.java:42", 5),
+			types.LogEntry{ServiceName: "checkout", Body: "timeout calling payments after 5000ms"}),
+		spans("checkout", 100, 110, 120, 130),
+		spans("checkout", 300, 310, 320, 330),
+	)
+
+	result, err := CompareAround(context.Background(), mock, "prod", Options{
+		Around:     around,
+		Window:     30 * time.Minute,
+		Thresholds: DefaultThresholds(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.DurationMin != 30 {
+		t.Errorf("expected 30 min windows, got %d", result.DurationMin)
+	}
+	if len(result.Services) != 1 {
+		t.Fatalf("expected 1 service, got %d", len(result.Services))
+	}
+
+	s := result.Services[0]
+	if s.ThroughputBefore != 100 || s.ThroughputAfter != 1000.0/30 {
+		t.Errorf("unexpected throughput: %.1f → %.1f", s.ThroughputBefore, s.ThroughputAfter)
+	}
+	if s.P99Before != 130 || s.P99After != 330 {
+		t.Errorf("unexpected p99: %.0f → %.0f", s.P99Before, s.P99After)
+	}
+
+	if len(result.Patterns) != 1 || result.Patterns[0].Status != "new" ||
+		result.Patterns[0].Sample != "NullPointerException at CartService.java:42" {
+		t.Errorf("expected only the NPE as a new pattern, got %+v", result.Patterns)
+	}
+
+	metrics := make(map[string]bool)
+	for _, r := range result.Regressions {
+		metrics[r.Metric] = true
+	}
+	for _, m := range []string{"error_rate", "p99", "throughput", "new_error_pattern"} {
+		if !metrics[m] {
+			t.Errorf("expected %s regression, got %+v", m, result.Regressions)
+		}
+	}
+	if result.ExitCode() != 2 {
+		t.Errorf("expected exit code 2, got %d", result.ExitCode())
+	}
+}
+
+func TestCompareAroundNoRegressions(t *testing.T) {
+	around := time.Now().Add(-time.Hour)
+	svc := []types.Service{{Name: "api", NumCalls: 1000, NumErrors: 1, ErrorRate: 0.1}}
+	mock := aroundMock(around, svc, svc, nil, nil, spans("api", 50, 60), spans("api", 50, 61))
+
+	result, err := CompareAround(context.Background(), mock, "prod", Options{
+		Around:     around,
+		Window:     30 * time.Minute,
+		Thresholds: DefaultThresholds(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Regressions) != 0 {
+		t.Errorf("expected no regressions, got %+v", result.Regressions)
+	}
+	if result.ExitCode() != 0 {
+		t.Errorf("expected exit code 0, got %d", result.ExitCode())
+	}
+
+	var buf bytes.Buffer
+	result.RenderTerminal(&buf)
+	if !strings.Contains(buf.String(), "PASS") {
+		t.Error("expected PASS verdict in output")
+	}
+}
+
+func TestCompareAroundTruncatesToNow(t *testing.T) {
+	around := time.Now().Add(-10 * time.Minute)
+	mock := aroundMock(around, nil, nil, nil, nil, nil, nil)
+
+	result, err := CompareAround(context.Background(), mock, "prod", Options{Around: around, Window: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.DurationMin != 10 {
+		t.Errorf("expected windows shortened to 10 min, got %d", result.DurationMin)
+	}
+}
+
+func TestCompareAroundPartialSpanSample(t *testing.T) {
+	// The after window's sample is full: only its last moments, where a
+	// slow burst happened to land. The whole-window p99 must be used instead.
+	around := time.Now().Add(-time.Hour)
+	burst := make([]float64, windowSpans)
+	for i := range burst {
+		burst[i] = 900
+	}
+	mock := aroundMock(around,
+		[]types.Service{{Name: "checkout", NumCalls: 3000, P99: 120e6}},
+		[]types.Service{{Name: "checkout", NumCalls: 3000, P99: 125e6}},
+		nil, nil,
+		spans("checkout", 100, 110, 120),
+		spans("checkout", burst...),
+	)
+
+	result, err := CompareAround(context.Background(), mock, "prod", Options{Around: around, Window: 30 * time.Minute, Thresholds: DefaultThresholds()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := result.Services[0]; s.P99After != 125 {
+		t.Errorf("expected the services endpoint p99 for a partial sample, got %.0f", s.P99After)
+	}
+	if len(result.Regressions) != 0 {
+		t.Errorf("expected no regressions, got %+v", result.Regressions)
+	}
+}
+
+func TestCompareAroundQueryErrors(t *testing.T) {
+	around := time.Now().Add(-time.Hour)
+	mock := aroundMock(around, nil, nil, nil, nil, nil, nil)
+	mock.queryLogsRangeFunc = func(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+		return nil, errors.New("signoz unavailable")
+	}
+	if _, err := CompareAround(context.Background(), mock, "prod", Options{Around: around}); err == nil {
+		t.Error("a failed log query must fail the gate, not pass it")
+	}
+
+	mock = aroundMock(around, nil, nil, nil, nil, nil, nil)
+	mock.queryTracesRangeFunc = func(ctx context.Context, service string, start, end time.Time, limit int) (*types.QueryResult, error) {
+		return nil, errors.New("signoz unavailable")
+	}
+	if _, err := CompareAround(context.Background(), mock, "prod", Options{Around: around}); err == nil {
+		t.Error("a failed span query must fail the gate, not pass it")
+	}
+}
+
+func TestCompareAroundSamplesBeforeWindow(t *testing.T) {
+	// The NPE fired early in the before window, behind more than 500 newer lines.
+	around := time.Now().Add(-time.Hour)
+	npe := "NullPointerException at CartService.java:42"
+	beforeLogs := append(errorLogs("checkout", "timeout calling payments", 600), errorLogs("checkout", npe, 10)...)
+	mock := aroundMock(around, nil, nil, nil, errorLogs("checkout", npe, 6), nil, nil)
+	mock.queryLogsRangeFunc = func(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+		logs := errorLogs("checkout", npe, 6)
+		if start.Before(around) {
+			logs = beforeLogs
+		}
+		if len(logs) > limit {
+			logs = logs[:limit] // newest first
+		}
+		return &types.QueryResult{Logs: logs}, nil
+	}
+
+	result, err := CompareAround(context.Background(), mock, "prod", Options{Around: around, Window: 30 * time.Minute, Thresholds: DefaultThresholds()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, p := range result.Patterns {
+		if p.Status == "new" {
+			t.Errorf("a pattern seen early in the before window is not new: %+v", p)
+		}
+	}
+	if result.PatternBaseline != 0 || result.ExitCode() != 0 {
+		t.Errorf("expected the whole before window to be seen, got baseline %d, exit %d", result.PatternBaseline, result.ExitCode())
+	}
+
+	// Past the sample size, "new" is hedged.
+	beforeLogs = errorLogs("checkout", "timeout calling payments", baselineLogs+10)
+	result, err = CompareAround(context.Background(), mock, "prod", Options{Around: around, Window: 30 * time.Minute, Thresholds: DefaultThresholds()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.PatternBaseline != baselineLogs || len(result.Regressions) != 1 ||
+		!strings.Contains(result.Regressions[0].Message, "not seen in the sampled 5000 lines before") {
+		t.Errorf("expected a hedged new pattern, got baseline %d, %+v", result.PatternBaseline, result.Regressions)
+	}
+	var buf bytes.Buffer
+	result.RenderTerminal(&buf)
+	if !strings.Contains(buf.String(), "New Error Patterns (not seen in the sampled 5000 lines before)") {
+		t.Errorf("expected a hedged section title, got:\n%s", buf.String())
+	}
+}
+
+func TestRenderTerminalVerdictWithoutServices(t *testing.T) {
+	r := &DiffResult{Instance: "prod", Around: time.Now()}
+	var buf bytes.Buffer
+	r.RenderTerminal(&buf)
+	if !strings.Contains(buf.String(), "No services with errors found") || !strings.Contains(buf.String(), "PASS") {
+		t.Errorf("expected the verdict even without services, got:\n%s", buf.String())
+	}
+
+	r.Regressions = []Regression{{Service: "checkout", Metric: "new_error_pattern", Message: "new error pattern (6x): NPE"}}
+	buf.Reset()
+	r.RenderTerminal(&buf)
+	if !strings.Contains(buf.String(), "FAIL — 1 regression(s)") {
+		t.Errorf("expected a FAIL verdict, got:\n%s", buf.String())
+	}
+}
+
+func TestCompareAroundFuture(t *testing.T) {
+	mock := aroundMock(time.Now(), nil, nil, nil, nil, nil, nil)
+	_, err := CompareAround(context.Background(), mock, "prod", Options{Around: time.Now().Add(time.Hour)})
+	if err == nil {
+		t.Error("expected error for --around in the future")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	if got := percentile(sorted, 50); got != 5 {
+		t.Errorf("p50 = %.0f, want 5", got)
+	}
+	if got := percentile(sorted, 99); got != 10 {
+		t.Errorf("p99 = %.0f, want 10", got)
+	}
+	if got := percentile(nil, 99); got != 0 {
+		t.Errorf("empty p99 = %.0f, want 0", got)
+	}
+}
+
+func TestParseAround(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", origHome)
+
+	now := time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC)
+
+	got, err := ParseAround("2026-03-01T14:32:00Z", now)
+	if err != nil || !got.Equal(time.Date(2026, 3, 1, 14, 32, 0, 0, time.UTC)) {
+		t.Errorf("RFC3339: got %v, %v", got, err)
+	}
+
+	got, err = ParseAround("14:32", now)
+	if err != nil || got.Hour() != 14 || got.Minute() != 32 || got.Day() != 1 {
+		t.Errorf("clock time: got %v, %v", got, err)
+	}
+
+	got, err = ParseAround("1772375520", now)
+	if err != nil || got.Unix() != 1772375520 {
+		t.Errorf("unix: got %v, %v", got, err)
+	}
+
+	if _, err := ParseAround("deploy-42", now); err == nil {
+		t.Error("expected error for unknown marker")
+	}
+
+	deployAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := SaveMarker(Marker{Name: "deploy-42", Time: deployAt}); err != nil {
+		t.Fatalf("SaveMarker: %v", err)
+	}
+	got, err = ParseAround("deploy-42", now)
+	if err != nil || !got.Equal(deployAt) {
+		t.Errorf("marker: got %v, %v", got, err)
+	}
+	got, err = ParseAround("last", now)
+	if err != nil || !got.Equal(deployAt) {
+		t.Errorf("last marker: got %v, %v", got, err)
+	}
+}
+
+// ──────────────────────────────────────────────
+// Significance Tests
+// ──────────────────────────────────────────────
+
+func TestClassifyNotEnoughData(t *testing.T) {
+	var summary DiffSummary
+	d := ServiceDiff{Name: "cron", ErrorsBefore: 1, ErrorsAfter: 2, ErrorsChange: 100}
+	classify(&d, &summary, Options{}.withDefaults())
+
+	if d.Status != "insufficient" {
+		t.Errorf("expected insufficient for 1 → 2 errors, got %s", d.Status)
+	}
+	if summary.Insufficient != 1 {
+		t.Errorf("expected Insufficient=1, got %d", summary.Insufficient)
+	}
+}
+
+func TestClassifyPoissonDegraded(t *testing.T) {
+	var summary DiffSummary
+	d := ServiceDiff{Name: "api", ErrorsBefore: 10, ErrorsAfter: 40, ErrorsChange: 300}
+	classify(&d, &summary, Options{}.withDefaults())
+
+	if d.Test != "poisson" {
+		t.Errorf("expected poisson test without call counts, got %q", d.Test)
+	}
+	if !d.Significant || d.Status != "degraded" {
+		t.Errorf("expected significant degradation, got %s (p=%f)", d.Status, d.PValue)
+	}
+}
+
+func TestClassifyZTestNotSignificant(t *testing.T) {
+	var summary DiffSummary
+	// 6 → 9 errors on 1000 calls: +50% but well within noise.
+	d := ServiceDiff{Name: "api", CallsBefore: 1000, CallsAfter: 1000, ErrorsBefore: 6, ErrorsAfter: 9,
+		RateBefore: 0.6, RateAfter: 0.9, ErrorsChange: 50}
+	classify(&d, &summary, Options{}.withDefaults())
+
+	if d.Test != "z-test" {
+		t.Errorf("expected z-test with call counts, got %q", d.Test)
+	}
+	if d.Significant || d.Status != "stable" {
+		t.Errorf("expected stable (not significant), got %s (p=%f)", d.Status, d.PValue)
+	}
+}
+
+func TestClassifyMinCalls(t *testing.T) {
+	var summary DiffSummary
+	d := ServiceDiff{Name: "api", CallsBefore: 10, CallsAfter: 10, ErrorsBefore: 3, ErrorsAfter: 8}
+	classify(&d, &summary, Options{MinCalls: 50}.withDefaults())
+
+	if d.Status != "insufficient" {
+		t.Errorf("expected insufficient below MinCalls, got %s", d.Status)
+	}
+}
+
+func TestRenderTerminalShowsPValues(t *testing.T) {
+	r := &DiffResult{
+		Instance:    "prod",
+		DurationMin: 60,
+		Services: []ServiceDiff{
+			{Name: "api", ErrorsBefore: 10, ErrorsAfter: 40, ErrorsChange: 300, Status: "degraded", Test: "poisson", PValue: 0.0001, Significant: true},
+			{Name: "cron", ErrorsBefore: 1, ErrorsAfter: 2, ErrorsChange: 100, Status: "insufficient", PValue: 1},
+		},
+		Summary: DiffSummary{Degraded: 1, Insufficient: 1},
+	}
+
+	var buf bytes.Buffer
+	r.RenderTerminal(&buf)
+	out := buf.String()
+	if !strings.Contains(out, "P-VALUE") || !strings.Contains(out, "<0.001") {
+		t.Error("expected p-value column")
+	}
+	if !strings.Contains(out, "not enough data") {
+		t.Error("expected 'not enough data' status")
+	}
+}
+
+// ──────────────────────────────────────────────
+// Pattern Tests
+// ──────────────────────────────────────────────
+
+func errorLogs(service, body string, n int) []types.LogEntry {
+	var out []types.LogEntry
+	for i := 0; i < n; i++ {
+		out = append(out, types.LogEntry{ServiceName: service, Body: body})
+	}
+	return out
+}
+
+func errorLogsAt(service, body string, n int, ts time.Time) []types.LogEntry {
+	out := errorLogs(service, body, n)
+	for i := range out {
+		out[i].Timestamp = ts
+	}
+	return out
+}
+
+func TestComparePatterns(t *testing.T) {
+	var before, after []types.LogEntry
+	before = append(before, errorLogs("checkout", "timeout calling payments after 3000ms", 5)...)
+	before = append(before, errorLogs("auth", "token expired for user 42", 8)...)
+	before = append(before, errorLogs("api", "cache miss for key 7", 10)...)
+
+	after = append(after, errorLogs("checkout", "timeout calling payments after 5000ms", 40)...)
+	after = append(after, errorLogs("checkout", "NullPointerException at CartService.java:42", 6)...)
+	after = append(after, errorLogs("api", "connection reset by peer", 1)...)
+	after = append(after, errorLogs("api", "cache miss for key 9", 12)...)
+
+	changes := comparePatterns(before, after, Options{}.withDefaults())
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 pattern changes, got %+v", changes)
+	}
+
+	want := []struct {
+		status  string
+		service string
+		before  int
+		after   int
+	}{
+		{"new", "checkout", 0, 6},
+		{"grew", "checkout", 5, 40},
+		{"gone", "auth", 8, 0},
+	}
+	for i, w := range want {
+		c := changes[i]
+		if c.Status != w.status || c.Service != w.service || c.Before != w.before || c.After != w.after {
+			t.Errorf("change %d: expected %+v, got %+v", i, w, c)
+		}
+	}
+	if !strings.Contains(changes[0].Sample, "NullPointerException") {
+		t.Errorf("expected raw sample for new pattern, got %q", changes[0].Sample)
+	}
+}
+
+func TestComparePatternsIgnoresStrayNewError(t *testing.T) {
+	after := errorLogs("api", "connection reset by peer", 1)
+
+	if changes := comparePatterns(nil, after, Options{}.withDefaults()); len(changes) != 0 {
+		t.Errorf("a single error line should not count as a new pattern, got %+v", changes)
+	}
+}
+
+func TestComparePatternsIgnoresNoisyGrowth(t *testing.T) {
+	before := errorLogs("api", "cache miss", 2)
+	after := errorLogs("api", "cache miss", 5)
+
+	if changes := comparePatterns(before, after, Options{}.withDefaults()); len(changes) != 0 {
+		t.Errorf("2 → 5 should not count as growth, got %+v", changes)
+	}
+}
+
+func TestCompareReportsNewPattern(t *testing.T) {
+	now := time.Now()
+	mock := &mockSignozClient{
+		queryLogsRangeFunc: func(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+			if end.Before(now.Add(-59 * time.Minute)) {
+				return &types.QueryResult{Logs: errorLogsAt("checkout", "timeout calling payments", 6, now.Add(-90*time.Minute))}, nil
+			}
+			return &types.QueryResult{Logs: errorLogsAt("checkout", "NullPointerException at CartService.java:42", 6, now.Add(-5*time.Minute))}, nil
+		},
+	}
+
+	result, err := Compare(context.Background(), mock, "prod", Options{Duration: 60})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	result.RenderTerminal(&buf)
+	out := buf.String()
+	if !strings.Contains(out, "New Error Patterns") || !strings.Contains(out, "NullPointerException") {
+		t.Errorf("expected new NPE pattern in output, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Gone Error Patterns") {
+		t.Errorf("expected gone pattern section, got:\n%s", out)
+	}
+}
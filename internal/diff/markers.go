@@ -0,0 +1,112 @@
+package diff
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Marker is a named point in time, typically a deploy.
+type Marker struct {
+	Name string    `yaml:"name" json:"name"`
+	Time time.Time `yaml:"time" json:"time"`
+	Note string    `yaml:"note,omitempty" json:"note,omitempty"`
+}
+
+type markerFile struct {
+	Markers []Marker `yaml:"markers"`
+}
+
+func markersPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".argus", "markers.yaml")
+}
+
+// LoadMarkers reads saved markers, oldest first. A missing file is not an error.
+func LoadMarkers() ([]Marker, error) {
+	data, err := os.ReadFile(markersPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading markers: %w", err)
+	}
+	var f markerFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing markers: %w", err)
+	}
+	sort.Slice(f.Markers, func(i, j int) bool { return f.Markers[i].Time.Before(f.Markers[j].Time) })
+	return f.Markers, nil
+}
+
+// SaveMarker records a marker, replacing any existing marker with the same name.
+func SaveMarker(m Marker) error {
+	markers, err := LoadMarkers()
+	if err != nil {
+		return err
+	}
+	var kept []Marker
+	for _, existing := range markers {
+		if existing.Name != m.Name {
+			kept = append(kept, existing)
+		}
+	}
+	kept = append(kept, m)
+
+	if err := os.MkdirAll(filepath.Dir(markersPath()), 0700); err != nil {
+		return fmt.Errorf("creating config dir: %w", err)
+	}
+	data, err := yaml.Marshal(markerFile{Markers: kept})
+	if err != nil {
+		return fmt.Errorf("marshaling markers: %w", err)
+	}
+	return os.WriteFile(markersPath(), data, 0600)
+}
+
+// ParseAround resolves an --around value to a point in time. It accepts
+// RFC3339, "2006-01-02 15:04[:05]", "15:04[:05]" (today, local time), unix
+// seconds, "last" (the most recent marker) or the name of a saved marker.
+func ParseAround(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("empty --around value")
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			y, m, d := now.Date()
+			return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, now.Location()), nil
+		}
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+
+	markers, err := LoadMarkers()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if value == "last" && len(markers) > 0 {
+		return markers[len(markers)-1].Time, nil
+	}
+	for _, m := range markers {
+		if m.Name == value {
+			return m.Time, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse --around %q: not a timestamp or known marker (see 'argus diff mark')", value)
+}
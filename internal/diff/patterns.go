@@ -0,0 +1,197 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lbarahona/argus/internal/logpattern"
+	"github.com/lbarahona/argus/internal/stats"
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// growthFactor is how much a pattern must grow (after/before) before it is
+// reported as "grew", in addition to being statistically significant.
+const growthFactor = 2.0
+
+// maxPatternsShown caps how many patterns of each kind are rendered.
+const maxPatternsShown = 10
+
+// PatternChange is an error log pattern whose frequency changed between windows.
+type PatternChange struct {
+	Service string
+	Pattern string // template, variables masked or <*>
+	Sample  string // one raw message, preferring the later window
+	Before  int
+	After   int
+	Status  string // "new", "gone", "grew"
+	PValue  float64
+}
+
+// comparePatterns clusters error log bodies from both windows and returns the
+// patterns that are new, gone, or grew sharply, ordered by status then count.
+// New and grown patterns need at least opts.MinErrors lines, so a stray error
+// doesn't count as a regression.
+// Both windows share one miner per service so that a template is the same
+// cluster on either side, however much it generalizes.
+func comparePatterns(before, after []types.LogEntry, opts Options) []PatternChange {
+	type key struct {
+		service string
+		cluster *logpattern.Cluster
+	}
+	miners := make(map[string]*logpattern.Miner)
+	changes := make(map[key]*PatternChange)
+	add := func(l types.LogEntry, later bool) {
+		m, ok := miners[l.ServiceName]
+		if !ok {
+			m = logpattern.New(logpattern.Options{})
+			miners[l.ServiceName] = m
+		}
+		cl := m.Add(l.Body)
+		if cl == nil {
+			return
+		}
+		k := key{l.ServiceName, cl}
+		c, ok := changes[k]
+		if !ok {
+			c = &PatternChange{Service: l.ServiceName}
+			changes[k] = c
+		}
+		if later {
+			if c.After == 0 {
+				c.Sample = strings.TrimSpace(l.Body)
+			}
+			c.After++
+		} else {
+			if c.Sample == "" {
+				c.Sample = strings.TrimSpace(l.Body)
+			}
+			c.Before++
+		}
+	}
+	for _, l := range before {
+		add(l, false)
+	}
+	for _, l := range after {
+		add(l, true)
+	}
+	for k, c := range changes {
+		c.Pattern = k.cluster.Template()
+	}
+
+	var out []PatternChange
+	for _, c := range changes {
+		switch {
+		case c.Before == 0:
+			if c.After < opts.MinErrors {
+				continue
+			}
+			c.Status = "new"
+		case c.After == 0:
+			c.Status = "gone"
+		case float64(c.After) >= growthFactor*float64(c.Before) && c.After >= opts.MinErrors:
+			// Windows are the same length, so the exposures cancel out.
+			c.PValue = stats.PoissonRateTest(c.Before, 1, c.After, 1)
+			if c.PValue >= 1-opts.Confidence {
+				continue
+			}
+			c.Status = "grew"
+		default:
+			continue
+		}
+		out = append(out, *c)
+	}
+
+	order := map[string]int{"new": 0, "grew": 1, "gone": 2}
+	sort.Slice(out, func(i, j int) bool {
+		if order[out[i].Status] != order[out[j].Status] {
+			return order[out[i].Status] < order[out[j].Status]
+		}
+		ci, cj := out[i].After, out[j].After
+		if out[i].Status == "gone" {
+			ci, cj = out[i].Before, out[j].Before
+		}
+		if ci != cj {
+			return ci > cj
+		}
+		if out[i].Service != out[j].Service {
+			return out[i].Service < out[j].Service
+		}
+		return out[i].Pattern < out[j].Pattern
+	})
+	return out
+}
+
+func filterWindow(logs []types.LogEntry, from, to time.Time) []types.LogEntry {
+	var out []types.LogEntry
+	for _, l := range logs {
+		if (l.Timestamp.Equal(from) || l.Timestamp.After(from)) && l.Timestamp.Before(to) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// newPatternLabel describes a new pattern, hedged when the earlier window was
+// only sampled.
+func (r *DiffResult) newPatternLabel() string {
+	if r.PatternBaseline > 0 {
+		return fmt.Sprintf("error pattern not seen in the sampled %d lines before", r.PatternBaseline)
+	}
+	return "new error pattern"
+}
+
+// renderPatterns prints new, grown and vanished error patterns.
+func (r *DiffResult) renderPatterns(w io.Writer) {
+	sections := []struct {
+		status string
+		title  string
+	}{
+		{"new", "🆕 New Error Patterns"},
+		{"grew", "📈 Growing Error Patterns"},
+		{"gone", "👻 Gone Error Patterns"},
+	}
+
+	for _, sec := range sections {
+		var matched []PatternChange
+		for _, p := range r.Patterns {
+			if p.Status == sec.status {
+				matched = append(matched, p)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		title := sec.title
+		if sec.status == "new" && r.PatternBaseline > 0 {
+			title += fmt.Sprintf(" (not seen in the sampled %d lines before)", r.PatternBaseline)
+		}
+		fmt.Fprintf(w, "\n  %s\n", title)
+		shown := matched
+		if len(shown) > maxPatternsShown {
+			shown = shown[:maxPatternsShown]
+		}
+		for i, p := range shown {
+			connector := "├─"
+			if i == len(shown)-1 && len(matched) == len(shown) {
+				connector = "└─"
+			}
+			var counts string
+			switch p.Status {
+			case "new":
+				counts = fmt.Sprintf("%dx", p.After)
+			case "gone":
+				counts = fmt.Sprintf("was %dx", p.Before)
+			default:
+				counts = fmt.Sprintf("%d → %d", p.Before, p.After)
+			}
+			fmt.Fprintf(w, "  %s [%s] (%s) %s\n", connector, p.Service, counts, truncate(p.Sample, 70))
+		}
+		if len(matched) > len(shown) {
+			fmt.Fprintf(w, "  └─ … and %d more\n", len(matched)-len(shown))
+		}
+	}
+}
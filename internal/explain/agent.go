@@ -0,0 +1,523 @@
+package explain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lbarahona/argus/internal/ai"
+	"github.com/lbarahona/argus/internal/cite"
+	"github.com/lbarahona/argus/internal/signoz"
+	"github.com/lbarahona/argus/internal/stats"
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+const (
+	// DefaultMaxSteps caps how many rounds of tool calls the model may make.
+	DefaultMaxSteps = 8
+	// maxToolOutput bounds each tool result so one query can't fill the context.
+	maxToolOutput = 8000
+)
+
+const agentSystemPrompt = `You are an expert Site Reliability Engineer investigating a service on a Signoz instance.
+
+You have tools that query the instance's services, logs, traces and metrics. Use them to gather the evidence you need: start broad, then drill into whatever looks wrong (error messages, slow or failing spans, dependencies, changes versus the previous window). Every round of tool calls uses one step of a small budget, so request several tools at once when they are independent, and stop calling tools as soon as you can explain what is happening.
+
+Base every claim on data you retrieved and quote the log messages and spans you rely on. Format your final answer with clear markdown sections.`
+
+// Investigate runs an agentic root cause analysis starting from data, as
+// returned by Collect: the model pulls whatever else it needs through tools
+// backed by client, for at most opts.MaxSteps rounds, and then writes its
+// analysis. Each tool call is shown on w as it happens, and the tokens used
+// by all rounds after the analysis.
+func Investigate(ctx context.Context, client signoz.SignozQuerier, data *CorrelatedData, opts Options, w io.Writer) error {
+	analyzer, err := ai.FromProfile(opts.AI)
+	if err != nil {
+		return err
+	}
+	answer, _, err := runAgent(ctx, analyzer, client, data, opts, "", w)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, data.Refs.Link(answer))
+	writeUsage(w, analyzer)
+	return nil
+}
+
+// Assess runs the same investigation as Investigate but concludes with a
+// validated ai.Assessment instead of prose. An invalid answer is sent back
+// once for correction. Tool calls and token usage are shown on w.
+func Assess(ctx context.Context, client signoz.SignozQuerier, data *CorrelatedData, opts Options, w io.Writer) (*ai.Assessment, error) {
+	analyzer, err := ai.FromProfile(opts.AI)
+	if err != nil {
+		return nil, err
+	}
+	answer, messages, err := runAgent(ctx, analyzer, client, data, opts, ai.StructuredRequest, w)
+	if err != nil {
+		return nil, err
+	}
+	assessment, err := ai.ParseAssessment(answer)
+	if err != nil {
+		analyzer.Forget()
+		messages = append(messages, ai.ToolMessage{Role: "user", Content: []ai.ContentBlock{{Type: "text", Text: ai.RetryRequest(err)}}})
+		resp, err := analyzer.Converse(ctx, agentSystemPrompt, messages, agentTools(), false)
+		if err != nil {
+			return nil, err
+		}
+		if assessment, err = ai.ParseAssessment(resp.Text()); err != nil {
+			analyzer.Forget()
+			return nil, fmt.Errorf("model returned an invalid assessment twice: %w", err)
+		}
+	}
+	data.Refs.LinkAssessment(assessment)
+	writeUsage(w, analyzer)
+	return assessment, nil
+}
+
+func writeUsage(w io.Writer, analyzer *ai.Analyzer) {
+	if usage := analyzer.Usage(); !usage.Empty() {
+		fmt.Fprintf(w, "\n🪙 %s\n", usage)
+	}
+}
+
+// runAgent runs the tool loop and returns the model's final answer with the
+// conversation so far. request, when set, is appended to the first prompt to
+// change the answer format.
+func runAgent(ctx context.Context, analyzer *ai.Analyzer, client signoz.SignozQuerier, data *CorrelatedData, opts Options, request string, w io.Writer) (string, []ai.ToolMessage, error) {
+	maxSteps := opts.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	inv := &investigation{client: client, duration: opts.Duration, end: time.Now().UTC(), refs: data.Refs}
+	if inv.duration <= 0 {
+		inv.duration = 60
+	}
+	tools := agentTools()
+
+	messages := []ai.ToolMessage{{
+		Role:    "user",
+		Content: []ai.ContentBlock{{Type: "text", Text: buildAgentPrompt(data, inv.duration) + request}},
+	}}
+
+	for step := 1; ; step++ {
+		allowTools := step <= maxSteps
+		resp, err := analyzer.Converse(ctx, agentSystemPrompt, messages, tools, allowTools)
+		if err != nil {
+			return "", nil, err
+		}
+		messages = append(messages, ai.ToolMessage{Role: "assistant", Content: resp.Content})
+
+		calls := resp.ToolCalls()
+		if len(calls) == 0 {
+			return strings.TrimSpace(resp.Text()), messages, nil
+		}
+		if !allowTools {
+			return "", nil, fmt.Errorf("model kept calling tools after the %d-step limit", maxSteps)
+		}
+		if text := strings.TrimSpace(resp.Text()); text != "" {
+			fmt.Fprintf(w, "💭 %s\n", text)
+		}
+
+		var results []ai.ContentBlock
+		for _, call := range calls {
+			out, summary, err := inv.call(ctx, call.Name, call.Input)
+			result := ai.ContentBlock{Type: "tool_result", ToolUseID: call.ID, Content: truncate(out, maxToolOutput)}
+			if err != nil {
+				result.Content, result.IsError = err.Error(), true
+				summary = "error: " + err.Error()
+			}
+			fmt.Fprintf(w, "🔧 [%d/%d] %s(%s) → %s\n", step, maxSteps, call.Name, describeInput(call.Input), summary)
+			results = append(results, result)
+		}
+		messages = append(messages, ai.ToolMessage{Role: "user", Content: results})
+
+		if step == maxSteps {
+			fmt.Fprintf(w, "⏱️  Step limit reached (%d), asking for a conclusion\n", maxSteps)
+		}
+	}
+}
+
+func buildAgentPrompt(data *CorrelatedData, duration int) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Investigate the service '%s' on Signoz instance '%s' over the last %d minutes and provide a root cause analysis.\n\n", data.Service, data.Instance, duration))
+	b.WriteString("## Service Overview\n")
+	writeServiceOverview(&b, data.Services, data.Service)
+	data.BlastRadius.writePrompt(&b)
+	writeFindingsPrompt(&b, data.Findings)
+	b.WriteString("\nUse the tools to look at logs, traces, metrics and how things changed versus the previous window before you conclude.\n")
+	if data.Refs != nil {
+		b.WriteString(cite.Instruction)
+	}
+	b.WriteString(analysisRequest)
+	return b.String()
+}
+
+// describeInput renders a tool input as sorted key=value pairs for display.
+func describeInput(input json.RawMessage) string {
+	var m map[string]interface{}
+	if err := json.Unmarshal(input, &m); err != nil || len(m) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", k, m[k])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ──────────────────────────────────────────────
+// Tools
+// ──────────────────────────────────────────────
+
+// investigation executes tool calls against Signoz. Windows are relative to
+// end, fixed when the investigation starts so repeated calls see the same data.
+type investigation struct {
+	client   signoz.SignozQuerier
+	duration int // default window, minutes
+	end      time.Time
+	refs     *cite.Refs // tags logs and traces in tool results, nil for none
+}
+
+// toolInput is the union of every tool's parameters.
+type toolInput struct {
+	Service       string  `json:"service"`
+	Severity      string  `json:"severity"`
+	Contains      string  `json:"contains"`
+	Minutes       int     `json:"minutes"`
+	OffsetMinutes int     `json:"offset_minutes"`
+	Limit         int     `json:"limit"`
+	ErrorsOnly    bool    `json:"errors_only"`
+	MinDurationMs float64 `json:"min_duration_ms"`
+	TraceID       string  `json:"trace_id"`
+	MetricName    string  `json:"metric_name"`
+}
+
+func agentTools() []ai.Tool {
+	window := `"minutes": {"type": "integer", "description": "Window length in minutes (default: the investigation window)"},
+		"offset_minutes": {"type": "integer", "description": "End the window this many minutes before now (default 0)"}`
+	schema := func(props string, required ...string) json.RawMessage {
+		req, _ := json.Marshal(required)
+		if required == nil {
+			req = []byte("[]")
+		}
+		return json.RawMessage(fmt.Sprintf(`{"type": "object", "properties": {%s}, "required": %s}`, props, req))
+	}
+	return []ai.Tool{
+		{
+			Name:        "list_services",
+			Description: "List services with call count, error count, error rate and p99 latency for a window.",
+			InputSchema: schema(window),
+		},
+		{
+			Name:        "query_logs",
+			Description: "Fetch log entries, newest first. Filter by service, severity and a case-insensitive substring of the message.",
+			InputSchema: schema(`"service": {"type": "string"},
+		"severity": {"type": "string", "enum": ["ERROR", "WARN", "INFO", "DEBUG"]},
+		"contains": {"type": "string", "description": "Only messages containing this text"},
+		"limit": {"type": "integer", "description": "Maximum entries, up to 200 (default 50)"},
+		` + window),
+		},
+		{
+			Name:        "query_traces",
+			Description: "Fetch spans of a service, newest first, optionally only failed or slow ones. Returns trace IDs for get_trace.",
+			InputSchema: schema(`"service": {"type": "string"},
+		"errors_only": {"type": "boolean"},
+		"min_duration_ms": {"type": "number"},
+		"limit": {"type": "integer", "description": "Maximum spans, up to 200 (default 50)"},
+		`+window, "service"),
+		},
+		{
+			Name:        "get_trace",
+			Description: "Show every span of one trace as a parent/child tree with durations and statuses. Spans are searched in the given window (default: the investigation window); pass the service that produced the trace ID to narrow the search.",
+			InputSchema: schema(`"trace_id": {"type": "string"},
+		"service": {"type": "string"},
+		`+window, "trace_id"),
+		},
+		{
+			Name:        "query_metric",
+			Description: "Fetch a metric's time series (averaged) with min, max, average and latest value.",
+			InputSchema: schema(`"metric_name": {"type": "string"},
+		"minutes": {"type": "integer"}`, "metric_name"),
+		},
+		{
+			Name:        "compare_windows",
+			Description: "Compare services between the window and the window of equal length before it: calls, error rate and p99, with a significance test on the error rate.",
+			InputSchema: schema(`"service": {"type": "string", "description": "Only this service (default: all)"},
+		"minutes": {"type": "integer"}`),
+		},
+	}
+}
+
+// call runs a tool and returns its output plus a short summary for display.
+func (inv *investigation) call(ctx context.Context, name string, raw json.RawMessage) (string, string, error) {
+	var in toolInput
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return "", "", fmt.Errorf("invalid input: %w", err)
+		}
+	}
+	switch name {
+	case "list_services":
+		return inv.listServices(ctx, in)
+	case "query_logs":
+		return inv.queryLogs(ctx, in)
+	case "query_traces":
+		return inv.queryTraces(ctx, in)
+	case "get_trace":
+		return inv.getTrace(ctx, in)
+	case "query_metric":
+		return inv.queryMetric(ctx, in)
+	case "compare_windows":
+		return inv.compareWindows(ctx, in)
+	default:
+		return "", "", fmt.Errorf("unknown tool %q", name)
+	}
+}
+
+func (inv *investigation) window(in toolInput) (time.Time, time.Time) {
+	minutes := in.Minutes
+	if minutes <= 0 {
+		minutes = inv.duration
+	}
+	end := inv.end.Add(-time.Duration(in.OffsetMinutes) * time.Minute)
+	return end.Add(-time.Duration(minutes) * time.Minute), end
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return 50
+	}
+	return min(limit, 200)
+}
+
+func (inv *investigation) listServices(ctx context.Context, in toolInput) (string, string, error) {
+	start, end := inv.window(in)
+	services, err := inv.client.ListServicesRange(ctx, start, end)
+	if err != nil {
+		return "", "", err
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].NumErrors > services[j].NumErrors })
+	var b strings.Builder
+	for _, s := range services {
+		b.WriteString(fmt.Sprintf("%s: %d calls, %d errors (%.2f%%), p99 %.0fms\n", s.Name, s.NumCalls, s.NumErrors, s.ErrorRate, s.P99Ms()))
+	}
+	return b.String(), fmt.Sprintf("%d services", len(services)), nil
+}
+
+func (inv *investigation) queryLogs(ctx context.Context, in toolInput) (string, string, error) {
+	start, end := inv.window(in)
+	limit := clampLimit(in.Limit)
+	fetch := limit
+	if in.Contains != "" {
+		fetch = 200 // filtered client-side, so fetch the most we allow
+	}
+	result, err := inv.client.QueryLogsRange(ctx, in.Service, start, end, fetch, in.Severity)
+	if err != nil {
+		return "", "", err
+	}
+	needle := strings.ToLower(in.Contains)
+	var b strings.Builder
+	n := 0
+	for _, l := range result.Logs {
+		if needle != "" && !strings.Contains(strings.ToLower(l.Body), needle) {
+			continue
+		}
+		if n == limit {
+			break
+		}
+		n++
+		b.WriteString(fmt.Sprintf("%s[%s] [%s] [%s] %s", inv.refs.Log(l), l.Timestamp.Format("15:04:05"), l.SeverityText, l.ServiceName, truncate(l.Body, 300)))
+		if id := l.Attributes["trace_id"]; id != "" {
+			b.WriteString(" trace_id=" + id)
+		}
+		b.WriteString("\n")
+	}
+	if n == 0 {
+		return "No matching logs.", "0 logs", nil
+	}
+	return b.String(), fmt.Sprintf("%d logs", n), nil
+}
+
+func (inv *investigation) queryTraces(ctx context.Context, in toolInput) (string, string, error) {
+	start, end := inv.window(in)
+	limit := clampLimit(in.Limit)
+	fetch := limit
+	if in.ErrorsOnly || in.MinDurationMs > 0 {
+		fetch = 500
+	}
+	result, err := inv.client.QueryTracesRange(ctx, in.Service, start, end, fetch)
+	if err != nil {
+		return "", "", err
+	}
+	var b strings.Builder
+	n := 0
+	for _, t := range result.Traces {
+		if (in.ErrorsOnly && !t.IsError()) || t.DurationMs() < in.MinDurationMs {
+			continue
+		}
+		if n == limit {
+			break
+		}
+		n++
+		b.WriteString(fmt.Sprintf("%s%s %s %s %.1fms status=%s trace_id=%s\n",
+			inv.refs.Trace(t), t.Timestamp.Format("15:04:05"), t.ServiceName, t.OperationName, t.DurationMs(), statusOf(t), t.TraceID))
+	}
+	if n == 0 {
+		return "No matching spans.", "0 spans", nil
+	}
+	return b.String(), fmt.Sprintf("%d spans", n), nil
+}
+
+func (inv *investigation) getTrace(ctx context.Context, in toolInput) (string, string, error) {
+	if in.TraceID == "" {
+		return "", "", fmt.Errorf("trace_id is required")
+	}
+	start, end := inv.window(in)
+	result, err := inv.client.QueryTracesRange(ctx, in.Service, start, end, 2000)
+	if err != nil {
+		return "", "", err
+	}
+	var spans []types.TraceEntry
+	for _, t := range result.Traces {
+		if t.TraceID == in.TraceID {
+			spans = append(spans, t)
+		}
+	}
+	if len(spans) == 0 {
+		return fmt.Sprintf("Trace %s not found among the spans in the window.", in.TraceID), "not found", nil
+	}
+
+	ids := make(map[string]bool)
+	children := make(map[string][]types.TraceEntry)
+	for _, s := range spans {
+		ids[s.SpanID] = true
+	}
+	var roots []types.TraceEntry
+	for _, s := range spans {
+		if s.ParentSpanID != "" && ids[s.ParentSpanID] {
+			children[s.ParentSpanID] = append(children[s.ParentSpanID], s)
+		} else {
+			roots = append(roots, s) // the root, or a span whose parent wasn't retrieved
+		}
+	}
+	byTime := func(ss []types.TraceEntry) {
+		sort.Slice(ss, func(i, j int) bool { return ss[i].Timestamp.Before(ss[j].Timestamp) })
+	}
+	var b strings.Builder
+	if tag := inv.refs.Trace(spans[0]); tag != "" {
+		b.WriteString(tag + "trace " + in.TraceID + "\n")
+	}
+	var walk func(s types.TraceEntry, depth int)
+	walk = func(s types.TraceEntry, depth int) {
+		b.WriteString(fmt.Sprintf("%s%s %s %.1fms status=%s\n", strings.Repeat("  ", depth), s.ServiceName, s.OperationName, s.DurationMs(), statusOf(s)))
+		kids := children[s.SpanID]
+		byTime(kids)
+		for _, c := range kids {
+			walk(c, depth+1)
+		}
+	}
+	byTime(roots)
+	for _, r := range roots {
+		walk(r, 0)
+	}
+	return b.String(), fmt.Sprintf("%d spans", len(spans)), nil
+}
+
+func (inv *investigation) queryMetric(ctx context.Context, in toolInput) (string, string, error) {
+	if in.MetricName == "" {
+		return "", "", fmt.Errorf("metric_name is required")
+	}
+	minutes := in.Minutes
+	if minutes <= 0 {
+		minutes = inv.duration
+	}
+	result, err := inv.client.QueryMetrics(ctx, in.MetricName, minutes)
+	if err != nil {
+		return "", "", err
+	}
+	points := result.Metrics
+	if len(points) == 0 {
+		return "No data points.", "0 points", nil
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Timestamp.Before(points[j].Timestamp) })
+	lo, hi, sum := points[0].Value, points[0].Value, 0.0
+	for _, p := range points {
+		lo, hi, sum = min(lo, p.Value), max(hi, p.Value), sum+p.Value
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s: %d points, min %g, max %g, avg %g, latest %g\n",
+		in.MetricName, len(points), lo, hi, sum/float64(len(points)), points[len(points)-1].Value))
+	stride := max(len(points)/60, 1) // at most ~60 points
+	for i := 0; i < len(points); i += stride {
+		b.WriteString(fmt.Sprintf("%s %g\n", points[i].Timestamp.Format("15:04"), points[i].Value))
+	}
+	return b.String(), fmt.Sprintf("%d points", len(points)), nil
+}
+
+func (inv *investigation) compareWindows(ctx context.Context, in toolInput) (string, string, error) {
+	start, end := inv.window(toolInput{Minutes: in.Minutes})
+	length := end.Sub(start)
+	after, err := inv.client.ListServicesRange(ctx, start, end)
+	if err != nil {
+		return "", "", err
+	}
+	before, err := inv.client.ListServicesRange(ctx, start.Add(-length), start)
+	if err != nil {
+		return "", "", err
+	}
+	prev := make(map[string]types.Service)
+	for _, s := range before {
+		prev[s.Name] = s
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Previous %s vs last %s:\n", length, length))
+	n := 0
+	for _, s := range after {
+		if in.Service != "" && s.Name != in.Service {
+			continue
+		}
+		n++
+		p, ok := prev[s.Name]
+		if !ok {
+			b.WriteString(fmt.Sprintf("%s: new in this window — %d calls, %.2f%% errors\n", s.Name, s.NumCalls, s.ErrorRate))
+			continue
+		}
+		line := fmt.Sprintf("%s: calls %d → %d, error rate %.2f%% → %.2f%%, p99 %.0fms → %.0fms",
+			s.Name, p.NumCalls, s.NumCalls, p.ErrorRate, s.ErrorRate, p.P99Ms(), s.P99Ms())
+		if p.NumCalls > 0 && s.NumCalls > 0 {
+			if _, pv := stats.TwoProportionZTest(p.NumErrors, p.NumCalls, s.NumErrors, s.NumCalls); pv < 0.05 {
+				line += fmt.Sprintf(" (error rate change significant, p=%.3g)", pv)
+			}
+		}
+		b.WriteString(line + "\n")
+	}
+	if n == 0 {
+		return "No matching services in the window.", "0 services", nil
+	}
+	return b.String(), fmt.Sprintf("%d services", n), nil
+}
+
+func statusOf(t types.TraceEntry) string {
+	if t.StatusCode == "" {
+		return "unset"
+	}
+	return t.StatusCode
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
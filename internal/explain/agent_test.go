@@ -0,0 +1,215 @@
+package explain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// fakeModel serves the Messages API, answering each request with the next
+// scripted reply and recording what it was sent.
+type fakeModel struct {
+	replies  []string
+	requests []map[string]interface{}
+}
+
+func (f *fakeModel) serve(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		f.requests = append(f.requests, req)
+		if len(f.replies) == 0 {
+			t.Error("unexpected extra request")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		reply := f.replies[0]
+		f.replies = f.replies[1:]
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func toolUse(id, name, input string) string {
+	return `{"type":"tool_use","id":"` + id + `","name":"` + name + `","input":` + input + `}`
+}
+
+func agentMock() *mockSignozClient {
+	now := time.Now()
+	return &mockSignozClient{
+		listServicesFunc: func(ctx context.Context) ([]types.Service, error) {
+			return []types.Service{{Name: "api", NumCalls: 1000, NumErrors: 50, ErrorRate: 5}, {Name: "db"}}, nil
+		},
+		queryLogsFunc: func(ctx context.Context, service string, d, limit int, sev string) (*types.QueryResult, error) {
+			return &types.QueryResult{Logs: []types.LogEntry{
+				{Timestamp: now, Body: "connection refused to db:5432", SeverityText: "ERROR", ServiceName: "api", Attributes: map[string]string{"trace_id": "t1"}},
+				{Timestamp: now, Body: "request served", SeverityText: "INFO", ServiceName: "api"},
+			}}, nil
+		},
+		queryTracesFunc: func(ctx context.Context, service string, d, limit int) (*types.QueryResult, error) {
+			return &types.QueryResult{Traces: []types.TraceEntry{
+				{Timestamp: now, TraceID: "t1", SpanID: "a", ServiceName: "api", OperationName: "GET /orders", DurationNano: 3e9, StatusCode: "ERROR"},
+				{Timestamp: now.Add(time.Millisecond), TraceID: "t1", SpanID: "b", ParentSpanID: "a", ServiceName: "db", OperationName: "SELECT", DurationNano: 29e8, StatusCode: "ERROR"},
+				{Timestamp: now, TraceID: "t2", SpanID: "c", ServiceName: "api", OperationName: "GET /health", DurationNano: 1e6},
+			}}, nil
+		},
+	}
+}
+
+func TestInvestigateRunsTools(t *testing.T) {
+	model := &fakeModel{replies: []string{
+		`{"stop_reason":"tool_use","content":[{"type":"text","text":"Checking errors first."},` +
+			toolUse("call_1", "query_logs", `{"service":"api","contains":"refused"}`) + `,` +
+			toolUse("call_2", "get_trace", `{"trace_id":"t1"}`) + `]}`,
+		`{"stop_reason":"end_turn","content":[{"type":"text","text":"## Root Cause\nThe database refuses connections."}]}`,
+	}}
+	srv := model.serve(t)
+
+	var out bytes.Buffer
+	err := investigate(t, Options{Service: "api", Duration: 30, AI: types.AIProfile{APIKey: "k", BaseURL: srv.URL}}, &out)
+	if err != nil {
+		t.Fatalf("Investigate: %v", err)
+	}
+
+	for _, want := range []string{"💭 Checking errors first.", "🔧 [1/8] query_logs(contains=refused, service=api) → 1 logs", "get_trace(trace_id=t1) → 2 spans", "The database refuses connections."} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	if len(model.requests) != 2 {
+		t.Fatalf("expected 2 model calls, got %d", len(model.requests))
+	}
+	if tools, _ := model.requests[0]["tools"].([]interface{}); len(tools) != len(agentTools()) {
+		t.Errorf("expected the tool definitions to be sent, got %v", model.requests[0]["tools"])
+	}
+	msgs := model.requests[1]["messages"].([]interface{})
+	results, _ := json.Marshal(msgs[len(msgs)-1])
+	if !strings.Contains(string(results), `"tool_use_id":"call_2"`) || !strings.Contains(string(results), "connection refused to db:5432") ||
+		!strings.Contains(string(results), `  db SELECT 2900.0ms status=ERROR`) {
+		t.Errorf("tool results not sent back correctly: %s", results)
+	}
+}
+
+func TestInvestigateLinksCitations(t *testing.T) {
+	model := &fakeModel{replies: []string{
+		`{"stop_reason":"tool_use","content":[` + toolUse("c1", "query_traces", `{"service":"api","errors_only":true}`) + `]}`,
+		`{"stop_reason":"end_turn","content":[{"type":"text","text":"GET /orders fails [T1]; see also [T9]."}]}`,
+	}}
+	srv := model.serve(t)
+
+	var out bytes.Buffer
+	opts := Options{Service: "api", SignozURL: "http://signoz:3301", AI: types.AIProfile{BaseURL: srv.URL}}
+	if err := investigate(t, opts, &out); err != nil {
+		t.Fatalf("Investigate: %v", err)
+	}
+	if !strings.Contains(out.String(), "GET /orders fails [T1](http://signoz:3301/trace/t1); see also [T9].") {
+		t.Errorf("expected the citation to link to the trace:\n%s", out.String())
+	}
+	first, _ := json.Marshal(model.requests[0]["messages"])
+	results, _ := json.Marshal(model.requests[1]["messages"])
+	if !strings.Contains(string(first), "reference IDs") || !strings.Contains(string(results), "[T1] ") {
+		t.Errorf("expected tagged tool results and the citation instruction: %s", results)
+	}
+}
+
+func TestInvestigateStopsAtStepLimit(t *testing.T) {
+	loop := `{"stop_reason":"tool_use","content":[` + toolUse("c", "list_services", `{}`) + `]}`
+	model := &fakeModel{replies: []string{loop, loop, `{"stop_reason":"end_turn","content":[{"type":"text","text":"done"}]}`}}
+	srv := model.serve(t)
+
+	var out bytes.Buffer
+	if err := investigate(t, Options{Service: "api", MaxSteps: 2, AI: types.AIProfile{BaseURL: srv.URL}}, &out); err != nil {
+		t.Fatalf("Investigate: %v", err)
+	}
+	if !strings.Contains(out.String(), "Step limit reached (2)") || !strings.HasSuffix(out.String(), "done\n") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+	if choice, _ := model.requests[2]["tool_choice"].(map[string]interface{}); choice["type"] != "none" {
+		t.Errorf("final request should forbid tools, got %v", model.requests[2]["tool_choice"])
+	}
+	if model.requests[1]["tool_choice"] != nil {
+		t.Error("tools should be allowed within the budget")
+	}
+}
+
+func TestInvestigateToolErrors(t *testing.T) {
+	inv := &investigation{client: agentMock(), duration: 60, end: time.Now()}
+	if _, _, err := inv.call(context.Background(), "rm_rf", nil); err == nil {
+		t.Error("expected an error for an unknown tool")
+	}
+	if _, _, err := inv.call(context.Background(), "get_trace", json.RawMessage(`{}`)); err == nil {
+		t.Error("expected an error without a trace ID")
+	}
+	out, _, err := inv.call(context.Background(), "query_traces", json.RawMessage(`{"service":"api","errors_only":true}`))
+	if err != nil || strings.Contains(out, "GET /health") || !strings.Contains(out, "GET /orders") {
+		t.Errorf("errors_only should filter spans: %q (%v)", out, err)
+	}
+}
+
+func TestInvestigatePromptIncludesBlastRadius(t *testing.T) {
+	model := &fakeModel{replies: []string{`{"stop_reason":"end_turn","content":[{"type":"text","text":"ok"}]}`}}
+	srv := model.serve(t)
+
+	if err := investigate(t, Options{Service: "api", AI: types.AIProfile{BaseURL: srv.URL}}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Investigate: %v", err)
+	}
+	prompt, _ := json.Marshal(model.requests[0]["messages"])
+	if !strings.Contains(string(prompt), "Blast Radius") || !strings.Contains(string(prompt), "api → db") {
+		t.Errorf("prompt should include the dependency graph: %s", prompt)
+	}
+}
+
+func TestAssessRetriesInvalidAnswer(t *testing.T) {
+	valid := `{"severity":"high","summary":"db down","root_causes":[{"description":"db refuses connections","confidence":0.8,"services":["db"]}],` +
+		`"affected_services":["api","db"],"evidence":[{"type":"trace","trace_id":"t1","detail":"SELECT failed"}],"recommended_actions":[{"action":"restart db","priority":"high"}]}`
+	answer := func(text string) string {
+		b, _ := json.Marshal(text)
+		return `{"stop_reason":"end_turn","content":[{"type":"text","text":` + string(b) + `}]}`
+	}
+	model := &fakeModel{replies: []string{answer(`{"severity":"bad"}`), answer(valid)}}
+	srv := model.serve(t)
+
+	opts := Options{Service: "api", AI: types.AIProfile{BaseURL: srv.URL}}
+	client := agentMock()
+	data, err := Collect(context.Background(), client, "prod", opts)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	a, err := Assess(context.Background(), client, data, opts, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("Assess: %v", err)
+	}
+	if a.Severity != "high" || a.Evidence[0].TraceID != "t1" {
+		t.Errorf("unexpected assessment %+v", a)
+	}
+	first, _ := json.Marshal(model.requests[0]["messages"])
+	if !strings.Contains(string(first), "Answer Format") {
+		t.Error("the first prompt should ask for JSON")
+	}
+	retry, _ := json.Marshal(model.requests[1]["messages"])
+	if !strings.Contains(string(retry), "not valid") || model.requests[1]["tool_choice"] == nil {
+		t.Errorf("the retry should send the error back without tools: %s", retry)
+	}
+}
+
+// investigate collects from agentMock and runs Investigate on the result.
+func investigate(t *testing.T, opts Options, w io.Writer) error {
+	t.Helper()
+	client := agentMock()
+	data, err := Collect(context.Background(), client, "prod", opts)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	return Investigate(context.Background(), client, data, opts, w)
+}
@@ -0,0 +1,170 @@
+package explain
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lbarahona/argus/internal/topology"
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+const (
+	// dependencySpans is how many spans across all services are sampled to
+	// derive who calls whom.
+	dependencySpans = 1000
+	// blastDepth is how many hops up and down the graph the blast radius covers.
+	blastDepth = 3
+)
+
+// Dependency is a service related to the target through the call graph.
+type Dependency struct {
+	Service   string
+	Distance  int     // hops from the target; 1 for direct callers or callees
+	Via       string  // the neighbour on the path towards the target
+	Calls     int     // sampled calls on the edge from Via (or to Via)
+	EdgeRate  float64 // percent of those calls that failed
+	EdgeP99   float64 // ms
+	ErrorRate float64 // the service's overall error rate, percent
+	Affected  bool    // failing calls on the edge or errors in the service
+}
+
+// BlastRadius lists the callers a failing service can take down with it and
+// the dependencies that may be causing its trouble.
+type BlastRadius struct {
+	Service      string
+	Callers      []Dependency // upstream, nearest first
+	Dependencies []Dependency // downstream, nearest first
+}
+
+// ComputeBlastRadius walks the dependency graph around service. Error rates
+// come from services, as reported by Signoz.
+func ComputeBlastRadius(g *topology.Graph, services []types.Service, service string) *BlastRadius {
+	rates := make(map[string]float64, len(services))
+	for _, s := range services {
+		rates[s.Name] = s.ErrorRate
+	}
+	convert := func(hops []topology.Hop, upstream bool) []Dependency {
+		var deps []Dependency
+		for _, h := range hops {
+			via := h.Edge.From
+			if upstream {
+				via = h.Edge.To
+			}
+			d := Dependency{
+				Service:   h.Service,
+				Distance:  h.Distance,
+				Via:       via,
+				Calls:     h.Edge.Calls,
+				EdgeRate:  h.Edge.ErrorRate(),
+				EdgeP99:   h.Edge.P99(),
+				ErrorRate: rates[h.Service],
+			}
+			d.Affected = d.EdgeRate > 0 || d.ErrorRate > 0
+			deps = append(deps, d)
+		}
+		return deps
+	}
+	return &BlastRadius{
+		Service:      service,
+		Callers:      convert(g.Upstream(service, blastDepth), true),
+		Dependencies: convert(g.Downstream(service, blastDepth), false),
+	}
+}
+
+// Empty reports whether no callers or dependencies were found.
+func (b *BlastRadius) Empty() bool {
+	return b == nil || (len(b.Callers) == 0 && len(b.Dependencies) == 0)
+}
+
+// AffectedCallers returns the callers showing errors.
+func (b *BlastRadius) AffectedCallers() []Dependency {
+	var out []Dependency
+	for _, d := range b.Callers {
+		if d.Affected {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Render prints the blast radius for the terminal.
+func (b *BlastRadius) Render(w io.Writer) {
+	fmt.Fprintf(w, "\n💥 Blast radius of %s\n", b.Service)
+	if b.Empty() {
+		fmt.Fprintf(w, "  No cross-service calls found in the sampled traces.\n")
+		return
+	}
+	section := func(title string, deps []Dependency, upstream bool) {
+		if len(deps) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "  %s\n", title)
+		for i, d := range deps {
+			connector := "├─"
+			if i == len(deps)-1 {
+				connector = "└─"
+			}
+			icon := "🟢"
+			if d.Affected {
+				icon = "🔴"
+			}
+			fmt.Fprintf(w, "  %s %s %s %s\n", connector, icon, d.Service, d.describe(upstream))
+		}
+	}
+	section(fmt.Sprintf("⬆️  Callers (%d affected of %d)", len(b.AffectedCallers()), len(b.Callers)), b.Callers, true)
+	section(fmt.Sprintf("⬇️  Dependencies (%d)", len(b.Dependencies)), b.Dependencies, false)
+}
+
+func (d Dependency) describe(upstream bool) string {
+	edge := fmt.Sprintf("%s → %s", d.Via, d.Service)
+	if upstream {
+		edge = fmt.Sprintf("%s → %s", d.Service, d.Via)
+	}
+	hops := ""
+	if d.Distance > 1 {
+		hops = fmt.Sprintf(", %d hops", d.Distance)
+	}
+	return fmt.Sprintf("(error rate %.2f%%%s) — %s: %d calls, %.1f%% failed, p99 %.0fms",
+		d.ErrorRate, hops, edge, d.Calls, d.EdgeRate, d.EdgeP99)
+}
+
+// mergeSpans appends the spans of more that aren't already in spans.
+func mergeSpans(spans, more []types.TraceEntry) []types.TraceEntry {
+	seen := make(map[[2]string]bool, len(spans))
+	for _, s := range spans {
+		seen[[2]string{s.TraceID, s.SpanID}] = true
+	}
+	for _, s := range more {
+		key := [2]string{s.TraceID, s.SpanID}
+		if !seen[key] {
+			seen[key] = true
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+// writePrompt adds the blast radius to an AI prompt.
+func (b *BlastRadius) writePrompt(sb *strings.Builder) {
+	if b == nil {
+		return
+	}
+	sb.WriteString("\n## Dependencies & Blast Radius\n")
+	if b.Empty() {
+		sb.WriteString("No cross-service calls were found in the sampled traces.\n")
+		return
+	}
+	if len(b.Callers) > 0 {
+		sb.WriteString("Upstream callers (affected when the target fails):\n")
+		for _, d := range b.Callers {
+			sb.WriteString(fmt.Sprintf("- %s %s\n", d.Service, d.describe(true)))
+		}
+	}
+	if len(b.Dependencies) > 0 {
+		sb.WriteString("Downstream dependencies (possible causes):\n")
+		for _, d := range b.Dependencies {
+			sb.WriteString(fmt.Sprintf("- %s %s\n", d.Service, d.describe(false)))
+		}
+	}
+}
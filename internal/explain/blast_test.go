@@ -0,0 +1,110 @@
+package explain
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lbarahona/argus/internal/topology"
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// chainSpans builds web → api → db, with api's calls to db failing.
+func chainSpans() []types.TraceEntry {
+	return []types.TraceEntry{
+		{TraceID: "t1", SpanID: "w", ServiceName: "web", DurationNano: 5e8},
+		{TraceID: "t1", SpanID: "a", ParentSpanID: "w", ServiceName: "api", DurationNano: 4e8, StatusCode: "ERROR"},
+		{TraceID: "t1", SpanID: "d", ParentSpanID: "a", ServiceName: "db", DurationNano: 3e8, StatusCode: "ERROR"},
+		{TraceID: "t2", SpanID: "w", ServiceName: "web", DurationNano: 1e8},
+		{TraceID: "t2", SpanID: "a", ParentSpanID: "w", ServiceName: "api", DurationNano: 5e7},
+		{TraceID: "t2", SpanID: "d", ParentSpanID: "a", ServiceName: "db", DurationNano: 2e7},
+		{TraceID: "t3", SpanID: "m", ServiceName: "mobile", DurationNano: 1e8},
+		{TraceID: "t3", SpanID: "w", ParentSpanID: "m", ServiceName: "web", DurationNano: 9e7},
+	}
+}
+
+func TestComputeBlastRadius(t *testing.T) {
+	services := []types.Service{{Name: "web", ErrorRate: 2.5}, {Name: "api", ErrorRate: 50}, {Name: "db"}, {Name: "mobile"}}
+	b := ComputeBlastRadius(topology.Build(chainSpans()), services, "api")
+
+	if len(b.Callers) != 2 || b.Callers[0].Service != "web" || b.Callers[1].Service != "mobile" {
+		t.Fatalf("expected callers web then mobile, got %+v", b.Callers)
+	}
+	web := b.Callers[0]
+	if web.Distance != 1 || web.Via != "api" || web.Calls != 2 || web.EdgeRate != 50 || web.ErrorRate != 2.5 || !web.Affected {
+		t.Errorf("unexpected web caller: %+v", web)
+	}
+	if mobile := b.Callers[1]; mobile.Distance != 2 || mobile.Via != "web" || mobile.Affected {
+		t.Errorf("unexpected mobile caller: %+v", mobile)
+	}
+	if got := b.AffectedCallers(); len(got) != 1 || got[0].Service != "web" {
+		t.Errorf("expected only web affected, got %+v", got)
+	}
+
+	if len(b.Dependencies) != 1 || b.Dependencies[0].Service != "db" || b.Dependencies[0].EdgeP99 != 300 {
+		t.Errorf("expected db dependency with p99 300ms, got %+v", b.Dependencies)
+	}
+}
+
+func TestBlastRadiusRender(t *testing.T) {
+	b := ComputeBlastRadius(topology.Build(chainSpans()), nil, "api")
+	var out bytes.Buffer
+	b.Render(&out)
+	for _, want := range []string{
+		"💥 Blast radius of api",
+		"Callers (1 affected of 2)",
+		"🔴 web (error rate 0.00%) — web → api: 2 calls, 50.0% failed, p99 400ms",
+		"🟢 mobile (error rate 0.00%, 2 hops) — mobile → web",
+		"Dependencies (1)",
+		"🔴 db (error rate 0.00%) — api → db",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	ComputeBlastRadius(topology.Build(nil), nil, "api").Render(&out)
+	if !strings.Contains(out.String(), "No cross-service calls") {
+		t.Errorf("expected an empty notice, got:\n%s", out.String())
+	}
+}
+
+func TestCollectBuildsDependencyGraph(t *testing.T) {
+	spans := chainSpans()
+	var sampled bool
+	mock := &mockSignozClient{
+		listServicesFunc: func(ctx context.Context) ([]types.Service, error) {
+			return []types.Service{{Name: "web"}, {Name: "api"}, {Name: "db"}}, nil
+		},
+		queryTracesFunc: func(ctx context.Context, service string, d, limit int) (*types.QueryResult, error) {
+			if service == "" {
+				sampled = true
+				return &types.QueryResult{Traces: spans}, nil
+			}
+			// The target's own spans overlap the sample and must not be double counted.
+			return &types.QueryResult{Traces: spans[1:3]}, nil
+		},
+	}
+
+	data, err := Collect(context.Background(), mock, "prod", Options{Service: "api", Duration: 30})
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if !sampled {
+		t.Fatal("expected a cross-service span sample")
+	}
+	if len(data.Traces) != 2 {
+		t.Errorf("Traces should only hold the target's spans, got %d", len(data.Traces))
+	}
+	if edges := data.Graph.Callers("api"); len(edges) != 1 || edges[0].Calls != 2 {
+		t.Errorf("expected web → api with 2 calls, got %+v", edges)
+	}
+	if data.BlastRadius.Empty() {
+		t.Error("expected a blast radius")
+	}
+	if prompt := BuildPrompt(data, 0).Text; !strings.Contains(prompt, "## Dependencies & Blast Radius") || !strings.Contains(prompt, "- web (error rate") {
+		t.Errorf("prompt missing blast radius:\n%s", prompt)
+	}
+}
@@ -3,19 +3,25 @@ package explain
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/lbarahona/argus/internal/ai"
+	"github.com/lbarahona/argus/internal/cite"
+	"github.com/lbarahona/argus/internal/prompt"
 	"github.com/lbarahona/argus/internal/signoz"
+	"github.com/lbarahona/argus/internal/topology"
 	"github.com/lbarahona/argus/pkg/types"
 )
 
 // Options configures the explain command.
 type Options struct {
-	Service      string
-	Duration     int // minutes
-	AnthropicKey string
+	Service   string
+	Duration  int             // minutes
+	AI        types.AIProfile // model used by Run and Investigate
+	MaxSteps  int             // tool-call rounds for Investigate (default DefaultMaxSteps)
+	SignozURL string          // Signoz UI that cited logs and traces link to; empty disables citations
 }
 
 // CorrelatedData holds all collected observability data for a service.
@@ -26,7 +32,19 @@ type CorrelatedData struct {
 	ErrorLogs   []types.LogEntry
 	RecentLogs  []types.LogEntry
 	Traces      []types.TraceEntry
+	Spans       []types.TraceEntry // cross-service sample, including Traces
+	Graph       *topology.Graph    // service dependencies seen in Spans
+	BlastRadius *BlastRadius
+	Duration    int // minutes
 	CollectedAt time.Time
+
+	// The same length of time just before the window, for comparison.
+	PrevErrorLogs []types.LogEntry
+	PrevTraces    []types.TraceEntry
+
+	Findings []Finding // heuristic root-cause ranking, best first
+
+	Refs *cite.Refs // reference IDs of the logs and traces sent to the model
 }
 
 // Collect gathers all relevant data for a service from Signoz.
@@ -34,7 +52,9 @@ func Collect(ctx context.Context, client signoz.SignozQuerier, instanceName stri
 	data := &CorrelatedData{
 		Service:     opts.Service,
 		Instance:    instanceName,
+		Duration:    opts.Duration,
 		CollectedAt: time.Now().UTC(),
+		Refs:        cite.New(opts.SignozURL),
 	}
 
 	// Get all services for context
@@ -44,20 +64,8 @@ func Collect(ctx context.Context, client signoz.SignozQuerier, instanceName stri
 	}
 	data.Services = services
 
-	// Verify target service exists
-	found := false
-	for _, s := range services {
-		if s.Name == opts.Service {
-			found = true
-			break
-		}
-	}
-	if !found {
-		available := make([]string, len(services))
-		for i, s := range services {
-			available[i] = s.Name
-		}
-		return nil, fmt.Errorf("service %q not found. Available: %s", opts.Service, strings.Join(available, ", "))
+	if err := findService(services, opts.Service); err != nil {
+		return nil, err
 	}
 
 	// Get error logs
@@ -75,59 +83,126 @@ func Collect(ctx context.Context, client signoz.SignozQuerier, instanceName stri
 		data.Traces = result.Traces
 	}
 
+	// Sample spans across all services to see who calls whom. The target's
+	// own spans go in too so its edges show up even if the sample misses them.
+	spans := append([]types.TraceEntry(nil), data.Traces...)
+	if result, err := client.QueryTraces(ctx, "", opts.Duration, dependencySpans); err == nil {
+		spans = mergeSpans(spans, result.Traces)
+	}
+	data.Spans = spans
+	data.Graph = topology.Build(spans)
+	data.BlastRadius = ComputeBlastRadius(data.Graph, services, opts.Service)
+
+	// The previous window is the baseline for new errors and latency shifts.
+	window := time.Duration(opts.Duration) * time.Minute
+	prevEnd := data.CollectedAt.Add(-window)
+	if result, err := client.QueryLogsRange(ctx, opts.Service, prevEnd.Add(-window), prevEnd, 200, "error"); err == nil {
+		data.PrevErrorLogs = result.Logs
+	}
+	if result, err := client.QueryTracesRange(ctx, opts.Service, prevEnd.Add(-window), prevEnd, dependencySpans); err == nil {
+		data.PrevTraces = result.Traces
+	}
+
+	data.Findings = Rank(data)
+
 	return data, nil
 }
 
-// BuildPrompt creates the AI analysis prompt from correlated data.
-func BuildPrompt(data *CorrelatedData) string {
-	var b strings.Builder
+// findService verifies the target service exists.
+func findService(services []types.Service, name string) error {
+	for _, s := range services {
+		if s.Name == name {
+			return nil
+		}
+	}
+	available := make([]string, len(services))
+	for i, s := range services {
+		available[i] = s.Name
+	}
+	return fmt.Errorf("service %q not found. Available: %s", name, strings.Join(available, ", "))
+}
 
-	b.WriteString(fmt.Sprintf("You are an expert SRE analyzing the service '%s' on Signoz instance '%s'.\n", data.Service, data.Instance))
-	b.WriteString("Correlate the following observability data and provide a root cause analysis.\n\n")
+func writeServiceOverview(b *strings.Builder, services []types.Service, target string) {
+	for _, l := range overviewLines(services, target) {
+		b.WriteString(l + "\n")
+	}
+}
 
-	// Service context
-	b.WriteString("## Service Overview\n")
-	for _, s := range data.Services {
+// overviewLines describes each service, the target first and then the rest
+// by errors, so a trimmed list still shows what matters.
+func overviewLines(services []types.Service, target string) []string {
+	sorted := append([]types.Service(nil), services...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if (sorted[i].Name == target) != (sorted[j].Name == target) {
+			return sorted[i].Name == target
+		}
+		return sorted[i].NumErrors > sorted[j].NumErrors
+	})
+	lines := make([]string, len(sorted))
+	for i, s := range sorted {
 		marker := ""
-		if s.Name == data.Service {
+		if s.Name == target {
 			marker = " ← TARGET"
 		}
-		rate := s.ErrorRate * 100
+		rate := s.ErrorRate
 		if s.NumCalls > 0 && s.ErrorRate == 0 {
 			rate = float64(s.NumErrors) / float64(s.NumCalls) * 100
 		}
-		b.WriteString(fmt.Sprintf("- %s: %d calls, %d errors (%.2f%%)%s\n", s.Name, s.NumCalls, s.NumErrors, rate, marker))
+		lines[i] = fmt.Sprintf("- %s: %d calls, %d errors (%.2f%%)%s", s.Name, s.NumCalls, s.NumErrors, rate, marker)
 	}
+	return lines
+}
 
-	// Error logs
-	if len(data.ErrorLogs) > 0 {
-		b.WriteString(fmt.Sprintf("\n## Error Logs (%d found)\n", len(data.ErrorLogs)))
-		for _, log := range data.ErrorLogs {
-			body := log.Body
-			if len(body) > 300 {
-				body = body[:300] + "..."
-			}
-			b.WriteString(fmt.Sprintf("[%s] %s\n", log.Timestamp.Format("15:04:05"), body))
-		}
-	} else {
-		b.WriteString("\n## Error Logs\nNo error logs found in the time window.\n")
+// analysisRequest is the answer structure both prompt styles ask for.
+const analysisRequest = `
+## Your Analysis
+
+Provide:
+1. **Health Assessment** — Is the service healthy, degraded, or critical?
+2. **Root Cause** — What's causing any issues? Correlate across logs, traces, and metrics.
+3. **Impact** — What's the blast radius? Are downstream services affected?
+4. **Recommended Actions** — Specific steps to resolve or mitigate, ordered by priority.
+5. **Prevention** — What would prevent this in the future?
+
+Be specific and actionable. Reference actual log messages and trace data.`
+
+// BuildPrompt creates the AI analysis prompt from correlated data, fitted to
+// budget estimated tokens (0 for prompt.DefaultBudget). Errors, slow traces
+// and findings get the budget first; recent logs of all levels go first when
+// it runs out.
+func BuildPrompt(data *CorrelatedData, budget int) prompt.Result {
+	b := prompt.New(budget)
+
+	b.Textf("You are an expert SRE analyzing the service '%s' on Signoz instance '%s'.\n", data.Service, data.Instance)
+	b.Text("Correlate the following observability data and provide a root cause analysis.\n")
+
+	// Service context, the target first
+	overview := b.Section("## Service Overview", prompt.Normal)
+	for _, l := range overviewLines(data.Services, data.Service) {
+		overview.Add(l)
+	}
+
+	// Error logs, repeats collapsed
+	errorLogs := b.Section(fmt.Sprintf("## Error Logs (%d found)", len(data.ErrorLogs)), prompt.High)
+	errorLogs.Empty = "No error logs found in the time window."
+	if len(data.ErrorLogs) == 0 {
+		errorLogs.Title = "## Error Logs"
+	}
+	for _, log := range data.ErrorLogs {
+		errorLogs.AddKeyed(log.Body, fmt.Sprintf("%s[%s] %s", data.Refs.Log(log), log.Timestamp.Format("15:04:05"), log.Body))
 	}
 
 	// Recent logs for context
 	if len(data.RecentLogs) > 0 {
-		b.WriteString(fmt.Sprintf("\n## Recent Logs (all levels, %d entries)\n", len(data.RecentLogs)))
+		recent := b.Section(fmt.Sprintf("## Recent Logs (all levels, %d entries)", len(data.RecentLogs)), prompt.Low)
 		for _, log := range data.RecentLogs {
-			body := log.Body
-			if len(body) > 200 {
-				body = body[:200] + "..."
-			}
-			b.WriteString(fmt.Sprintf("[%s] [%s] %s\n", log.Timestamp.Format("15:04:05"), log.SeverityText, body))
+			recent.AddKeyed(log.SeverityText+" "+log.Body, fmt.Sprintf("%s[%s] [%s] %s", data.Refs.Log(log), log.Timestamp.Format("15:04:05"), log.SeverityText, log.Body))
 		}
 	}
 
 	// Traces
 	if len(data.Traces) > 0 {
-		b.WriteString(fmt.Sprintf("\n## Traces (%d spans)\n", len(data.Traces)))
+		b.Textf("\n## Traces (%d spans)\n", len(data.Traces))
 
 		// Find slow and error traces
 		var slowTraces, errorTraces []types.TraceEntry
@@ -135,45 +210,39 @@ func BuildPrompt(data *CorrelatedData) string {
 			if t.DurationMs() > 1000 {
 				slowTraces = append(slowTraces, t)
 			}
-			if t.StatusCode != "" && t.StatusCode != "OK" && t.StatusCode != "0" {
+			if t.IsError() {
 				errorTraces = append(errorTraces, t)
 			}
 		}
 
 		if len(errorTraces) > 0 {
-			b.WriteString(fmt.Sprintf("\n### Error Traces (%d)\n", len(errorTraces)))
+			section := b.Section(fmt.Sprintf("### Error Traces (%d)", len(errorTraces)), prompt.High)
 			for _, t := range errorTraces {
-				b.WriteString(fmt.Sprintf("- %s %s → %s (%.1fms, status: %s)\n",
-					t.Timestamp.Format("15:04:05"), t.ServiceName, t.OperationName, t.DurationMs(), t.StatusCode))
+				section.AddKeyed(t.ServiceName+"\x00"+t.OperationName+"\x00"+t.StatusCode, fmt.Sprintf("- %s%s %s → %s (%.1fms, status: %s)",
+					data.Refs.Trace(t), t.Timestamp.Format("15:04:05"), t.ServiceName, t.OperationName, t.DurationMs(), t.StatusCode))
 			}
 		}
 
 		if len(slowTraces) > 0 {
-			b.WriteString(fmt.Sprintf("\n### Slow Traces >1s (%d)\n", len(slowTraces)))
-			limit := 10
-			if len(slowTraces) < limit {
-				limit = len(slowTraces)
-			}
-			for _, t := range slowTraces[:limit] {
-				b.WriteString(fmt.Sprintf("- %s %s → %s (%.1fms)\n",
-					t.Timestamp.Format("15:04:05"), t.ServiceName, t.OperationName, t.DurationMs()))
+			sort.SliceStable(slowTraces, func(i, j int) bool { return slowTraces[i].DurationNano > slowTraces[j].DurationNano })
+			section := b.Section(fmt.Sprintf("### Slow Traces >1s (%d)", len(slowTraces)), prompt.High)
+			for _, t := range slowTraces {
+				section.Addf("- %s%s %s → %s (%.1fms)",
+					data.Refs.Trace(t), t.Timestamp.Format("15:04:05"), t.ServiceName, t.OperationName, t.DurationMs())
 			}
 		}
 	}
 
-	b.WriteString(`
-## Your Analysis
+	var blast, findings strings.Builder
+	data.BlastRadius.writePrompt(&blast)
+	b.Block("Dependencies & Blast Radius", prompt.Normal, blast.String())
+	writeFindingsPrompt(&findings, data.Findings)
+	b.Block("Heuristic Findings", prompt.High, findings.String())
 
-Provide:
-1. **Health Assessment** — Is the service healthy, degraded, or critical?
-2. **Root Cause** — What's causing any issues? Correlate across logs, traces, and metrics.
-3. **Impact** — What's the blast radius? Are downstream services affected?
-4. **Recommended Actions** — Specific steps to resolve or mitigate, ordered by priority.
-5. **Prevention** — What would prevent this in the future?
-
-Be specific and actionable. Reference actual log messages and trace data.`)
+	b.Text(data.Refs.Instruction())
+	b.Text(analysisRequest)
 
-	return b.String()
+	return b.Build()
 }
 
 // Run collects data and streams AI analysis.
@@ -183,7 +252,10 @@ func Run(ctx context.Context, client signoz.SignozQuerier, instanceName string,
 		return err
 	}
 
-	prompt := BuildPrompt(data)
-	analyzer := ai.New(opts.AnthropicKey)
-	return analyzer.Analyze(prompt, writer)
+	analyzer, err := ai.FromProfile(opts.AI)
+	if err != nil {
+		return err
+	}
+	p := BuildPrompt(data, opts.AI.PromptBudget)
+	return analyzer.Analyze(ctx, p.Text, writer)
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/lbarahona/argus/internal/cite"
 	"github.com/lbarahona/argus/pkg/types"
 )
 
@@ -49,6 +50,18 @@ func (m *mockSignozClient) QueryMetrics(ctx context.Context, metricName string,
 	return &types.QueryResult{}, nil
 }
 
+func (m *mockSignozClient) ListServicesRange(ctx context.Context, start, end time.Time) ([]types.Service, error) {
+	return m.ListServices(ctx)
+}
+
+func (m *mockSignozClient) QueryLogsRange(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+	return m.QueryLogs(ctx, service, int(end.Sub(start).Minutes()), limit, severityFilter)
+}
+
+func (m *mockSignozClient) QueryTracesRange(ctx context.Context, service string, start, end time.Time, limit int) (*types.QueryResult, error) {
+	return m.QueryTraces(ctx, service, int(end.Sub(start).Minutes()), limit)
+}
+
 // ──────────────────────────────────────────────
 // Collect Tests
 // ──────────────────────────────────────────────
@@ -171,7 +184,7 @@ func TestBuildPromptWithAllData(t *testing.T) {
 		},
 	}
 
-	prompt := BuildPrompt(data)
+	prompt := BuildPrompt(data, 0).Text
 
 	if !strings.Contains(prompt, "api") {
 		t.Error("prompt should mention the service")
@@ -205,7 +218,7 @@ func TestBuildPromptNoErrors(t *testing.T) {
 		},
 	}
 
-	prompt := BuildPrompt(data)
+	prompt := BuildPrompt(data, 0).Text
 	if !strings.Contains(prompt, "No error logs found") {
 		t.Error("prompt should mention no error logs")
 	}
@@ -223,7 +236,7 @@ func TestBuildPromptSlowTraces(t *testing.T) {
 		},
 	}
 
-	prompt := BuildPrompt(data)
+	prompt := BuildPrompt(data, 0).Text
 	if !strings.Contains(prompt, "Slow Traces") {
 		t.Error("prompt should have Slow Traces section for >1s traces")
 	}
@@ -241,8 +254,73 @@ func TestBuildPromptErrorTraces(t *testing.T) {
 		},
 	}
 
-	prompt := BuildPrompt(data)
+	prompt := BuildPrompt(data, 0).Text
 	if !strings.Contains(prompt, "Error Traces") {
 		t.Error("prompt should have Error Traces section")
 	}
 }
+
+func TestBuildPromptCitations(t *testing.T) {
+	now := time.Now()
+	data := &CorrelatedData{
+		Service:  "api",
+		Instance: "prod",
+		ErrorLogs: []types.LogEntry{
+			{Body: "db timeout", ServiceName: "api", Timestamp: now},
+			{Body: "db timeout", ServiceName: "api", Timestamp: now},
+			{Body: "pool exhausted", ServiceName: "api", Timestamp: now},
+		},
+		Traces: []types.TraceEntry{
+			{TraceID: "abc", ServiceName: "api", OperationName: "POST /order", DurationNano: 2e9, StatusCode: "ERROR", Timestamp: now},
+		},
+		Refs: cite.New("http://signoz:3301"),
+	}
+
+	p := BuildPrompt(data, 0).Text
+	for _, want := range []string{"[L1] [", "db timeout (×2)", "[L2] [", "- [T1] ", "cite it by writing its ID"} {
+		if !strings.Contains(p, want) {
+			t.Errorf("prompt missing %q:\n%s", want, p)
+		}
+	}
+
+	data.Refs = nil
+	if p := BuildPrompt(data, 0).Text; strings.Contains(p, "[L1]") || strings.Contains(p, "reference IDs") {
+		t.Errorf("no citations without a Signoz URL:\n%s", p)
+	}
+}
+
+func TestBuildPromptBudget(t *testing.T) {
+	now := time.Now()
+	data := &CorrelatedData{
+		Service:  "api",
+		Instance: "prod",
+		Services: []types.Service{
+			{Name: "web", NumCalls: 100},
+			{Name: "api", NumCalls: 100, NumErrors: 40},
+		},
+	}
+	for i := 0; i < 30; i++ {
+		data.ErrorLogs = append(data.ErrorLogs, types.LogEntry{Body: "db timeout", SeverityText: "ERROR", Timestamp: now})
+	}
+	data.ErrorLogs = append(data.ErrorLogs, types.LogEntry{Body: "pool exhausted", SeverityText: "ERROR", Timestamp: now})
+	for i := 0; i < 200; i++ {
+		data.RecentLogs = append(data.RecentLogs, types.LogEntry{Body: fmt.Sprintf("request %d handled", i), SeverityText: "INFO", Timestamp: now})
+	}
+
+	p := BuildPrompt(data, 600)
+	if p.Tokens > 600 {
+		t.Errorf("prompt of %d tokens exceeds the budget", p.Tokens)
+	}
+	if !strings.Contains(p.Text, "db timeout (×30)") || !strings.Contains(p.Text, "pool exhausted") {
+		t.Errorf("error logs should be kept and collapsed:\n%s", p.Text)
+	}
+	if !strings.Contains(p.Text, "- api: 100 calls, 40 errors (40.00%) ← TARGET\n- web") {
+		t.Errorf("the target should lead the overview:\n%s", p.Text)
+	}
+	if !strings.Contains(p.Text, "Root Cause") {
+		t.Error("the instructions must never be dropped")
+	}
+	if len(p.Dropped) != 1 || p.Dropped[0].Section != "Recent Logs" {
+		t.Errorf("expected only recent logs to be trimmed, got %+v", p.Dropped)
+	}
+}
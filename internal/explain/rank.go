@@ -0,0 +1,440 @@
+package explain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lbarahona/argus/internal/logpattern"
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// Finding kinds, one per heuristic.
+const (
+	KindDependency  = "dependency"  // a downstream service started failing first
+	KindNewError    = "new-error"   // an error pattern absent from the previous window
+	KindLatency     = "latency"     // an operation got markedly slower
+	KindCorrelation = "correlation" // another service's errors move with the target's
+)
+
+const (
+	// maxFindings caps the ranking shown and sent to the model.
+	maxFindings = 8
+	// correlationBucket is the resolution of error time series.
+	correlationBucket = time.Minute
+	// minCorrelation is the Pearson coefficient worth reporting.
+	minCorrelation = 0.6
+	// minLatencyRatio is the p99 growth that counts as a latency shift, with
+	// at least minLatencyDelta ms of absolute change and minLatencySamples
+	// spans on each side.
+	minLatencyRatio   = 1.5
+	minLatencyDelta   = 50.0
+	minLatencySamples = 3
+)
+
+// Finding is one suspected cause, scored from 0 to 1.
+type Finding struct {
+	Kind     string   `json:"kind"`
+	Suspect  string   `json:"suspect"` // service, operation or error pattern
+	Score    float64  `json:"score"`
+	Summary  string   `json:"summary"`
+	Evidence []string `json:"evidence"`
+}
+
+// Rank runs every heuristic over data and returns the findings, highest score
+// first. It uses only collected data, so it works without an AI provider, and
+// it is deterministic: the same data always gives the same ranking.
+func Rank(data *CorrelatedData) []Finding {
+	var findings []Finding
+	findings = append(findings, rankDependencies(data)...)
+	findings = append(findings, rankNewErrors(data)...)
+	findings = append(findings, rankLatency(data)...)
+	findings = append(findings, rankCorrelations(data)...)
+
+	for i := range findings {
+		findings[i].Score = math.Round(findings[i].Score*100) / 100
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Suspect < b.Suspect
+	})
+	if len(findings) > maxFindings {
+		findings = findings[:maxFindings]
+	}
+	return findings
+}
+
+// firstErrors returns when each service first failed: its earliest error
+// span, and for the target also its earliest error log.
+func firstErrors(data *CorrelatedData) map[string]time.Time {
+	first := make(map[string]time.Time)
+	note := func(service string, ts time.Time) {
+		if ts.IsZero() {
+			return
+		}
+		if t, ok := first[service]; !ok || ts.Before(t) {
+			first[service] = ts
+		}
+	}
+	for _, s := range data.Spans {
+		if s.IsError() {
+			note(s.ServiceName, s.Timestamp)
+		}
+	}
+	for _, s := range data.Traces {
+		if s.IsError() {
+			note(s.ServiceName, s.Timestamp)
+		}
+	}
+	for _, l := range data.ErrorLogs {
+		note(data.Service, l.Timestamp)
+	}
+	return first
+}
+
+// rankDependencies scores each failing downstream dependency. One whose
+// errors began before the target's is the strongest suspect; nearer
+// dependencies and higher failure rates on the edge score higher.
+func rankDependencies(data *CorrelatedData) []Finding {
+	if data.BlastRadius == nil {
+		return nil
+	}
+	first := firstErrors(data)
+	targetFirst, targetFailing := first[data.Service]
+
+	var findings []Finding
+	for _, d := range data.BlastRadius.Dependencies {
+		depFirst, failing := first[d.Service]
+		if !failing && d.EdgeRate == 0 {
+			continue
+		}
+		score := 0.35
+		summary := fmt.Sprintf("downstream dependency %s is failing", d.Service)
+		var timing string
+		switch {
+		case failing && targetFailing && !depFirst.After(targetFirst):
+			score = 0.6
+			summary = fmt.Sprintf("downstream dependency %s started failing first", d.Service)
+			timing = fmt.Sprintf("%s errors began %s, %s before %s's first error", d.Service, depFirst.Format("15:04:05"), roundDuration(targetFirst.Sub(depFirst)), data.Service)
+		case failing && targetFailing:
+			timing = fmt.Sprintf("%s errors began %s, %s after %s's first error", d.Service, depFirst.Format("15:04:05"), roundDuration(depFirst.Sub(targetFirst)), data.Service)
+		case failing:
+			score = 0.5
+			timing = fmt.Sprintf("%s errors began %s", d.Service, depFirst.Format("15:04:05"))
+		}
+		score += 0.3 * d.EdgeRate / 100
+		score /= float64(d.Distance)
+
+		f := Finding{Kind: KindDependency, Suspect: d.Service, Score: score, Summary: summary}
+		if timing != "" {
+			f.Evidence = append(f.Evidence, timing)
+		}
+		f.Evidence = append(f.Evidence, fmt.Sprintf("%s → %s: %d calls, %.1f%% failed, p99 %.0fms", d.Via, d.Service, d.Calls, d.EdgeRate, d.EdgeP99))
+		if d.Distance > 1 {
+			f.Evidence = append(f.Evidence, fmt.Sprintf("%d hops from %s", d.Distance, data.Service))
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// rankNewErrors reports error patterns of the target that did not occur in
+// the previous window, scored by their share of current errors. Both windows
+// share one miner so a template is the same cluster on either side.
+func rankNewErrors(data *CorrelatedData) []Finding {
+	if len(data.ErrorLogs) == 0 {
+		return nil
+	}
+	miner := logpattern.New(logpattern.Options{})
+	before := make(map[*logpattern.Cluster]int)
+	for _, l := range data.PrevErrorLogs {
+		if cl := miner.Add(l.Body); cl != nil {
+			before[cl]++
+		}
+	}
+	type current struct {
+		count int
+		first time.Time
+	}
+	now := make(map[*logpattern.Cluster]*current)
+	var order []*logpattern.Cluster
+	for _, l := range data.ErrorLogs {
+		cl := miner.Add(l.Body)
+		if cl == nil {
+			continue
+		}
+		c, ok := now[cl]
+		if !ok {
+			c = &current{first: l.Timestamp}
+			now[cl] = c
+			order = append(order, cl)
+		}
+		c.count++
+		if l.Timestamp.Before(c.first) {
+			c.first = l.Timestamp
+		}
+	}
+
+	var findings []Finding
+	for _, cl := range order {
+		if before[cl] > 0 {
+			continue
+		}
+		c := now[cl]
+		share := float64(c.count) / float64(len(data.ErrorLogs))
+		template := truncate(cl.Template(), 120)
+		f := Finding{
+			Kind:    KindNewError,
+			Suspect: template,
+			Score:   0.3 + 0.4*share,
+			Summary: fmt.Sprintf("new error pattern in %s", data.Service),
+			Evidence: []string{
+				fmt.Sprintf("%d of %d error logs (%.0f%%), first at %s", c.count, len(data.ErrorLogs), share*100, c.first.Format("15:04:05")),
+				fmt.Sprintf("not seen in the previous %dm (%d error logs)", data.Duration, len(data.PrevErrorLogs)),
+				"sample: " + truncate(cl.Sample, 200),
+			},
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// rankLatency compares each of the target's operations with the previous
+// window and reports those whose p99 grew sharply.
+func rankLatency(data *CorrelatedData) []Finding {
+	byOp := func(spans []types.TraceEntry) map[string][]float64 {
+		ops := make(map[string][]float64)
+		seen := make(map[[2]string]bool)
+		for _, s := range spans {
+			key := [2]string{s.TraceID, s.SpanID}
+			if s.ServiceName != data.Service || seen[key] {
+				continue
+			}
+			seen[key] = true
+			ops[s.OperationName] = append(ops[s.OperationName], s.DurationMs())
+		}
+		return ops
+	}
+	now := byOp(append(append([]types.TraceEntry(nil), data.Traces...), data.Spans...))
+	prev := byOp(data.PrevTraces)
+
+	var findings []Finding
+	for op, cur := range now {
+		old := prev[op]
+		if len(cur) < minLatencySamples || len(old) < minLatencySamples {
+			continue
+		}
+		curP99, oldP99 := p99(cur), p99(old)
+		if oldP99 <= 0 || curP99/oldP99 < minLatencyRatio || curP99-oldP99 < minLatencyDelta {
+			continue
+		}
+		ratio := curP99 / oldP99
+		findings = append(findings, Finding{
+			Kind:    KindLatency,
+			Suspect: op,
+			Score:   math.Min(0.9, 0.3+0.15*math.Log2(ratio)),
+			Summary: fmt.Sprintf("%s got %.1f× slower", op, ratio),
+			Evidence: []string{
+				fmt.Sprintf("p99 %.0fms over %d spans, was %.0fms over %d spans in the previous %dm", curP99, len(cur), oldP99, len(old), data.Duration),
+				fmt.Sprintf("median %.0fms, was %.0fms", median(cur), median(old)),
+			},
+		})
+	}
+	return findings
+}
+
+// rankCorrelations buckets error events per service by minute and reports
+// services whose error series correlates with the target's.
+func rankCorrelations(data *CorrelatedData) []Finding {
+	if data.Duration <= 0 {
+		return nil
+	}
+	start := data.CollectedAt.Add(-time.Duration(data.Duration) * time.Minute)
+	buckets := int(time.Duration(data.Duration)*time.Minute/correlationBucket) + 1
+	bucketize := func(times []time.Time) []float64 {
+		series := make([]float64, buckets)
+		for _, ts := range times {
+			if i := int(ts.Sub(start) / correlationBucket); ts.After(start) && i < buckets {
+				series[i]++
+			}
+		}
+		return series
+	}
+
+	others := make(map[string][]time.Time)
+	var targetSpans, targetLogs []time.Time
+	seen := make(map[[2]string]bool)
+	for _, s := range append(append([]types.TraceEntry(nil), data.Traces...), data.Spans...) {
+		key := [2]string{s.TraceID, s.SpanID}
+		if !s.IsError() || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if s.ServiceName == data.Service {
+			targetSpans = append(targetSpans, s.Timestamp)
+		} else {
+			others[s.ServiceName] = append(others[s.ServiceName], s.Timestamp)
+		}
+	}
+	for _, l := range data.ErrorLogs {
+		targetLogs = append(targetLogs, l.Timestamp)
+	}
+	// The target's error logs and error spans are usually the same failures,
+	// so it uses whichever signal has more events rather than both.
+	target := bucketize(targetLogs)
+	if spans := bucketize(targetSpans); sum(spans) > sum(target) {
+		target = spans
+	}
+	if sum(target) < 2 {
+		return nil
+	}
+
+	relation := make(map[string]string)
+	if data.BlastRadius != nil {
+		for _, d := range data.BlastRadius.Callers {
+			relation[d.Service] = "caller"
+		}
+		for _, d := range data.BlastRadius.Dependencies {
+			relation[d.Service] = "dependency"
+		}
+	}
+
+	names := make([]string, 0, len(others))
+	for name := range others {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var findings []Finding
+	for _, name := range names {
+		s := bucketize(others[name])
+		if sum(s) < 2 {
+			continue
+		}
+		r := pearson(target, s)
+		if r < minCorrelation {
+			continue
+		}
+		rel := relation[name]
+		score := 0.45 * r
+		if rel == "dependency" {
+			score += 0.1
+		}
+		summary := fmt.Sprintf("%s errors rise and fall with %s's", name, data.Service)
+		if rel != "" {
+			summary = fmt.Sprintf("%s (%s) errors rise and fall with %s's", name, rel, data.Service)
+		}
+		findings = append(findings, Finding{
+			Kind:    KindCorrelation,
+			Suspect: name,
+			Score:   score,
+			Summary: summary,
+			Evidence: []string{
+				fmt.Sprintf("Pearson r=%.2f over %d one-minute buckets", r, buckets),
+				fmt.Sprintf("%.0f error events in %s, %.0f in %s", sum(s), name, sum(target), data.Service),
+			},
+		})
+	}
+	return findings
+}
+
+// RenderFindings prints the ranking for the terminal.
+func RenderFindings(w io.Writer, findings []Finding) {
+	fmt.Fprintf(w, "\n🧭 Suspected causes (heuristic ranking)\n")
+	if len(findings) == 0 {
+		fmt.Fprintf(w, "  Nothing stands out: no failing dependencies, new error patterns, latency shifts or correlated errors.\n")
+		return
+	}
+	for i, f := range findings {
+		fmt.Fprintf(w, "  %d. [%.2f] %s — %s\n", i+1, f.Score, kindLabel(f.Kind), f.Summary)
+		for _, e := range f.Evidence {
+			fmt.Fprintf(w, "       • %s\n", e)
+		}
+	}
+}
+
+func kindLabel(kind string) string {
+	switch kind {
+	case KindDependency:
+		return "🔗 dependency"
+	case KindNewError:
+		return "🆕 new error"
+	case KindLatency:
+		return "🐢 latency"
+	case KindCorrelation:
+		return "📈 correlation"
+	default:
+		return kind
+	}
+}
+
+// writeFindingsPrompt hands the ranking to the model as structured input.
+func writeFindingsPrompt(b *strings.Builder, findings []Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	out, err := json.MarshalIndent(findings, "", "  ")
+	if err != nil {
+		return
+	}
+	b.WriteString("\n## Heuristic Findings\n")
+	b.WriteString("A deterministic analysis ranked these suspected causes (score 0-1, highest first). Confirm or refute each against the data rather than repeating it:\n")
+	b.WriteString("```json\n")
+	b.Write(out)
+	b.WriteString("\n```\n")
+}
+
+func p99(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted[int(math.Ceil(0.99*float64(len(sorted))))-1]
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/2]
+}
+
+func sum(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// pearson returns the correlation coefficient of two equal-length series, or
+// 0 when either is constant.
+func pearson(a, b []float64) float64 {
+	n := float64(len(a))
+	ma, mb := sum(a)/n, sum(b)/n
+	var cov, va, vb float64
+	for i := range a {
+		da, db := a[i]-ma, b[i]-mb
+		cov += da * db
+		va += da * da
+		vb += db * db
+	}
+	if va == 0 || vb == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(va*vb)
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(time.Second)
+}
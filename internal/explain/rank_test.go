@@ -0,0 +1,150 @@
+package explain
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbarahona/argus/internal/topology"
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// incident builds an outage of api caused by db: db starts failing two
+// minutes before api, api logs a new error, and GET /orders slows down.
+func incident() *CorrelatedData {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	data := &CorrelatedData{Service: "api", Instance: "prod", Duration: 30, CollectedAt: now}
+	at := func(min int) time.Time { return now.Add(time.Duration(-min) * time.Minute) }
+
+	for i := 0; i < 6; i++ {
+		trace := fmt.Sprintf("t%d", i)
+		status := ""
+		if i < 4 {
+			status = "ERROR"
+		}
+		ts := at(10 - i)
+		data.Spans = append(data.Spans,
+			types.TraceEntry{Timestamp: ts.Add(-time.Second), TraceID: trace, SpanID: "w", ServiceName: "web", OperationName: "GET /", DurationNano: 9e8, StatusCode: status},
+			types.TraceEntry{Timestamp: ts, TraceID: trace, SpanID: "a", ParentSpanID: "w", ServiceName: "api", OperationName: "GET /orders", DurationNano: 8e8, StatusCode: status},
+			types.TraceEntry{Timestamp: ts.Add(-2 * time.Minute), TraceID: trace, SpanID: "d", ParentSpanID: "a", ServiceName: "db", OperationName: "SELECT", DurationNano: 7e8, StatusCode: status},
+		)
+	}
+	for i := 0; i < 4; i++ {
+		data.ErrorLogs = append(data.ErrorLogs, types.LogEntry{Timestamp: at(10 - i), Body: fmt.Sprintf("query timeout after %dms on orders", 500+i)})
+	}
+	data.ErrorLogs = append(data.ErrorLogs, types.LogEntry{Timestamp: at(9), Body: "cache miss for user 42"})
+	data.PrevErrorLogs = []types.LogEntry{{Timestamp: at(40), Body: "cache miss for user 7"}}
+	for i := 0; i < 5; i++ {
+		data.PrevTraces = append(data.PrevTraces, types.TraceEntry{TraceID: fmt.Sprintf("p%d", i), SpanID: "a", ServiceName: "api", OperationName: "GET /orders", DurationNano: 1e8})
+	}
+
+	data.Graph = topology.Build(data.Spans)
+	data.BlastRadius = ComputeBlastRadius(data.Graph, []types.Service{{Name: "api", ErrorRate: 66}, {Name: "db", ErrorRate: 66}}, "api")
+	return data
+}
+
+func TestRankDependencyFirst(t *testing.T) {
+	findings := Rank(incident())
+	if len(findings) == 0 {
+		t.Fatal("expected findings")
+	}
+	top := findings[0]
+	if top.Kind != KindDependency || top.Suspect != "db" {
+		t.Fatalf("expected db to rank first, got %+v", findings)
+	}
+	if top.Summary != "downstream dependency db started failing first" || !strings.Contains(top.Evidence[0], "2m0s before api's first error") {
+		t.Errorf("unexpected finding: %+v", top)
+	}
+	for i := 1; i < len(findings); i++ {
+		if findings[i].Score > findings[i-1].Score {
+			t.Errorf("findings not sorted by score: %+v", findings)
+		}
+	}
+}
+
+func TestRankNewErrors(t *testing.T) {
+	var novel []Finding
+	for _, f := range Rank(incident()) {
+		if f.Kind == KindNewError {
+			novel = append(novel, f)
+		}
+	}
+	if len(novel) != 1 || !strings.HasPrefix(novel[0].Suspect, "query timeout after") {
+		t.Fatalf("expected only the timeout pattern to be new, got %+v", novel)
+	}
+	if !strings.Contains(novel[0].Evidence[0], "4 of 5 error logs (80%)") {
+		t.Errorf("unexpected evidence: %v", novel[0].Evidence)
+	}
+}
+
+func TestRankLatency(t *testing.T) {
+	var slow *Finding
+	for _, f := range Rank(incident()) {
+		if f.Kind == KindLatency {
+			f := f
+			slow = &f
+		}
+	}
+	if slow == nil || slow.Suspect != "GET /orders" || slow.Summary != "GET /orders got 8.0× slower" {
+		t.Fatalf("expected GET /orders latency shift, got %+v", slow)
+	}
+
+	data := incident()
+	data.PrevTraces = data.PrevTraces[:2]
+	for _, f := range Rank(data) {
+		if f.Kind == KindLatency {
+			t.Errorf("too few baseline spans should not report latency: %+v", f)
+		}
+	}
+}
+
+func TestRankCorrelations(t *testing.T) {
+	found := map[string]Finding{}
+	for _, f := range Rank(incident()) {
+		if f.Kind == KindCorrelation {
+			found[f.Suspect] = f
+		}
+	}
+	if f, ok := found["web"]; !ok || !strings.Contains(f.Summary, "web (caller)") {
+		t.Errorf("expected web's errors to correlate, got %+v", found)
+	}
+	if _, ok := found["db"]; ok {
+		t.Error("db's errors lead api's by two minutes and should not correlate bucket for bucket")
+	}
+}
+
+func TestRankDeterministic(t *testing.T) {
+	a, b := Rank(incident()), Rank(incident())
+	if fmt.Sprint(a) != fmt.Sprint(b) {
+		t.Errorf("ranking changed between runs:\n%v\n%v", a, b)
+	}
+}
+
+func TestRankNothing(t *testing.T) {
+	if findings := Rank(&CorrelatedData{Service: "api", Duration: 30, CollectedAt: time.Now()}); len(findings) != 0 {
+		t.Errorf("expected no findings without data, got %+v", findings)
+	}
+	var out bytes.Buffer
+	RenderFindings(&out, nil)
+	if !strings.Contains(out.String(), "Nothing stands out") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestFindingsInPrompt(t *testing.T) {
+	data := incident()
+	data.Findings = Rank(data)
+
+	var out bytes.Buffer
+	RenderFindings(&out, data.Findings)
+	if !strings.Contains(out.String(), "1. [") || !strings.Contains(out.String(), "🔗 dependency — downstream dependency db started failing first") {
+		t.Errorf("unexpected rendering:\n%s", out.String())
+	}
+
+	prompt := BuildPrompt(data, 0).Text
+	if !strings.Contains(prompt, "## Heuristic Findings") || !strings.Contains(prompt, `"kind": "dependency"`) || !strings.Contains(prompt, `"suspect": "db"`) {
+		t.Errorf("prompt missing structured findings:\n%s", prompt)
+	}
+}
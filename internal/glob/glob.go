@@ -0,0 +1,20 @@
+// Package glob ranks the path.Match patterns that config files use to pick
+// services, so that the most specific match wins.
+package glob
+
+import "strings"
+
+// Specificity ranks patterns: literal characters count, wildcards don't, and
+// an exact name always wins.
+func Specificity(pattern string) int {
+	if !strings.ContainsAny(pattern, `*?[\`) {
+		return 1 << 20
+	}
+	n := 0
+	for _, r := range pattern {
+		if r != '*' && r != '?' {
+			n++
+		}
+	}
+	return n
+}
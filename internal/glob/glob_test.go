@@ -0,0 +1,12 @@
+package glob
+
+import "testing"
+
+func TestSpecificity(t *testing.T) {
+	ranked := []string{"checkout", "checkout-*", "check*", "*-api", "*"}
+	for i := 1; i < len(ranked); i++ {
+		if Specificity(ranked[i-1]) <= Specificity(ranked[i]) {
+			t.Errorf("%q should rank above %q", ranked[i-1], ranked[i])
+		}
+	}
+}
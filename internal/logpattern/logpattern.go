@@ -0,0 +1,279 @@
+// Package logpattern clusters log messages into templates using a Drain-style
+// fixed-depth parse tree (He et al., "Drain: An Online Log Parsing Approach
+// with Fixed Depth Tree", ICWS 2017), after masking obvious variables such as
+// UUIDs, IPs, hex IDs and numbers.
+package logpattern
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Wildcard marks a template position whose value varies between messages.
+const Wildcard = "<*>"
+
+const (
+	defaultDepth       = 2   // prefix tokens used to route a message in the tree
+	defaultSimilarity  = 0.6 // minimum share of matching tokens to join a cluster
+	defaultMaxChildren = 100 // per tree node; beyond this, tokens route to Wildcard
+	defaultMaxTokens   = 64  // longer messages (stack traces) are clustered on their head
+	maxValuesPerParam  = 20  // distinct sample values kept per parameter
+)
+
+var (
+	uuidRe   = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	ipRe     = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|(?i)\b(?:[0-9a-f]{1,4}:){3,7}[0-9a-f]{1,4}\b`)
+	hexRe    = regexp.MustCompile(`(?i)\b(0x)?[0-9a-f]*[0-9][0-9a-f]*\b`)
+	numberRe = regexp.MustCompile(`\d+`)
+)
+
+// Mask replaces the variable parts of a message (UUIDs, IPs, long hex IDs,
+// numbers) with <uuid>, <ip>, <id> and <n>.
+func Mask(s string) string {
+	s = uuidRe.ReplaceAllString(s, "<uuid>")
+	s = ipRe.ReplaceAllString(s, "<ip>")
+	s = hexRe.ReplaceAllStringFunc(s, func(m string) string {
+		if len(m) >= 8 {
+			return "<id>"
+		}
+		return m
+	})
+	return numberRe.ReplaceAllString(s, "<n>")
+}
+
+// Options tunes the miner. Zero values use the defaults.
+type Options struct {
+	Depth       int     // prefix tokens used to route messages (default 2)
+	Similarity  float64 // 0-1, minimum share of matching tokens (default 0.6)
+	MaxChildren int     // children per tree node (default 100)
+}
+
+// Miner incrementally clusters messages. It is not safe for concurrent use.
+type Miner struct {
+	opts     Options
+	root     map[int]*node // keyed by token count
+	clusters []*Cluster
+}
+
+type node struct {
+	children map[string]*node
+	clusters []*Cluster
+}
+
+// Cluster is a group of messages sharing a template.
+type Cluster struct {
+	ID     int
+	Tokens []string // template tokens; Wildcard and masks mark variables
+	Count  int
+	Sample string // first raw message seen
+
+	values []map[string]int // per position: raw values seen where the template varies
+}
+
+// Param describes one variable position of a template.
+type Param struct {
+	Position int
+	Values   []ValueCount // most frequent first, at most 20
+	Distinct int          // distinct values seen, possibly more than len(Values)
+}
+
+// ValueCount is a sample value of a parameter and how often it occurred.
+type ValueCount struct {
+	Value string
+	Count int
+}
+
+// New creates a miner.
+func New(opts Options) *Miner {
+	if opts.Depth <= 0 {
+		opts.Depth = defaultDepth
+	}
+	if opts.Similarity <= 0 {
+		opts.Similarity = defaultSimilarity
+	}
+	if opts.MaxChildren <= 0 {
+		opts.MaxChildren = defaultMaxChildren
+	}
+	return &Miner{opts: opts, root: make(map[int]*node)}
+}
+
+// Add clusters one message and returns the cluster it joined or created.
+// It returns nil for blank messages. A returned cluster stays valid as
+// later messages generalize its template.
+func (m *Miner) Add(message string) *Cluster {
+	raw := strings.Fields(message)
+	if len(raw) == 0 {
+		return nil
+	}
+	if len(raw) > defaultMaxTokens {
+		raw = raw[:defaultMaxTokens]
+	}
+	masked := make([]string, len(raw))
+	for i, t := range raw {
+		masked[i] = Mask(t)
+	}
+
+	leaf := m.leaf(masked)
+	c := m.best(leaf.clusters, masked)
+	if c == nil {
+		c = &Cluster{
+			ID:     len(m.clusters) + 1,
+			Tokens: append([]string(nil), masked...),
+			Sample: strings.TrimSpace(message),
+			values: make([]map[string]int, len(masked)),
+		}
+		leaf.clusters = append(leaf.clusters, c)
+		m.clusters = append(m.clusters, c)
+	} else {
+		for i, t := range masked {
+			if c.Tokens[i] == t || c.Tokens[i] == Wildcard {
+				continue
+			}
+			// Until now every message had this literal here; keep it as a value.
+			if !strings.Contains(c.Tokens[i], "<") {
+				if c.values[i] == nil {
+					c.values[i] = make(map[string]int)
+				}
+				c.values[i][c.Tokens[i]] = c.Count
+			}
+			c.Tokens[i] = Wildcard
+		}
+	}
+	c.Count++
+	c.record(raw)
+	return c
+}
+
+// leaf walks (and grows) the parse tree: first by token count, then by the
+// first Depth tokens. Tokens that look variable route through Wildcard so
+// they can't fragment the tree.
+func (m *Miner) leaf(tokens []string) *node {
+	n, ok := m.root[len(tokens)]
+	if !ok {
+		n = &node{children: make(map[string]*node)}
+		m.root[len(tokens)] = n
+	}
+	for i := 0; i < m.opts.Depth && i < len(tokens); i++ {
+		key := tokens[i]
+		if isVariable(key) {
+			key = Wildcard
+		}
+		child, ok := n.children[key]
+		if !ok {
+			if len(n.children) >= m.opts.MaxChildren {
+				key = Wildcard
+				child = n.children[key]
+			}
+			if child == nil {
+				child = &node{children: make(map[string]*node)}
+				n.children[key] = child
+			}
+		}
+		n = child
+	}
+	return n
+}
+
+// best returns the most similar cluster at or above the threshold. Ties go
+// to the cluster with fewer wildcards.
+func (m *Miner) best(clusters []*Cluster, tokens []string) *Cluster {
+	var best *Cluster
+	bestSim, bestParams := -1.0, 0
+	for _, c := range clusters {
+		same, params := 0, 0
+		for i, t := range c.Tokens {
+			switch {
+			case t == Wildcard:
+				params++
+			case t == tokens[i]:
+				same++
+			}
+		}
+		sim := float64(same) / float64(len(tokens))
+		if sim > bestSim || (sim == bestSim && params < bestParams) {
+			best, bestSim, bestParams = c, sim, params
+		}
+	}
+	if best == nil || bestSim < m.opts.Similarity {
+		return nil
+	}
+	return best
+}
+
+func isVariable(token string) bool {
+	return strings.ContainsAny(token, "0123456789<")
+}
+
+func (c *Cluster) record(raw []string) {
+	for i, t := range raw {
+		if c.Tokens[i] == t {
+			continue
+		}
+		if c.values[i] == nil {
+			c.values[i] = make(map[string]int)
+		}
+		if _, ok := c.values[i][t]; ok || len(c.values[i]) < maxValuesPerParam*5 {
+			c.values[i][t]++
+		}
+	}
+}
+
+// Template returns the cluster's template as a single string.
+func (c *Cluster) Template() string {
+	return strings.Join(c.Tokens, " ")
+}
+
+// Params returns the variable positions of the template with sample values.
+func (c *Cluster) Params() []Param {
+	var out []Param
+	for i, vals := range c.values {
+		if len(vals) == 0 {
+			continue
+		}
+		p := Param{Position: i, Distinct: len(vals)}
+		for v, n := range vals {
+			p.Values = append(p.Values, ValueCount{Value: v, Count: n})
+		}
+		sort.Slice(p.Values, func(a, b int) bool {
+			if p.Values[a].Count != p.Values[b].Count {
+				return p.Values[a].Count > p.Values[b].Count
+			}
+			return p.Values[a].Value < p.Values[b].Value
+		})
+		if len(p.Values) > maxValuesPerParam {
+			p.Values = p.Values[:maxValuesPerParam]
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// Clusters returns every cluster, most frequent first.
+func (m *Miner) Clusters() []*Cluster {
+	out := append([]*Cluster(nil), m.clusters...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Template() < out[j].Template()
+	})
+	return out
+}
+
+// Compatible reports whether two templates can describe the same messages:
+// same length, and every position equal or a Wildcard on either side. Use it
+// to match templates mined in different runs, which may generalize
+// differently.
+func Compatible(a, b string) bool {
+	ta, tb := strings.Fields(a), strings.Fields(b)
+	if len(ta) != len(tb) {
+		return false
+	}
+	for i := range ta {
+		if ta[i] != tb[i] && ta[i] != Wildcard && tb[i] != Wildcard {
+			return false
+		}
+	}
+	return true
+}
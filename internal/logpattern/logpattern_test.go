@@ -0,0 +1,97 @@
+package logpattern
+
+import "testing"
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"order 1234 failed", "order <n> failed"},
+		{"user 550e8400-e29b-41d4-a716-446655440000 not found", "user <uuid> not found"},
+		{"dial tcp 10.0.3.17:5432: connection refused", "dial tcp <ip>:<n>: connection refused"},
+		{"trace 4bf92f3577b34da6a3ce929d0e0e4736 dropped", "trace <id> dropped"},
+		{"cache miss", "cache miss"},
+	}
+	for _, tt := range tests {
+		if got := Mask(tt.in); got != tt.want {
+			t.Errorf("Mask(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMinerMergesVariableTokens(t *testing.T) {
+	m := New(Options{})
+	m.Add("payment declined for user alice amount 12")
+	m.Add("payment declined for user bob amount 40")
+	m.Add("payment declined for user carol amount 7")
+
+	clusters := m.Clusters()
+	if len(clusters) != 1 {
+		t.Fatalf("expected 1 cluster, got %d", len(clusters))
+	}
+	c := clusters[0]
+	if c.Template() != "payment declined for user <*> amount <n>" || c.Count != 3 {
+		t.Errorf("unexpected cluster %q (%d)", c.Template(), c.Count)
+	}
+	if c.Sample != "payment declined for user alice amount 12" {
+		t.Errorf("sample should be the first message, got %q", c.Sample)
+	}
+
+	params := c.Params()
+	if len(params) != 2 || params[0].Position != 4 || params[0].Distinct != 3 {
+		t.Fatalf("unexpected params %+v", params)
+	}
+	for _, v := range params[0].Values {
+		if v.Count != 1 {
+			t.Errorf("each user appears once, got %+v", params[0].Values)
+		}
+	}
+	if params[1].Position != 6 || params[1].Distinct != 3 || params[1].Values[0].Value != "12" {
+		t.Errorf("expected raw amounts as values, got %+v", params[1])
+	}
+}
+
+func TestMinerKeepsDistinctMessagesApart(t *testing.T) {
+	m := New(Options{})
+	a := m.Add("connection refused by upstream auth")
+	b := m.Add("connection pool exhausted waiting 30s")
+	c := m.Add("connection refused by upstream billing")
+	if a == b {
+		t.Error("different messages with a shared first token should not merge")
+	}
+	if a != c {
+		t.Error("messages differing in one token should merge")
+	}
+	if m.Add("   ") != nil {
+		t.Error("blank messages should be ignored")
+	}
+	if got := m.Clusters(); len(got) != 2 || got[0] != a {
+		t.Errorf("clusters should be ordered by count, got %d", len(got))
+	}
+}
+
+func TestMinerDifferentLengths(t *testing.T) {
+	m := New(Options{})
+	a := m.Add("timeout calling inventory")
+	b := m.Add("timeout calling inventory after retries")
+	if a == b {
+		t.Error("messages of different length should not share a cluster")
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"user <*> not found", "user alice not found", true},
+		{"user alice not found", "user <*> not found", true},
+		{"user alice not found", "user bob not found", false},
+		{"user <*> not found", "user <*> not found here", false},
+	}
+	for _, tt := range tests {
+		if got := Compatible(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compatible(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -226,7 +226,7 @@ func PrintTraces(traces []types.TraceEntry) {
 		op := t.OperationName
 
 		statusIcon := SuccessStyle.Render("✓")
-		if t.StatusCode == "ERROR" || t.StatusCode == "STATUS_CODE_ERROR" {
+		if t.IsError() {
 			statusIcon = ErrorStyle.Render("✗")
 		}
 
@@ -0,0 +1,111 @@
+// Package ownership maps services to the teams that own them, from the
+// teams section of ~/.argus/config.yaml.
+package ownership
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/lbarahona/argus/internal/glob"
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// Owner is the team that owns a service, with its escalation metadata.
+type Owner struct {
+	Team       string `json:"team"`
+	Escalation string `json:"escalation,omitempty"`
+	Runbook    string `json:"runbook,omitempty"`
+}
+
+// Catalog resolves service owners. A nil *Catalog owns nothing, so callers
+// don't need to check whether teams are configured.
+type Catalog struct {
+	teams map[string]types.Team
+}
+
+// New builds a catalog from config, validating the service globs. It
+// returns nil when no teams are configured.
+func New(teams map[string]types.Team) (*Catalog, error) {
+	if len(teams) == 0 {
+		return nil, nil
+	}
+	for name, t := range teams {
+		if len(t.Services) == 0 {
+			return nil, fmt.Errorf("team %q: no services", name)
+		}
+		for _, pattern := range t.Services {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("team %q: bad service pattern %q: %w", name, pattern, err)
+			}
+		}
+	}
+	return &Catalog{teams: teams}, nil
+}
+
+// FromConfig builds the catalog from the loaded config.
+func FromConfig(cfg *types.Config) (*Catalog, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	return New(cfg.Teams)
+}
+
+// Owner returns the team owning a service. When several teams match, the
+// most specific pattern wins (an exact name beats any glob), then the team
+// name in alphabetical order, so every service has at most one owner.
+func (c *Catalog) Owner(service string) (Owner, bool) {
+	if c == nil {
+		return Owner{}, false
+	}
+	best, bestScore := "", -1
+	for _, name := range c.Names() {
+		for _, pattern := range c.teams[name].Services {
+			if ok, _ := path.Match(pattern, service); !ok {
+				continue
+			}
+			if s := glob.Specificity(pattern); s > bestScore {
+				best, bestScore = name, s
+			}
+		}
+	}
+	if bestScore < 0 {
+		return Owner{}, false
+	}
+	return c.owner(best), true
+}
+
+// Team returns a team's metadata.
+func (c *Catalog) Team(name string) (Owner, bool) {
+	if c == nil {
+		return Owner{}, false
+	}
+	if _, ok := c.teams[name]; !ok {
+		return Owner{}, false
+	}
+	return c.owner(name), true
+}
+
+// Owns reports whether team owns service.
+func (c *Catalog) Owns(team, service string) bool {
+	o, ok := c.Owner(service)
+	return ok && o.Team == team
+}
+
+// Names returns the configured team names, sorted.
+func (c *Catalog) Names() []string {
+	if c == nil {
+		return nil
+	}
+	names := make([]string, 0, len(c.teams))
+	for name := range c.teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Catalog) owner(name string) Owner {
+	t := c.teams[name]
+	return Owner{Team: name, Escalation: t.Escalation, Runbook: t.Runbook}
+}
@@ -0,0 +1,72 @@
+package ownership
+
+import (
+	"testing"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+func testCatalog(t *testing.T) *Catalog {
+	t.Helper()
+	c, err := New(map[string]types.Team{
+		"payments": {Services: []string{"payment-*", "billing"}, Escalation: "#payments-oncall", Runbook: "https://wiki/payments"},
+		"platform": {Services: []string{"*-gateway", "payment-gateway"}},
+		"checkout": {Services: []string{"payment-*-web"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestOwner(t *testing.T) {
+	c := testCatalog(t)
+	tests := []struct {
+		service, team string
+	}{
+		{"billing", "payments"},
+		{"payment-api", "payments"},
+		{"payment-gateway", "platform"},   // exact name beats payment-*
+		{"payment-admin-web", "checkout"}, // more literal characters win
+		{"auth-gateway", "platform"},
+		{"search", ""},
+	}
+	for _, tt := range tests {
+		o, ok := c.Owner(tt.service)
+		if o.Team != tt.team || ok != (tt.team != "") {
+			t.Errorf("Owner(%q) = %q, %v; want %q", tt.service, o.Team, ok, tt.team)
+		}
+	}
+
+	o, _ := c.Owner("billing")
+	if o.Escalation != "#payments-oncall" || o.Runbook != "https://wiki/payments" {
+		t.Errorf("missing owner metadata: %+v", o)
+	}
+	if !c.Owns("payments", "payment-api") || c.Owns("payments", "payment-gateway") {
+		t.Error("each service should have a single owner")
+	}
+}
+
+func TestNilCatalog(t *testing.T) {
+	var c *Catalog
+	if _, ok := c.Owner("api"); ok {
+		t.Error("nil catalog owns nothing")
+	}
+	if _, ok := c.Team("payments"); ok || c.Names() != nil {
+		t.Error("nil catalog has no teams")
+	}
+	if c, err := New(nil); c != nil || err != nil {
+		t.Errorf("expected nil catalog, got %v, %v", c, err)
+	}
+}
+
+func TestNewValidates(t *testing.T) {
+	for _, teams := range []map[string]types.Team{
+		{"a": {}},
+		{"a": {Services: []string{"[bad"}}},
+	} {
+		if _, err := New(teams); err == nil {
+			t.Errorf("expected error for %+v", teams)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+// Package prompt assembles AI prompts from prioritized sections that share a
+// token budget. Repeated lines are collapsed with a count, long lines are cut,
+// and whatever does not fit is dropped lowest priority first and reported.
+package prompt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultBudget is the prompt size, in estimated tokens, used when a
+	// profile doesn't set prompt_budget.
+	DefaultBudget = 12000
+
+	// maxLine is the longest a single line may be, in characters.
+	maxLine = 500
+)
+
+// EstimateTokens approximates the token count of s at four characters per
+// token, which is close enough for English text and log lines.
+func EstimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
+
+// Priority decides which sections get the budget first.
+type Priority int
+
+const (
+	Low      Priority = iota // background, e.g. recent logs of all levels
+	Normal                   // supporting data, e.g. the service list
+	High                     // the signal: errors, new patterns, slow traces
+	Required                 // never dropped: framing and instructions
+)
+
+// Builder collects the parts of a prompt in the order they are written.
+type Builder struct {
+	budget int
+	parts  []*Section
+}
+
+// New creates a Builder for a prompt of at most budget estimated tokens;
+// zero or less uses DefaultBudget.
+func New(budget int) *Builder {
+	if budget <= 0 {
+		budget = DefaultBudget
+	}
+	return &Builder{budget: budget}
+}
+
+// Section is a titled list of lines, or a block of text kept or dropped as
+// a whole.
+type Section struct {
+	Title    string // e.g. "## Error Logs (12 found)", written before the lines
+	Priority Priority
+	Empty    string // written instead of the lines when there are none
+
+	block string
+	lines []line
+	index map[string]int
+}
+
+type line struct {
+	text  string
+	count int
+}
+
+// Text adds text that is always included, written as is.
+func (b *Builder) Text(s string) {
+	b.Block("", Required, s)
+}
+
+// Textf is Text with formatting.
+func (b *Builder) Textf(format string, args ...interface{}) {
+	b.Text(fmt.Sprintf(format, args...))
+}
+
+// Block adds text that is included whole or not at all. An empty block is
+// ignored.
+func (b *Builder) Block(title string, p Priority, s string) {
+	if s == "" {
+		return
+	}
+	b.parts = append(b.parts, &Section{Title: title, Priority: p, block: s})
+}
+
+// Section starts a list section. It is preceded by a blank line unless it
+// opens the prompt.
+func (b *Builder) Section(title string, p Priority) *Section {
+	s := &Section{Title: title, Priority: p, index: map[string]int{}}
+	b.parts = append(b.parts, s)
+	return s
+}
+
+// Add appends a line.
+func (s *Section) Add(text string) {
+	s.lines = append(s.lines, line{text: cut(text), count: 1})
+}
+
+// Addf is Add with formatting.
+func (s *Section) Addf(format string, args ...interface{}) {
+	s.Add(fmt.Sprintf(format, args...))
+}
+
+// AddKeyed appends a line unless one with the same key was already added, in
+// which case that line's count goes up instead. Counts above one are shown
+// as "(×N)", so a log line repeated 40 times costs one line.
+func (s *Section) AddKeyed(key, text string) {
+	if i, ok := s.index[key]; ok {
+		s.lines[i].count++
+		return
+	}
+	s.index[key] = len(s.lines)
+	s.Add(text)
+}
+
+// Len returns the number of distinct lines.
+func (s *Section) Len() int {
+	return len(s.lines)
+}
+
+func (l line) String() string {
+	if l.count > 1 {
+		return fmt.Sprintf("%s (×%d)", l.text, l.count)
+	}
+	return l.text
+}
+
+// cut shortens text to maxLine characters.
+func cut(text string) string {
+	if utf8.RuneCountInString(text) <= maxLine {
+		return text
+	}
+	return string([]rune(text)[:maxLine]) + "..."
+}
+
+// Drop records what was left out of one section.
+type Drop struct {
+	Section string // title without markdown, counts or colon
+	Lines   int    // lines left out
+	Total   int    // lines the section had, 0 for a block
+}
+
+func (d Drop) String() string {
+	switch {
+	case d.Total == 0:
+		return d.Section
+	case d.Lines == d.Total:
+		return fmt.Sprintf("all %d %s lines", d.Total, d.Section)
+	default:
+		return fmt.Sprintf("%d of %d %s lines", d.Lines, d.Total, d.Section)
+	}
+}
+
+// Result is an assembled prompt.
+type Result struct {
+	Text    string
+	Tokens  int // estimated
+	Budget  int
+	Dropped []Drop
+}
+
+// Trimmed reports whether anything was left out to fit the budget.
+func (r Result) Trimmed() bool {
+	return len(r.Dropped) > 0
+}
+
+// Summary describes what was dropped, e.g. "~11980 of 12000 tokens; dropped
+// 20 of 30 Recent Logs lines, Slow Traces". It is empty when nothing was.
+func (r Result) Summary() string {
+	if !r.Trimmed() {
+		return ""
+	}
+	parts := make([]string, len(r.Dropped))
+	for i, d := range r.Dropped {
+		parts[i] = d.String()
+	}
+	return fmt.Sprintf("~%d of %d tokens; dropped %s", r.Tokens, r.Budget, strings.Join(parts, ", "))
+}
+
+// Build fits the sections into the budget and renders them in the order they
+// were added. Required parts always go in; the rest are filled by priority,
+// highest first, line by line, and a section that runs out of room ends with
+// a note saying how many lines were omitted. A section without room for its
+// title and one line is dropped whole.
+func (b *Builder) Build() Result {
+	keep := make([]int, len(b.parts)) // lines kept per part; -1 drops it
+	used := 0
+	for i, s := range b.parts {
+		if s.Priority == Required {
+			keep[i] = len(s.lines)
+			used += s.cost(len(s.lines))
+		}
+	}
+
+	order := make([]int, 0, len(b.parts))
+	for i, s := range b.parts {
+		if s.Priority != Required {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(x, y int) bool {
+		return b.parts[order[x]].Priority > b.parts[order[y]].Priority
+	})
+
+	var dropped []Drop
+	for _, i := range order {
+		s := b.parts[i]
+		if s.block != "" || len(s.lines) == 0 {
+			if cost := s.cost(0); used+cost <= b.budget {
+				used += cost
+			} else {
+				keep[i] = -1
+				dropped = append(dropped, Drop{Section: s.name()})
+			}
+			continue
+		}
+
+		// Fill line by line, keeping room for the omission note while lines
+		// are still being left out.
+		size := s.cost(-1)
+		n := 0
+		for ; n < len(s.lines); n++ {
+			next := size + EstimateTokens(s.lines[n].String()+"\n")
+			if used+next+noteCost(len(s.lines)-n-1) > b.budget {
+				break
+			}
+			size = next
+		}
+		if n == 0 {
+			keep[i] = -1
+			dropped = append(dropped, Drop{Section: s.name(), Lines: len(s.lines), Total: len(s.lines)})
+			continue
+		}
+		keep[i] = n
+		used += size + noteCost(len(s.lines)-n)
+		if n < len(s.lines) {
+			dropped = append(dropped, Drop{Section: s.name(), Lines: len(s.lines) - n, Total: len(s.lines)})
+		}
+	}
+
+	// Report drops in prompt order, not fill order.
+	pos := map[string]int{}
+	for i, s := range b.parts {
+		pos[s.name()] = i
+	}
+	sort.SliceStable(dropped, func(x, y int) bool { return pos[dropped[x].Section] < pos[dropped[y].Section] })
+
+	var sb strings.Builder
+	for i, s := range b.parts {
+		if keep[i] < 0 {
+			continue
+		}
+		s.render(&sb, keep[i])
+	}
+	text := sb.String()
+	return Result{Text: text, Tokens: EstimateTokens(text), Budget: b.budget, Dropped: dropped}
+}
+
+// cost estimates the tokens of the section with its first n lines, counting
+// the blank line before its title. With n of -1 it is the title alone.
+func (s *Section) cost(n int) int {
+	var sb strings.Builder
+	if s.Title != "" && s.block == "" {
+		sb.WriteString("\n")
+	}
+	if n < 0 {
+		sb.WriteString(s.Title + "\n")
+	} else {
+		s.render(&sb, n)
+	}
+	return EstimateTokens(sb.String())
+}
+
+// noteCost is the size of the note for n omitted lines.
+func noteCost(n int) int {
+	if n == 0 {
+		return 0
+	}
+	return EstimateTokens(omitted(n) + "\n")
+}
+
+func (s *Section) render(sb *strings.Builder, n int) {
+	if s.block != "" {
+		sb.WriteString(s.block)
+		return
+	}
+	if s.Title != "" {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(s.Title + "\n")
+	}
+	if len(s.lines) == 0 {
+		if s.Empty != "" {
+			sb.WriteString(s.Empty + "\n")
+		}
+		return
+	}
+	for _, l := range s.lines[:n] {
+		sb.WriteString(l.String() + "\n")
+	}
+	if n < len(s.lines) {
+		sb.WriteString(omitted(len(s.lines)-n) + "\n")
+	}
+}
+
+func omitted(n int) string {
+	return fmt.Sprintf("... %d more omitted to fit the prompt budget", n)
+}
+
+// name is the section title without markdown, counts or a trailing colon.
+func (s *Section) name() string {
+	name := strings.TrimSpace(strings.TrimLeft(s.Title, "#"))
+	if i := strings.Index(name, " ("); i > 0 {
+		name = name[:i]
+	}
+	name = strings.TrimSuffix(name, ":")
+	if name == "" {
+		name = "untitled"
+	}
+	return name
+}
@@ -0,0 +1,129 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestBuildFitsEverything(t *testing.T) {
+	b := New(0)
+	b.Text("Analyze the service.\n")
+	errs := b.Section("## Error Logs", High)
+	errs.Add("[10:00:00] boom")
+	none := b.Section("## Traces", Low)
+	none.Empty = "No traces found."
+	b.Text("\nBe brief.")
+
+	r := b.Build()
+	want := "Analyze the service.\n\n## Error Logs\n[10:00:00] boom\n\n## Traces\nNo traces found.\n\nBe brief."
+	if r.Text != want {
+		t.Errorf("got %q\nwant %q", r.Text, want)
+	}
+	if r.Trimmed() || r.Summary() != "" || r.Budget != DefaultBudget {
+		t.Errorf("nothing should be dropped: %+v", r)
+	}
+	if r.Tokens != EstimateTokens(want) {
+		t.Errorf("expected %d tokens, got %d", EstimateTokens(want), r.Tokens)
+	}
+}
+
+func TestAddKeyedCollapsesRepeats(t *testing.T) {
+	b := New(0)
+	s := b.Section("## Error Logs", High)
+	for i := 0; i < 40; i++ {
+		s.AddKeyed("timeout", fmt.Sprintf("[10:00:%02d] upstream timeout", i))
+	}
+	s.AddKeyed("refused", "[10:01:00] connection refused")
+
+	if s.Len() != 2 {
+		t.Fatalf("expected 2 distinct lines, got %d", s.Len())
+	}
+	want := "## Error Logs\n[10:00:00] upstream timeout (×40)\n[10:01:00] connection refused\n"
+	if got := b.Build().Text; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuildDropsLowPriorityFirst(t *testing.T) {
+	b := New(120)
+	b.Text("Header.\n")
+	recent := b.Section("## Recent Logs (30 entries)", Low)
+	for i := 0; i < 30; i++ {
+		recent.Addf("[INFO] request %d handled in a reasonable time", i)
+	}
+	errs := b.Section("## Error Logs (5 found)", High)
+	for i := 0; i < 5; i++ {
+		errs.Addf("[ERROR] payment %d declined", i)
+	}
+	b.Block("## Findings", Normal, strings.Repeat("finding ", 200))
+	b.Text("\nInstructions.")
+
+	r := b.Build()
+	if r.Tokens > r.Budget {
+		t.Errorf("prompt of %d tokens exceeds the budget of %d", r.Tokens, r.Budget)
+	}
+	for i := 0; i < 5; i++ {
+		if !strings.Contains(r.Text, fmt.Sprintf("payment %d declined", i)) {
+			t.Errorf("high priority line %d should be kept:\n%s", i, r.Text)
+		}
+	}
+	if !strings.HasPrefix(r.Text, "Header.\n") || !strings.HasSuffix(r.Text, "\nInstructions.") {
+		t.Errorf("required text must stay in place:\n%s", r.Text)
+	}
+	if strings.Contains(r.Text, "finding") {
+		t.Error("a block that doesn't fit should be dropped whole")
+	}
+	if !strings.Contains(r.Text, "more omitted to fit the prompt budget") {
+		t.Errorf("expected an omission note:\n%s", r.Text)
+	}
+	// Sections keep their written order even though errors were filled first.
+	if strings.Index(r.Text, "Recent Logs") > strings.Index(r.Text, "Error Logs") {
+		t.Errorf("sections out of order:\n%s", r.Text)
+	}
+
+	if len(r.Dropped) != 2 {
+		t.Fatalf("expected 2 drops, got %+v", r.Dropped)
+	}
+	if d := r.Dropped[0]; d.Section != "Recent Logs" || d.Total != 30 || d.Lines == 0 || d.Lines == 30 {
+		t.Errorf("expected part of Recent Logs dropped, got %+v", d)
+	}
+	if d := r.Dropped[1]; d.Section != "Findings" || d.Total != 0 {
+		t.Errorf("expected Findings dropped, got %+v", d)
+	}
+	if s := r.Summary(); !strings.Contains(s, "of 30 Recent Logs lines, Findings") || !strings.HasPrefix(s, "~") {
+		t.Errorf("unexpected summary %q", s)
+	}
+}
+
+func TestBuildDropsSectionWithoutRoom(t *testing.T) {
+	b := New(10)
+	b.Text(strings.Repeat("x", 36))
+	s := b.Section("## Services", Normal)
+	s.Add("- api: 100 calls, 3 errors")
+
+	r := b.Build()
+	if strings.Contains(r.Text, "Services") {
+		t.Errorf("section should be dropped, got %q", r.Text)
+	}
+	if len(r.Dropped) != 1 || r.Dropped[0].String() != "all 1 Services lines" {
+		t.Errorf("unexpected drops %+v", r.Dropped)
+	}
+}
+
+func TestLongLinesAreCut(t *testing.T) {
+	b := New(0)
+	b.Section("## Logs", Normal).Add(strings.Repeat("a", 2000))
+	text := b.Build().Text
+	if len(text) > maxLine+30 || !strings.Contains(text, "...") {
+		t.Errorf("expected the line cut to %d characters, got %d", maxLine, len(text))
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	for s, want := range map[string]int{"": 0, "abc": 1, "abcd": 1, "abcde": 2, "héllo wörld!": 3} {
+		if got := EstimateTokens(s); got != want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Package redact scrubs sensitive values (emails, tokens, API keys, card
+// numbers, IPs and user-defined patterns) from text before it is sent to an
+// AI provider.
+package redact
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// Redactor replaces sensitive values with placeholders such as [EMAIL]. A nil
+// Redactor leaves text untouched.
+type Redactor struct {
+	rules []rule
+}
+
+type rule struct {
+	name    string
+	re      *regexp.Regexp
+	replace string
+	valid   func(match string) bool // optional check that a match really is sensitive
+}
+
+// builtins run in order: JWTs before bearer tokens, so "Bearer eyJ..." keeps
+// its more specific label, and keys before the generic key=value pattern.
+var builtins = []rule{
+	{name: "jwt", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]*`), replace: "[JWT]"},
+	{name: "bearer", re: regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/=-]{8,}`), replace: "${1}[TOKEN]"},
+	{name: "api_key", re: regexp.MustCompile(`\b(?:sk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abprs]-[A-Za-z0-9-]{10,}|AIza[0-9A-Za-z_-]{35})`), replace: "[API_KEY]"},
+	{name: "api_key", re: regexp.MustCompile(`(?i)\b((?:api[_-]?key|access[_-]?key|secret|token|password|passwd|pwd)["']?\s*[:=]\s*["']?)[^\s"',;&}\[][^\s"',;&}]{5,}`), replace: "${1}[SECRET]"},
+	{name: "email", re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`), replace: "[EMAIL]"},
+	{name: "card", re: regexp.MustCompile(`\b[2-6](?:[ -]?\d){12,18}\b`), replace: "[CARD]", valid: isCard},
+	{name: "ip", re: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b`), replace: "[IP]"},
+	{name: "ip", re: regexp.MustCompile(`[0-9A-Za-z]*:[0-9A-Za-z:]*:[0-9A-Za-z:]*`), replace: "[IP]", valid: isIPv6},
+}
+
+// Builtins returns the names of the built-in detectors.
+func Builtins() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, r := range builtins {
+		if !seen[r.name] {
+			seen[r.name] = true
+			names = append(names, r.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New builds a Redactor from cfg. A nil cfg enables every built-in detector;
+// a disabled one returns a nil Redactor. User rules run before the built-ins,
+// so they see the original text.
+func New(cfg *types.RedactionConfig) (*Redactor, error) {
+	if cfg == nil {
+		cfg = &types.RedactionConfig{}
+	}
+	if cfg.Disabled {
+		return nil, nil
+	}
+
+	r := &Redactor{}
+	for i, ur := range cfg.Rules {
+		if ur.Pattern == "" {
+			return nil, fmt.Errorf("redaction rule %d (%q): pattern is required", i+1, ur.Name)
+		}
+		re, err := regexp.Compile(ur.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redaction rule %q: %w", ur.Name, err)
+		}
+		name := ur.Name
+		if name == "" {
+			name = "redacted"
+		}
+		replace := ur.Replacement
+		if replace == "" {
+			replace = "[" + strings.ToUpper(name) + "]"
+		}
+		r.rules = append(r.rules, rule{name: name, re: re, replace: replace})
+	}
+
+	skip := map[string]bool{}
+	for _, name := range cfg.Disable {
+		if !isBuiltin(name) {
+			return nil, fmt.Errorf("unknown redaction detector %q (want one of %s)", name, strings.Join(Builtins(), ", "))
+		}
+		skip[name] = true
+	}
+	for _, b := range builtins {
+		if !skip[b.name] {
+			r.rules = append(r.rules, b)
+		}
+	}
+	return r, nil
+}
+
+func isBuiltin(name string) bool {
+	for _, b := range builtins {
+		if b.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns s with every sensitive value replaced.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, rl := range r.rules {
+		if rl.valid == nil {
+			s = rl.re.ReplaceAllString(s, rl.replace)
+			continue
+		}
+		s = rl.re.ReplaceAllStringFunc(s, func(m string) string {
+			if rl.valid(m) {
+				return rl.replace
+			}
+			return m
+		})
+	}
+	return s
+}
+
+// isCard reports whether m, with spaces and dashes removed, is 13 to 19
+// digits that pass the Luhn check.
+func isCard(m string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(m)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	return luhn(digits)
+}
+
+func luhn(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// isIPv6 filters the loose IPv6 pattern, which matches any word with two
+// colons, such as 15:04:05 or std::io::Error.
+func isIPv6(m string) bool {
+	ip := net.ParseIP(m)
+	return ip != nil && ip.To4() == nil
+}
@@ -0,0 +1,282 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lbarahona/argus/internal/alert"
+	"github.com/lbarahona/argus/internal/logpattern"
+	"github.com/lbarahona/argus/internal/slo"
+	"github.com/lbarahona/argus/internal/stats"
+)
+
+const (
+	// historyKeep is how many past reports are kept per instance.
+	historyKeep = 200
+	// rateMoveThreshold is the smallest error-rate change, in percentage
+	// points, worth calling out; it must also be statistically significant.
+	rateMoveThreshold = 1.0
+)
+
+// SLOStatus is the state of one SLO when the report was generated.
+type SLOStatus struct {
+	Name    string  `json:"name"`
+	Service string  `json:"service"`
+	Status  string  `json:"status"` // ok, warning, critical, exhausted
+	Current float64 `json:"current"`
+	Target  float64 `json:"target"`
+}
+
+// AlertStatus is an alert rule that was firing when the report was generated.
+type AlertStatus struct {
+	Rule    string `json:"rule"`
+	Service string `json:"service"`
+	Status  string `json:"status"` // warning or critical
+	Message string `json:"message"`
+}
+
+// Changes is what moved between the previous report and this one.
+type Changes struct {
+	Since            time.Time          `json:"since"` // when the previous report was generated
+	ErrorRates       []RateChange       `json:"error_rates"`
+	NewPatterns      []JSONErrorPattern `json:"new_patterns"`
+	ResolvedPatterns []JSONErrorPattern `json:"resolved_patterns"`
+	SLOs             []SLOChange        `json:"slos"`
+	AlertsStarted    []AlertStatus      `json:"alerts_started"`
+	AlertsStopped    []AlertStatus      `json:"alerts_stopped"`
+}
+
+// RateChange is a service whose error rate moved significantly.
+type RateChange struct {
+	Service string  `json:"service"`
+	Before  float64 `json:"before"` // percent
+	After   float64 `json:"after"`
+}
+
+// SLOChange is an SLO whose status changed.
+type SLOChange struct {
+	Name    string `json:"name"`
+	Service string `json:"service"`
+	Before  string `json:"before"`
+	After   string `json:"after"`
+}
+
+// Empty reports whether nothing changed.
+func (c *Changes) Empty() bool {
+	return len(c.ErrorRates) == 0 && len(c.NewPatterns) == 0 && len(c.ResolvedPatterns) == 0 &&
+		len(c.SLOs) == 0 && len(c.AlertsStarted) == 0 && len(c.AlertsStopped) == 0
+}
+
+// LoadChecks loads the SLO and alert rule configs that reports evaluate.
+// Either is nil when not configured.
+func LoadChecks() (*slo.SLOConfig, *alert.AlertConfig) {
+	slos, err := slo.LoadSLOs()
+	if err != nil {
+		slos = nil
+	}
+	rules, err := alert.LoadAlerts()
+	if err != nil {
+		rules = nil
+	}
+	return slos, rules
+}
+
+// compare computes the changes since prev.
+func (r *Report) compare(prev *JSONReport) *Changes {
+	c := &Changes{
+		Since:            prev.GeneratedAt,
+		ErrorRates:       []RateChange{},
+		NewPatterns:      []JSONErrorPattern{},
+		ResolvedPatterns: []JSONErrorPattern{},
+		SLOs:             []SLOChange{},
+		AlertsStarted:    []AlertStatus{},
+		AlertsStopped:    []AlertStatus{},
+	}
+
+	before := make(map[string]JSONService)
+	for _, s := range prev.Services {
+		before[s.Name] = s
+	}
+	for _, s := range r.Services {
+		b, ok := before[s.Name]
+		if !ok || b.Calls == 0 || s.NumCalls == 0 {
+			continue
+		}
+		if math.Abs(s.ErrorRate-b.ErrorRate) < rateMoveThreshold {
+			continue
+		}
+		if _, p := stats.TwoProportionZTest(b.Errors, b.Calls, s.NumErrors, s.NumCalls); p >= 0.05 {
+			continue
+		}
+		c.ErrorRates = append(c.ErrorRates, RateChange{Service: s.Name, Before: b.ErrorRate, After: s.ErrorRate})
+	}
+	sort.Slice(c.ErrorRates, func(i, j int) bool {
+		di := math.Abs(c.ErrorRates[i].After - c.ErrorRates[i].Before)
+		dj := math.Abs(c.ErrorRates[j].After - c.ErrorRates[j].Before)
+		if di != dj {
+			return di > dj
+		}
+		return c.ErrorRates[i].Service < c.ErrorRates[j].Service
+	})
+
+	// Templates mined in different runs may generalize differently, so match
+	// them by compatibility rather than equality. Only the top patterns are
+	// stored, so a pattern counts as resolved when no template mined from all
+	// of this window's error logs matches it.
+	matches := func(service, pattern string, in []ErrorPattern) bool {
+		for _, p := range in {
+			if p.Service == service && logpattern.Compatible(p.Pattern, pattern) {
+				return true
+			}
+		}
+		return false
+	}
+	var prevPatterns []ErrorPattern
+	for _, p := range prev.ErrorPatterns {
+		prevPatterns = append(prevPatterns, ErrorPattern{Service: p.Service, Pattern: p.Pattern})
+	}
+	current := GroupPatterns(r.ErrorLogs)
+	for _, p := range r.ErrorPatterns {
+		if !matches(p.Service, p.Pattern, prevPatterns) {
+			c.NewPatterns = append(c.NewPatterns, JSONErrorPattern{Service: p.Service, Pattern: p.Pattern, Count: p.Count, Sample: p.Sample})
+		}
+	}
+	for _, p := range prev.ErrorPatterns {
+		if !matches(p.Service, p.Pattern, current) {
+			c.ResolvedPatterns = append(c.ResolvedPatterns, p)
+		}
+	}
+
+	prevSLOs := make(map[string]SLOStatus)
+	for _, s := range prev.SLOs {
+		prevSLOs[s.Name] = s
+	}
+	for _, s := range r.SLOs {
+		if b, ok := prevSLOs[s.Name]; ok && b.Status != s.Status {
+			c.SLOs = append(c.SLOs, SLOChange{Name: s.Name, Service: s.Service, Before: b.Status, After: s.Status})
+		}
+	}
+
+	alertKey := func(a AlertStatus) string { return a.Rule + "\x00" + a.Service }
+	prevAlerts := make(map[string]bool)
+	for _, a := range prev.Alerts {
+		prevAlerts[alertKey(a)] = true
+	}
+	curAlerts := make(map[string]bool)
+	for _, a := range r.Alerts {
+		curAlerts[alertKey(a)] = true
+		if !prevAlerts[alertKey(a)] {
+			c.AlertsStarted = append(c.AlertsStarted, a)
+		}
+	}
+	for _, a := range prev.Alerts {
+		if !curAlerts[alertKey(a)] {
+			c.AlertsStopped = append(c.AlertsStopped, a)
+		}
+	}
+	return c
+}
+
+// lines describes each change on one line, for the text renderers.
+func (c *Changes) lines() []string {
+	var out []string
+	for _, rc := range c.ErrorRates {
+		arrow := "📈"
+		if rc.After < rc.Before {
+			arrow = "📉"
+		}
+		out = append(out, fmt.Sprintf("%s %s error rate %.1f%% → %.1f%%", arrow, rc.Service, rc.Before, rc.After))
+	}
+	for _, p := range c.NewPatterns {
+		out = append(out, fmt.Sprintf("🆕 [%s] new error pattern (%dx): %s", p.Service, p.Count, truncate(p.Pattern, 60)))
+	}
+	for _, p := range c.ResolvedPatterns {
+		out = append(out, fmt.Sprintf("✅ [%s] resolved error pattern: %s", p.Service, truncate(p.Pattern, 60)))
+	}
+	for _, s := range c.SLOs {
+		out = append(out, fmt.Sprintf("🎯 SLO %s: %s → %s", s.Name, s.Before, s.After))
+	}
+	for _, a := range c.AlertsStarted {
+		out = append(out, fmt.Sprintf("🔔 alert started: %s (%s) — %s", a.Rule, a.Status, a.Message))
+	}
+	for _, a := range c.AlertsStopped {
+		out = append(out, fmt.Sprintf("🔕 alert stopped: %s — %s", a.Rule, a.Service))
+	}
+	return out
+}
+
+func historyDir(key string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".argus", "history", "reports", sanitize(key))
+}
+
+// historyKey separates team-scoped reports from whole-instance ones, so each
+// is compared with its own kind.
+func (r *Report) historyKey() string {
+	if r.Team != nil {
+		return r.Instance + "-team-" + r.Team.Team
+	}
+	return r.Instance
+}
+
+// LoadPrevious returns the most recently saved report under a history key
+// (the instance, or "<instance>-team-<team>" for team reports), or nil if
+// there is none.
+func LoadPrevious(key string) (*JSONReport, error) {
+	dir := historyDir(key)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading report history: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	sort.Strings(names)
+
+	data, err := os.ReadFile(filepath.Join(dir, names[len(names)-1]))
+	if err != nil {
+		return nil, fmt.Errorf("reading previous report: %w", err)
+	}
+	var prev JSONReport
+	if err := json.Unmarshal(data, &prev); err != nil {
+		return nil, fmt.Errorf("parsing previous report: %w", err)
+	}
+	return &prev, nil
+}
+
+// SaveHistory stores the report as JSON under ~/.argus/history/reports and
+// prunes the oldest entries.
+func SaveHistory(r *Report) (string, error) {
+	dir := historyDir(r.historyKey())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("creating report history: %w", err)
+	}
+	path := filepath.Join(dir, r.GeneratedAt.UTC().Format("20060102-150405.000")+".json")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", fmt.Errorf("saving report: %w", err)
+	}
+	err = r.RenderJSON(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("saving report: %w", err)
+	}
+	return path, rotate(dir, "", historyKeep)
+}
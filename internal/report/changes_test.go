@@ -0,0 +1,120 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+func TestCompareWithPrevious(t *testing.T) {
+	prev := &JSONReport{
+		GeneratedAt: time.Date(2026, 3, 1, 7, 0, 0, 0, time.UTC),
+		Services: []JSONService{
+			{Name: "api", Calls: 1000, Errors: 10, ErrorRate: 1},
+			{Name: "web", Calls: 1000, Errors: 50, ErrorRate: 5},
+		},
+		ErrorPatterns: []JSONErrorPattern{
+			{Service: "api", Pattern: "connection refused", Count: 3},
+			{Service: "web", Pattern: "template missing", Count: 2},
+		},
+		SLOs:   []SLOStatus{{Name: "api-availability", Service: "api", Status: "ok"}},
+		Alerts: []AlertStatus{{Rule: "api-errors", Service: "api", Status: "warning"}},
+	}
+
+	logs := []types.LogEntry{
+		{ServiceName: "api", Body: "connection refused"},
+		{ServiceName: "api", Body: "deadline exceeded"},
+	}
+	r := &Report{
+		Services: []types.Service{
+			{Name: "api", NumCalls: 1000, NumErrors: 80, ErrorRate: 8},
+			{Name: "web", NumCalls: 1000, NumErrors: 52, ErrorRate: 5.2},
+		},
+		ErrorLogs:     logs,
+		ErrorPatterns: detectPatterns(logs),
+		SLOs:          []SLOStatus{{Name: "api-availability", Service: "api", Status: "warning"}},
+		Alerts:        []AlertStatus{{Rule: "web-errors", Service: "web", Status: "critical", Message: "boom"}},
+	}
+
+	c := r.compare(prev)
+	if len(c.ErrorRates) != 1 || c.ErrorRates[0].Service != "api" || c.ErrorRates[0].Before != 1 {
+		t.Errorf("expected only api's error rate to move, got %+v", c.ErrorRates)
+	}
+	if len(c.NewPatterns) != 1 || c.NewPatterns[0].Pattern != "deadline exceeded" {
+		t.Errorf("unexpected new patterns %+v", c.NewPatterns)
+	}
+	if len(c.ResolvedPatterns) != 1 || c.ResolvedPatterns[0].Service != "web" {
+		t.Errorf("unexpected resolved patterns %+v", c.ResolvedPatterns)
+	}
+	if len(c.SLOs) != 1 || c.SLOs[0].Before != "ok" || c.SLOs[0].After != "warning" {
+		t.Errorf("unexpected SLO changes %+v", c.SLOs)
+	}
+	if len(c.AlertsStarted) != 1 || c.AlertsStarted[0].Rule != "web-errors" {
+		t.Errorf("unexpected started alerts %+v", c.AlertsStarted)
+	}
+	if len(c.AlertsStopped) != 1 || c.AlertsStopped[0].Rule != "api-errors" {
+		t.Errorf("unexpected stopped alerts %+v", c.AlertsStopped)
+	}
+	if c.Empty() || len(c.lines()) != 6 {
+		t.Errorf("expected 6 change lines, got %q", c.lines())
+	}
+}
+
+func TestGeneratePersistsAndCompares(t *testing.T) {
+	home := withTempHome(t)
+	errCount := 5
+	mock := &mockSignozClient{
+		listServicesFunc: func(ctx context.Context) ([]types.Service, error) {
+			return []types.Service{{Name: "api", NumCalls: 1000, NumErrors: errCount, ErrorRate: float64(errCount) / 10}}, nil
+		},
+	}
+
+	first, err := Generate(context.Background(), mock, "prod", Options{Duration: 60, Persist: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Changes != nil {
+		t.Error("first report has nothing to compare with")
+	}
+
+	errCount = 90
+	second, err := Generate(context.Background(), mock, "prod", Options{Duration: 60, Persist: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Changes == nil || len(second.Changes.ErrorRates) != 1 {
+		t.Fatalf("expected an error rate change, got %+v", second.Changes)
+	}
+
+	var buf bytes.Buffer
+	second.RenderTerminal(&buf)
+	if !strings.Contains(buf.String(), "Changes since last report") || !strings.Contains(buf.String(), "api error rate 0.5% → 9.0%") {
+		t.Errorf("terminal output missing changes:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := second.RenderHTML(&buf); err != nil || !strings.Contains(buf.String(), "Changes Since Last Report") {
+		t.Errorf("html output missing changes (%v)", err)
+	}
+
+	entries, _ := os.ReadDir(historyDir("prod"))
+	if len(entries) == 0 || !strings.HasPrefix(historyDir("prod"), home) {
+		t.Errorf("expected saved history under %s", home)
+	}
+
+	if r, _ := Generate(context.Background(), mock, "prod", Options{Duration: 60}); r.Changes != nil {
+		t.Error("reports without Persist should not compare")
+	}
+}
+
+func TestLoadChecksWithoutConfig(t *testing.T) {
+	withTempHome(t)
+	if slos, rules := LoadChecks(); slos != nil || rules != nil {
+		t.Errorf("expected no checks, got %v / %v", slos, rules)
+	}
+}
@@ -0,0 +1,249 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// Delivery is a generated report ready to be sent.
+type Delivery struct {
+	Schedule string
+	Path     string
+	Report   *Report
+}
+
+// Deliverer sends a generated report somewhere.
+type Deliverer interface {
+	Deliver(ctx context.Context, d Delivery) error
+}
+
+// Headline is a one-line summary of the report, used as email subject and
+// Slack message.
+func (r *Report) Headline() string {
+	s := fmt.Sprintf("Argus report %s — %s: %d services, %d errors (%.2f%%)",
+		r.Instance, r.windowLabel(), len(r.Services), r.TotalErrors, r.errorRate())
+	if len(r.TopErrors) > 0 {
+		s += fmt.Sprintf(", top: %s (%d)", r.TopErrors[0].Service, r.TopErrors[0].Errors)
+	}
+	return s
+}
+
+// SMTPDeliverer emails the report as an attachment.
+type SMTPDeliverer struct {
+	cfg  types.SMTPConfig
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPDeliverer creates an email deliverer.
+func NewSMTPDeliverer(cfg types.SMTPConfig) *SMTPDeliverer {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &SMTPDeliverer{cfg: cfg, send: smtp.SendMail}
+}
+
+func (d *SMTPDeliverer) Deliver(ctx context.Context, del Delivery) error {
+	if len(d.cfg.To) == 0 {
+		return fmt.Errorf("smtp: no recipients configured")
+	}
+	msg, err := d.message(del)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if d.cfg.Username != "" {
+		auth = smtp.PlainAuth("", d.cfg.Username, d.cfg.Password, d.cfg.Host)
+	}
+	addr := d.cfg.Host + ":" + strconv.Itoa(d.cfg.Port)
+	if err := d.send(addr, auth, d.cfg.From, d.cfg.To, msg); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return nil
+}
+
+// message builds a multipart email with a short text body and the report
+// file attached.
+func (d *SMTPDeliverer) message(del Delivery) ([]byte, error) {
+	data, err := os.ReadFile(del.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading report: %w", err)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", d.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(d.cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", del.Report.Headline()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mw.Boundary())
+
+	text, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(text, "%s\r\n\r\nThe full report is attached (%s).\r\n", del.Report.Headline(), filepath.Base(del.Path))
+
+	ctype := mime.TypeByExtension(filepath.Ext(del.Path))
+	if ctype == "" {
+		ctype = "application/octet-stream"
+	}
+	att, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {ctype},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", filepath.Base(del.Path))},
+	})
+	if err != nil {
+		return nil, err
+	}
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > 76 {
+		io.WriteString(att, enc[:76]+"\r\n")
+		enc = enc[76:]
+	}
+	io.WriteString(att, enc+"\r\n")
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// SlackDeliverer uploads the report file to a channel with a bot token, or
+// posts the headline to an incoming webhook (which cannot carry files).
+type SlackDeliverer struct {
+	cfg    types.SlackConfig
+	apiURL string
+	client *http.Client
+}
+
+// NewSlackDeliverer creates a Slack deliverer.
+func NewSlackDeliverer(cfg types.SlackConfig) *SlackDeliverer {
+	return &SlackDeliverer{cfg: cfg, apiURL: "https://slack.com/api", client: &http.Client{Timeout: 60 * time.Second}}
+}
+
+func (d *SlackDeliverer) Deliver(ctx context.Context, del Delivery) error {
+	switch {
+	case d.cfg.Token != "" && d.cfg.Channel != "":
+		return d.upload(ctx, del)
+	case d.cfg.WebhookURL != "":
+		return d.postWebhook(ctx, del)
+	default:
+		return fmt.Errorf("slack: set token and channel, or webhook_url")
+	}
+}
+
+// upload uses Slack's external upload flow: reserve an upload URL, send the
+// bytes there, then share the file in the channel.
+func (d *SlackDeliverer) upload(ctx context.Context, del Delivery) error {
+	data, err := os.ReadFile(del.Path)
+	if err != nil {
+		return fmt.Errorf("reading report: %w", err)
+	}
+	name := filepath.Base(del.Path)
+
+	var reserved struct {
+		UploadURL string `json:"upload_url"`
+		FileID    string `json:"file_id"`
+	}
+	form := url.Values{"filename": {name}, "length": {strconv.Itoa(len(data))}}
+	if err := d.call(ctx, "files.getUploadURLExternal", "application/x-www-form-urlencoded", strings.NewReader(form.Encode()), &reserved); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reserved.UploadURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("slack: creating upload request: %w", err)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack: uploading file: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack: upload failed (status %d)", resp.StatusCode)
+	}
+
+	complete, _ := json.Marshal(map[string]interface{}{
+		"files":           []map[string]string{{"id": reserved.FileID, "title": name}},
+		"channel_id":      d.cfg.Channel,
+		"initial_comment": del.Report.Headline(),
+	})
+	return d.call(ctx, "files.completeUploadExternal", "application/json; charset=utf-8", bytes.NewReader(complete), nil)
+}
+
+// call invokes a Slack Web API method and decodes the response into out.
+func (d *SlackDeliverer) call(ctx context.Context, method, contentType string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.apiURL+"/"+method, body)
+	if err != nil {
+		return fmt.Errorf("slack: creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+d.cfg.Token)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack: %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("slack: reading %s response: %w", method, err)
+	}
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return fmt.Errorf("slack: %s (status %d): %w", method, resp.StatusCode, err)
+	}
+	if !status.OK {
+		return fmt.Errorf("slack: %s: %s", method, status.Error)
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+func (d *SlackDeliverer) postWebhook(ctx context.Context, del Delivery) error {
+	payload, _ := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("%s\nSaved to `%s`", del.Report.Headline(), del.Path),
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.cfg.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("slack: creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack: posting to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("slack webhook error (status %d): %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
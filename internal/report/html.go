@@ -0,0 +1,234 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// chartBar is one row of a horizontal SVG bar chart.
+type chartBar struct {
+	Label string
+	Value float64
+	Text  string // value label drawn after the bar
+	Color string
+}
+
+const (
+	chartWidth  = 680
+	chartLabelW = 220
+	chartValueW = 80
+	chartRowH   = 24
+)
+
+// barChart renders a horizontal bar chart as inline SVG, so the HTML report
+// needs no scripts or external assets.
+func barChart(title string, bars []chartBar) template.HTML {
+	if len(bars) == 0 {
+		return ""
+	}
+	maxVal := 0.0
+	for _, b := range bars {
+		maxVal = max(maxVal, b.Value)
+	}
+	barArea := float64(chartWidth - chartLabelW - chartValueW)
+	height := len(bars)*chartRowH + 8
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg class="chart" role="img" aria-label="%s" viewBox="0 0 %d %d" width="%d" height="%d" xmlns="http://www.w3.org/2000/svg">`,
+		template.HTMLEscapeString(title), chartWidth, height, chartWidth, height)
+	for i, b := range bars {
+		y := i*chartRowH + 4
+		w := 0.0
+		if maxVal > 0 {
+			w = b.Value / maxVal * barArea
+		}
+		if b.Value > 0 {
+			w = max(w, 2)
+		}
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end" class="label">%s</text>`,
+			chartLabelW-8, y+16, template.HTMLEscapeString(truncate(b.Label, 32)))
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%.1f" height="%d" rx="3" fill="%s"><title>%s: %s</title></rect>`,
+			chartLabelW, y+3, w, chartRowH-8, b.Color, template.HTMLEscapeString(b.Label), template.HTMLEscapeString(b.Text))
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%d" class="value">%s</text>`,
+			float64(chartLabelW)+w+6, y+16, template.HTMLEscapeString(b.Text))
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String())
+}
+
+// rateColor colors an error rate the way the terminal output does.
+func rateColor(rate float64) string {
+	switch {
+	case rate >= 5:
+		return "#dc2626"
+	case rate >= 1:
+		return "#d97706"
+	default:
+		return "#16a34a"
+	}
+}
+
+// errorRateChart plots the error rate of the (at most 15) worst services.
+func (r *Report) errorRateChart() template.HTML {
+	var services []chartBar
+	for _, s := range r.Services {
+		if s.NumCalls == 0 {
+			continue
+		}
+		services = append(services, chartBar{
+			Label: s.Name,
+			Value: s.ErrorRate,
+			Text:  fmt.Sprintf("%.2f%%", s.ErrorRate),
+			Color: rateColor(s.ErrorRate),
+		})
+	}
+	sort.SliceStable(services, func(i, j int) bool {
+		if services[i].Value != services[j].Value {
+			return services[i].Value > services[j].Value
+		}
+		return services[i].Label < services[j].Label
+	})
+	if len(services) > 15 {
+		services = services[:15]
+	}
+	return barChart("Error rate per service", services)
+}
+
+// patternChart plots how often each error pattern occurred.
+func (r *Report) patternChart() template.HTML {
+	var bars []chartBar
+	for _, p := range r.ErrorPatterns {
+		bars = append(bars, chartBar{
+			Label: fmt.Sprintf("[%s] %s", p.Service, p.Pattern),
+			Value: float64(p.Count),
+			Text:  fmt.Sprintf("%dx", p.Count),
+			Color: "#7c3aed",
+		})
+	}
+	return barChart("Error pattern frequency", bars)
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Argus Health Report — {{.R.Instance}} — {{.R.GeneratedAt.Format "2006-01-02 15:04 MST"}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2937; background: #f9fafb; margin: 0; padding: 24px; }
+main { max-width: 760px; margin: 0 auto; background: #fff; border: 1px solid #e5e7eb; border-radius: 8px; padding: 24px 32px; }
+h1 { font-size: 22px; margin: 0 0 4px; }
+h2 { font-size: 16px; margin: 28px 0 10px; padding-bottom: 4px; border-bottom: 1px solid #e5e7eb; }
+.meta { color: #6b7280; font-size: 13px; }
+.cards { display: flex; gap: 12px; flex-wrap: wrap; }
+.card { flex: 1; min-width: 120px; border: 1px solid #e5e7eb; border-radius: 6px; padding: 10px 14px; }
+.card .n { font-size: 20px; font-weight: 600; }
+.card .k { font-size: 12px; color: #6b7280; text-transform: uppercase; letter-spacing: .04em; }
+table { border-collapse: collapse; width: 100%; font-size: 14px; }
+th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #f3f4f6; }
+th { color: #6b7280; font-weight: 600; font-size: 12px; text-transform: uppercase; }
+td.num, th.num { text-align: right; font-variant-numeric: tabular-nums; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 12px; background: #f3f4f6; padding: 1px 4px; border-radius: 3px; word-break: break-all; }
+.ok { color: #16a34a; } .bad { color: #dc2626; }
+.chart { max-width: 100%; height: auto; }
+.chart .label { font-size: 12px; fill: #374151; }
+.chart .value { font-size: 12px; fill: #6b7280; }
+.summary { white-space: pre-wrap; background: #f9fafb; border-left: 3px solid #6366f1; padding: 12px 16px; font-size: 14px; }
+</style>
+</head>
+<body>
+<main>
+<h1>🔭 Argus Health Report</h1>
+<div class="meta">Instance <strong>{{.R.Instance}}</strong> · {{.Window}} · generated {{.R.GeneratedAt.Format "2006-01-02 15:04:05 MST"}}</div>
+{{- with .R.Team}}
+<div class="meta">Team <strong>{{.Team}}</strong>{{if .Escalation}} · escalate to {{.Escalation}}{{end}}{{if .Runbook}} · <a href="{{.Runbook}}">runbook</a>{{end}}</div>
+{{- end}}
+
+<h2>Instance Health</h2>
+<ul>
+{{- range .R.Health}}
+<li>{{if .Healthy}}<span class="ok">● healthy</span>{{else}}<span class="bad">● unhealthy</span>{{end}} <strong>{{.InstanceName}}</strong>{{if .URL}} — {{.URL}}{{end}} (latency {{.Latency}}){{if .Message}} — {{.Message}}{{end}}</li>
+{{- end}}
+</ul>
+
+<h2>Overview</h2>
+<div class="cards">
+<div class="card"><div class="n">{{len .R.Services}}</div><div class="k">Services</div></div>
+<div class="card"><div class="n">{{.R.TotalCalls}}</div><div class="k">Calls</div></div>
+<div class="card"><div class="n">{{.R.TotalErrors}}</div><div class="k">Errors</div></div>
+<div class="card"><div class="n">{{printf "%.2f" .ErrorRate}}%</div><div class="k">Error rate</div></div>
+</div>
+{{- with .R.Changes}}
+
+<h2>Changes Since Last Report</h2>
+<div class="meta">Compared with the report of {{.Since.Format "2006-01-02 15:04 MST"}}</div>
+<ul>
+{{- range $.ChangeLines}}
+<li>{{.}}</li>
+{{- else}}
+<li>No significant changes</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .RateChart}}
+
+<h2>Error Rate per Service</h2>
+{{.RateChart}}
+{{- end}}
+{{- if .R.TopErrors}}
+
+<h2>Top Error Services</h2>
+<table>
+<tr><th>Service</th><th class="num">Errors</th><th class="num">Error rate</th></tr>
+{{- range .R.TopErrors}}
+<tr><td>{{.Service}}</td><td class="num">{{.Errors}}</td><td class="num">{{printf "%.1f" .ErrorRate}}%</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .R.ErrorPatterns}}
+
+<h2>Error Patterns</h2>
+{{.PatternChart}}
+<table>
+<tr><th>Service</th><th class="num">Count</th><th>Pattern</th></tr>
+{{- range .R.ErrorPatterns}}
+<tr><td>{{.Service}}</td><td class="num">{{.Count}}</td><td><code title="{{.Sample}}">{{.Pattern}}</code></td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .R.AISummary}}
+
+<h2>AI Assessment</h2>
+<div class="summary">{{.R.AISummary}}</div>
+{{- end}}
+</main>
+</body>
+</html>
+`))
+
+// RenderHTML writes the report as a single self-contained HTML page with
+// inline CSS and SVG charts, suitable for email and archiving.
+func (r *Report) RenderHTML(w io.Writer) error {
+	var changeLines []string
+	if r.Changes != nil {
+		changeLines = r.Changes.lines()
+	}
+	return htmlTemplate.Execute(w, struct {
+		R            *Report
+		ErrorRate    float64
+		Window       string
+		ChangeLines  []string
+		RateChart    template.HTML
+		PatternChart template.HTML
+	}{
+		R:            r,
+		ErrorRate:    r.errorRate(),
+		Window:       r.windowLabel(),
+		ChangeLines:  changeLines,
+		RateChart:    r.errorRateChart(),
+		PatternChart: r.patternChart(),
+	})
+}
@@ -0,0 +1,150 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/lbarahona/argus/internal/ai"
+	"github.com/lbarahona/argus/internal/ownership"
+)
+
+// SchemaVersion identifies the layout of RenderJSON output. Bump it whenever a
+// field is renamed or removed; adding fields is backwards compatible.
+const SchemaVersion = 1
+
+// JSONReport is the stable, archivable form of a report. Lists are sorted
+// deterministically so two reports can be diffed line by line.
+type JSONReport struct {
+	SchemaVersion int                `json:"schema_version"`
+	GeneratedAt   time.Time          `json:"generated_at"`
+	WindowMinutes int                `json:"window_minutes"`
+	WindowStart   *time.Time         `json:"window_start,omitempty"` // set for scheduled reports
+	WindowEnd     *time.Time         `json:"window_end,omitempty"`
+	Instance      string             `json:"instance"`
+	Team          *ownership.Owner   `json:"team,omitempty"` // set for team-scoped reports
+	Health        []JSONHealth       `json:"health"`
+	Overview      JSONOverview       `json:"overview"`
+	Services      []JSONService      `json:"services"`
+	TopErrors     []JSONServiceError `json:"top_errors"`
+	ErrorPatterns []JSONErrorPattern `json:"error_patterns"`
+	SLOs          []SLOStatus        `json:"slos,omitempty"`
+	Alerts        []AlertStatus      `json:"alerts"`
+	Changes       *Changes           `json:"changes,omitempty"`
+	AISummary     string             `json:"ai_summary,omitempty"`
+	AIAssessment  *ai.Assessment     `json:"ai_assessment,omitempty"` // with --ai
+}
+
+// JSONHealth is the health of one Signoz instance.
+type JSONHealth struct {
+	Instance  string  `json:"instance"`
+	URL       string  `json:"url,omitempty"`
+	Healthy   bool    `json:"healthy"`
+	LatencyMs float64 `json:"latency_ms"`
+	Message   string  `json:"message,omitempty"`
+}
+
+// JSONOverview holds the report totals.
+type JSONOverview struct {
+	Services    int     `json:"services"`
+	TotalCalls  int     `json:"total_calls"`
+	TotalErrors int     `json:"total_errors"`
+	ErrorRate   float64 `json:"error_rate"` // percent
+}
+
+// JSONService is one service's traffic over the window.
+type JSONService struct {
+	Name      string  `json:"name"`
+	Calls     int     `json:"calls"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"` // percent
+	P99Ms     float64 `json:"p99_ms"`
+}
+
+// JSONServiceError is an entry of the top errors table.
+type JSONServiceError struct {
+	Service   string  `json:"service"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// JSONErrorPattern is a group of similar error logs.
+type JSONErrorPattern struct {
+	Service string `json:"service"`
+	Pattern string `json:"pattern"`
+	Count   int    `json:"count"`
+	Sample  string `json:"sample"`
+}
+
+// errorRate is the overall error rate in percent.
+func (r *Report) errorRate() float64 {
+	if r.TotalCalls == 0 {
+		return 0
+	}
+	return float64(r.TotalErrors) / float64(r.TotalCalls) * 100
+}
+
+// JSON converts the report to its stable JSON form.
+func (r *Report) JSON() JSONReport {
+	out := JSONReport{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   r.GeneratedAt,
+		WindowMinutes: r.Duration,
+		Instance:      r.Instance,
+		Team:          r.Team,
+		Health:        []JSONHealth{},
+		Overview: JSONOverview{
+			Services:    len(r.Services),
+			TotalCalls:  r.TotalCalls,
+			TotalErrors: r.TotalErrors,
+			ErrorRate:   r.errorRate(),
+		},
+		Services:      []JSONService{},
+		TopErrors:     []JSONServiceError{},
+		ErrorPatterns: []JSONErrorPattern{},
+		SLOs:          r.SLOs,
+		Alerts:        append([]AlertStatus{}, r.Alerts...),
+		Changes:       r.Changes,
+		AISummary:     r.AISummary,
+		AIAssessment:  r.Assessment,
+	}
+
+	if !r.End.IsZero() {
+		start, end := r.Start, r.End
+		out.WindowStart, out.WindowEnd = &start, &end
+	}
+	for _, h := range r.Health {
+		out.Health = append(out.Health, JSONHealth{
+			Instance:  h.InstanceKey,
+			URL:       h.URL,
+			Healthy:   h.Healthy,
+			LatencyMs: float64(h.Latency) / float64(time.Millisecond),
+			Message:   h.Message,
+		})
+	}
+	for _, s := range r.Services {
+		out.Services = append(out.Services, JSONService{
+			Name:      s.Name,
+			Calls:     s.NumCalls,
+			Errors:    s.NumErrors,
+			ErrorRate: s.ErrorRate,
+			P99Ms:     s.P99Ms(),
+		})
+	}
+	sort.Slice(out.Services, func(i, j int) bool { return out.Services[i].Name < out.Services[j].Name })
+	for _, e := range r.TopErrors {
+		out.TopErrors = append(out.TopErrors, JSONServiceError{Service: e.Service, Errors: e.Errors, ErrorRate: e.ErrorRate})
+	}
+	for _, p := range r.ErrorPatterns {
+		out.ErrorPatterns = append(out.ErrorPatterns, JSONErrorPattern{Service: p.Service, Pattern: p.Pattern, Count: p.Count, Sample: p.Sample})
+	}
+	return out
+}
+
+// RenderJSON writes the report as indented JSON.
+func (r *Report) RenderJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.JSON())
+}
@@ -9,20 +9,32 @@ import (
 	"time"
 
 	"github.com/lbarahona/argus/internal/ai"
+	"github.com/lbarahona/argus/internal/alert"
+	"github.com/lbarahona/argus/internal/logpattern"
+	"github.com/lbarahona/argus/internal/ownership"
+	"github.com/lbarahona/argus/internal/prompt"
 	"github.com/lbarahona/argus/internal/signoz"
+	"github.com/lbarahona/argus/internal/slo"
 	"github.com/lbarahona/argus/pkg/types"
 )
 
 // Report holds all data for a health report.
 type Report struct {
 	GeneratedAt time.Time
-	Duration    int // minutes
+	Duration    int       // minutes
+	Start       time.Time // explicit window, zero for "last Duration minutes"
+	End         time.Time
 	Instance    string
+	Team        *ownership.Owner // set when the report is scoped to one team
 	Health      []types.HealthStatus
 	Services    []types.Service
 	ErrorLogs   []types.LogEntry
 	AllLogs     []types.LogEntry
 	AISummary   string
+	Assessment  *ai.Assessment
+	SLOs        []SLOStatus   // nil when no SLOs are configured
+	Alerts      []AlertStatus // alert rules firing at generation time
+	Changes     *Changes      // nil when there is no previous report
 
 	// Derived
 	TotalErrors   int
@@ -40,18 +52,25 @@ type ServiceError struct {
 
 // ErrorPattern groups similar errors.
 type ErrorPattern struct {
-	Pattern string
+	Pattern string // template, variables masked or <*>
 	Count   int
 	Service string
 	Sample  string
+	Params  []logpattern.Param // sample values of the template's variables
 }
 
 // Options configures report generation.
 type Options struct {
-	Duration     int // minutes
-	WithAI       bool
-	Format       string // "terminal" or "markdown"
-	AnthropicKey string
+	Duration   int // minutes
+	WithAI     bool
+	Format     string          // "terminal", "markdown", "html" or "json"
+	AI         types.AIProfile // model for the AI summary
+	Start, End time.Time       // explicit window; overrides Duration when End is set
+	SLOs       *slo.SLOConfig
+	AlertRules *alert.AlertConfig
+	Persist    bool               // compare with the previous saved report, then save this one
+	Team       string             // scope services, logs, SLOs and alerts to this team
+	Owners     *ownership.Catalog // required when Team is set
 }
 
 // Generate creates a health report from Signoz data.
@@ -60,6 +79,22 @@ func Generate(ctx context.Context, client signoz.SignozQuerier, instKey string,
 		GeneratedAt: time.Now(),
 		Duration:    opts.Duration,
 		Instance:    instKey,
+		Start:       opts.Start,
+		End:         opts.End,
+	}
+	if opts.Team != "" {
+		owner, ok := opts.Owners.Team(opts.Team)
+		if !ok {
+			return nil, fmt.Errorf("unknown team %q (teams are defined under teams: in config)", opts.Team)
+		}
+		r.Team = &owner
+	}
+	owned := func(service string) bool {
+		return r.Team == nil || opts.Owners.Owns(r.Team.Team, service)
+	}
+	ranged := !opts.End.IsZero()
+	if ranged {
+		r.Duration = int(opts.End.Sub(opts.Start) / time.Minute)
 	}
 
 	// Health check
@@ -76,36 +111,113 @@ func Generate(ctx context.Context, client signoz.SignozQuerier, instKey string,
 	r.Health = []types.HealthStatus{status}
 
 	// Services
-	if services, err := client.ListServices(ctx); err == nil {
-		r.Services = services
+	var services []types.Service
+	var err error
+	if ranged {
+		services, err = client.ListServicesRange(ctx, opts.Start, opts.End)
+	} else {
+		services, err = client.ListServices(ctx)
+	}
+	if err == nil {
 		for _, s := range services {
+			if !owned(s.Name) {
+				continue
+			}
+			r.Services = append(r.Services, s)
 			r.TotalCalls += s.NumCalls
 			r.TotalErrors += s.NumErrors
 		}
 	}
 
-	// Error logs
-	if result, err := client.QueryLogs(ctx, "", opts.Duration, 200, "ERROR"); err == nil {
-		r.ErrorLogs = result.Logs
+	queryLogs := func(service string, limit int, severity string) (*types.QueryResult, error) {
+		if ranged {
+			return client.QueryLogsRange(ctx, service, opts.Start, opts.End, limit, severity)
+		}
+		return client.QueryLogs(ctx, service, opts.Duration, limit, severity)
 	}
+	// A team report queries each of its services, so that other teams' logs
+	// can't crowd them out of the limit.
+	collectLogs := func(limit int, severity string) []types.LogEntry {
+		if r.Team == nil {
+			if result, err := queryLogs("", limit, severity); err == nil {
+				return result.Logs
+			}
+			return nil
+		}
+		var logs []types.LogEntry
+		per := max(limit/max(len(r.Services), 1), 10)
+		for _, s := range r.Services {
+			if result, err := queryLogs(s.Name, per, severity); err == nil {
+				logs = append(logs, result.Logs...)
+			}
+		}
+		return logs
+	}
+
+	// Error logs
+	r.ErrorLogs = collectLogs(200, "ERROR")
 
 	// All logs (sample for pattern detection)
-	if result, err := client.QueryLogs(ctx, "", opts.Duration, 50, ""); err == nil {
-		r.AllLogs = result.Logs
-	}
+	r.AllLogs = collectLogs(50, "")
 
 	// Derive top errors by service
 	r.TopErrors = computeTopErrors(r.Services)
 	r.ErrorPatterns = detectPatterns(r.ErrorLogs)
 
-	// AI summary
-	if opts.WithAI && opts.AnthropicKey != "" {
-		summary, err := generateAISummary(r, opts.AnthropicKey)
-		if err == nil {
+	if opts.SLOs != nil {
+		if res, err := slo.NewChecker(client, instKey).CheckAll(ctx, opts.SLOs); err == nil {
+			r.SLOs = []SLOStatus{}
+			for _, s := range res.Results {
+				if !owned(s.SLO.Service) {
+					continue
+				}
+				r.SLOs = append(r.SLOs, SLOStatus{Name: s.SLO.Name, Service: s.SLO.Service, Status: s.Status, Current: s.Current, Target: s.Target})
+			}
+			sort.Slice(r.SLOs, func(i, j int) bool { return r.SLOs[i].Name < r.SLOs[j].Name })
+		}
+	}
+	if opts.AlertRules != nil {
+		if res, err := alert.NewChecker(client, instKey).CheckAll(ctx, opts.AlertRules); err == nil {
+			for _, a := range res.Results {
+				if a.Status != "ok" && owned(a.Service) {
+					r.Alerts = append(r.Alerts, AlertStatus{Rule: a.Rule, Service: a.Service, Status: a.Status, Message: a.Message})
+				}
+			}
+			sort.Slice(r.Alerts, func(i, j int) bool {
+				if r.Alerts[i].Rule != r.Alerts[j].Rule {
+					return r.Alerts[i].Rule < r.Alerts[j].Rule
+				}
+				return r.Alerts[i].Service < r.Alerts[j].Service
+			})
+		}
+	}
+
+	var prev *JSONReport
+	if opts.Persist {
+		// A missing or unreadable history only costs the comparison.
+		prev, _ = LoadPrevious(r.historyKey())
+		if prev != nil {
+			r.Changes = r.compare(prev)
+		}
+	}
+
+	// AI summary, structured for JSON output
+	if opts.WithAI && opts.AI.Ready() {
+		if opts.Format == "json" {
+			if assessment, err := generateAIAssessment(ctx, r, opts.AI); err == nil {
+				r.Assessment = assessment
+			}
+		} else if summary, err := generateAISummary(ctx, r, opts.AI); err == nil {
 			r.AISummary = summary
 		}
 	}
 
+	if opts.Persist {
+		if _, err := SaveHistory(r); err != nil {
+			return r, err
+		}
+	}
+
 	return r, nil
 }
 
@@ -129,52 +241,83 @@ func computeTopErrors(services []types.Service) []ServiceError {
 	return top
 }
 
-func detectPatterns(logs []types.LogEntry) []ErrorPattern {
-	// Group by first 80 chars of body (rough dedup)
-	groups := make(map[string]*ErrorPattern)
+// GroupPatterns clusters log bodies per service into templates (see package
+// logpattern) and returns every group, most frequent first.
+func GroupPatterns(logs []types.LogEntry) []ErrorPattern {
+	miners := make(map[string]*logpattern.Miner)
+	var services []string
 	for _, log := range logs {
-		key := log.Body
-		if len(key) > 80 {
-			key = key[:80]
-		}
-		key = strings.TrimSpace(key)
-		if key == "" {
-			continue
-		}
-		if p, ok := groups[key]; ok {
-			p.Count++
-		} else {
-			groups[key] = &ErrorPattern{
-				Pattern: key,
-				Count:   1,
-				Service: log.ServiceName,
-				Sample:  truncate(log.Body, 200),
-			}
+		m, ok := miners[log.ServiceName]
+		if !ok {
+			m = logpattern.New(logpattern.Options{})
+			miners[log.ServiceName] = m
+			services = append(services, log.ServiceName)
 		}
+		m.Add(log.Body)
 	}
 
 	var patterns []ErrorPattern
-	for _, p := range groups {
-		patterns = append(patterns, *p)
+	for _, svc := range services {
+		for _, c := range miners[svc].Clusters() {
+			patterns = append(patterns, ErrorPattern{
+				Pattern: c.Template(),
+				Count:   c.Count,
+				Service: svc,
+				Sample:  truncate(c.Sample, 200),
+				Params:  c.Params(),
+			})
+		}
 	}
 	sort.Slice(patterns, func(i, j int) bool {
-		return patterns[i].Count > patterns[j].Count
+		if patterns[i].Count != patterns[j].Count {
+			return patterns[i].Count > patterns[j].Count
+		}
+		if patterns[i].Service != patterns[j].Service {
+			return patterns[i].Service < patterns[j].Service
+		}
+		return patterns[i].Pattern < patterns[j].Pattern
 	})
+	return patterns
+}
+
+func detectPatterns(logs []types.LogEntry) []ErrorPattern {
+	patterns := GroupPatterns(logs)
 	if len(patterns) > 10 {
 		patterns = patterns[:10]
 	}
 	return patterns
 }
 
-func generateAISummary(r *Report, apiKey string) (string, error) {
-	prompt := buildSummaryPrompt(r)
-	analyzer := ai.New(apiKey)
-	return analyzer.AnalyzeSync(prompt)
+func generateAISummary(ctx context.Context, r *Report, profile types.AIProfile) (string, error) {
+	analyzer, err := ai.FromProfile(profile)
+	if err != nil {
+		return "", err
+	}
+	prompt := buildSummaryPrompt(r, profile.PromptBudget)
+	return analyzer.AnalyzeSync(ctx, prompt.Text)
+}
+
+func generateAIAssessment(ctx context.Context, r *Report, profile types.AIProfile) (*ai.Assessment, error) {
+	analyzer, err := ai.FromProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	prompt := buildSummaryPrompt(r, profile.PromptBudget)
+	return analyzer.Assess(ctx, prompt.Text)
 }
 
-func buildSummaryPrompt(r *Report) string {
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Generate a concise health report summary for a Signoz instance over the last %d minutes.\n\n", r.Duration))
+// buildSummaryPrompt fits the report into budget estimated tokens (0 for the
+// default), keeping top errors and error patterns over the rest.
+func buildSummaryPrompt(r *Report, budget int) prompt.Result {
+	b := prompt.New(budget)
+	window := fmt.Sprintf("the last %d minutes", r.Duration)
+	if !r.End.IsZero() {
+		window = r.windowLabel()
+	}
+	b.Textf("Generate a concise health report summary for a Signoz instance over %s.\n\n", window)
+	if r.Team != nil {
+		b.Textf("The report covers only the services owned by team %s.\n\n", r.Team.Team)
+	}
 
 	// Health
 	for _, h := range r.Health {
@@ -182,30 +325,38 @@ func buildSummaryPrompt(r *Report) string {
 		if !h.Healthy {
 			status = "unhealthy: " + h.Message
 		}
-		sb.WriteString(fmt.Sprintf("Instance %s (%s): %s, latency %s\n", h.InstanceKey, h.URL, status, h.Latency))
+		b.Textf("Instance %s (%s): %s, latency %s\n", h.InstanceKey, h.URL, status, h.Latency)
 	}
 
 	// Services summary
-	sb.WriteString(fmt.Sprintf("\nTotal services: %d, Total calls: %d, Total errors: %d\n", len(r.Services), r.TotalCalls, r.TotalErrors))
+	b.Textf("\nTotal services: %d, Total calls: %d, Total errors: %d\n", len(r.Services), r.TotalCalls, r.TotalErrors)
 
 	// Top errors
 	if len(r.TopErrors) > 0 {
-		sb.WriteString("\nTop error services:\n")
+		section := b.Section("Top error services:", prompt.High)
 		for _, e := range r.TopErrors {
-			sb.WriteString(fmt.Sprintf("- %s: %d errors (%.1f%% error rate)\n", e.Service, e.Errors, e.ErrorRate))
+			section.Addf("- %s: %d errors (%.1f%% error rate)", e.Service, e.Errors, e.ErrorRate)
 		}
 	}
 
 	// Error patterns
 	if len(r.ErrorPatterns) > 0 {
-		sb.WriteString("\nTop error patterns:\n")
+		section := b.Section("Top error patterns:", prompt.High)
 		for _, p := range r.ErrorPatterns {
-			sb.WriteString(fmt.Sprintf("- [%s] (%dx): %s\n", p.Service, p.Count, p.Sample))
+			section.Addf("- [%s] (%dx): %s", p.Service, p.Count, p.Sample)
 		}
 	}
 
-	sb.WriteString("\nProvide:\n1. Overall health assessment (1-2 sentences)\n2. Key issues requiring attention (bullet points)\n3. Recommended actions (bullet points)\n\nKeep it brief and actionable.")
-	return sb.String()
+	// Changes since the previous report
+	if r.Changes != nil && !r.Changes.Empty() {
+		section := b.Section(fmt.Sprintf("Changes since the previous report (%s):", r.Changes.Since.Format("2006-01-02 15:04 MST")), prompt.Normal)
+		for _, l := range r.Changes.lines() {
+			section.Add("- " + l)
+		}
+	}
+
+	b.Text("\nProvide:\n1. Overall health assessment (1-2 sentences)\n2. Key issues requiring attention (bullet points)\n3. Recommended actions (bullet points)\n\nKeep it brief and actionable.")
+	return b.Build()
 }
 
 // RenderTerminal outputs the report to a terminal writer.
@@ -213,8 +364,18 @@ func (r *Report) RenderTerminal(w io.Writer) {
 	fmt.Fprintf(w, "\n🔭 ARGUS HEALTH REPORT\n")
 	fmt.Fprintf(w, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 	fmt.Fprintf(w, "  Generated: %s\n", r.GeneratedAt.Format("2006-01-02 15:04:05 MST"))
-	fmt.Fprintf(w, "  Window:    Last %d minutes\n", r.Duration)
-	fmt.Fprintf(w, "  Instance:  %s\n\n", r.Instance)
+	fmt.Fprintf(w, "  Window:    %s\n", r.windowLabel())
+	fmt.Fprintf(w, "  Instance:  %s\n", r.Instance)
+	if r.Team != nil {
+		fmt.Fprintf(w, "  Team:      %s\n", r.Team.Team)
+		if r.Team.Escalation != "" {
+			fmt.Fprintf(w, "  Escalate:  %s\n", r.Team.Escalation)
+		}
+		if r.Team.Runbook != "" {
+			fmt.Fprintf(w, "  Runbook:   %s\n", r.Team.Runbook)
+		}
+	}
+	fmt.Fprintln(w)
 
 	// Health
 	for _, h := range r.Health {
@@ -237,6 +398,23 @@ func (r *Report) RenderTerminal(w io.Writer) {
 	}
 	fmt.Fprintf(w, "  └─ Error Rate:   %.2f%%\n\n", errRate)
 
+	// Changes since last report
+	if r.Changes != nil {
+		fmt.Fprintf(w, "  🔄 Changes since last report (%s)\n", r.Changes.Since.Format("2006-01-02 15:04 MST"))
+		lines := r.Changes.lines()
+		if len(lines) == 0 {
+			lines = []string{"No significant changes"}
+		}
+		for i, l := range lines {
+			connector := "├─"
+			if i == len(lines)-1 {
+				connector = "└─"
+			}
+			fmt.Fprintf(w, "  %s %s\n", connector, l)
+		}
+		fmt.Fprintln(w)
+	}
+
 	// Top errors
 	if len(r.TopErrors) > 0 {
 		fmt.Fprintf(w, "  🚨 Top Error Services\n")
@@ -276,8 +454,19 @@ func (r *Report) RenderTerminal(w io.Writer) {
 func (r *Report) RenderMarkdown(w io.Writer) {
 	fmt.Fprintf(w, "# 🔭 Argus Health Report\n\n")
 	fmt.Fprintf(w, "**Generated:** %s  \n", r.GeneratedAt.Format("2006-01-02 15:04:05 MST"))
-	fmt.Fprintf(w, "**Window:** Last %d minutes  \n", r.Duration)
-	fmt.Fprintf(w, "**Instance:** %s\n\n", r.Instance)
+	fmt.Fprintf(w, "**Window:** %s  \n", r.windowLabel())
+	fmt.Fprintf(w, "**Instance:** %s\n", r.Instance)
+	if r.Team != nil {
+		fmt.Fprintf(w, "  \n**Team:** %s", r.Team.Team)
+		if r.Team.Escalation != "" {
+			fmt.Fprintf(w, "  \n**Escalation:** %s", r.Team.Escalation)
+		}
+		if r.Team.Runbook != "" {
+			fmt.Fprintf(w, "  \n**Runbook:** %s", r.Team.Runbook)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w)
 
 	// Health
 	fmt.Fprintf(w, "## Instance Health\n\n")
@@ -302,6 +491,19 @@ func (r *Report) RenderMarkdown(w io.Writer) {
 	fmt.Fprintf(w, "| Total Errors | %d |\n", r.TotalErrors)
 	fmt.Fprintf(w, "| Error Rate | %.2f%% |\n\n", errRate)
 
+	// Changes since last report
+	if r.Changes != nil {
+		fmt.Fprintf(w, "## Changes Since Last Report\n\n_Compared with the report of %s._\n\n", r.Changes.Since.Format("2006-01-02 15:04 MST"))
+		lines := r.Changes.lines()
+		if len(lines) == 0 {
+			lines = []string{"No significant changes"}
+		}
+		for _, l := range lines {
+			fmt.Fprintf(w, "- %s\n", l)
+		}
+		fmt.Fprintln(w)
+	}
+
 	// Top errors
 	if len(r.TopErrors) > 0 {
 		fmt.Fprintf(w, "## Top Error Services\n\n")
@@ -327,6 +529,18 @@ func (r *Report) RenderMarkdown(w io.Writer) {
 	}
 }
 
+// windowLabel describes the time window the report covers.
+func (r *Report) windowLabel() string {
+	if r.End.IsZero() {
+		return fmt.Sprintf("Last %d minutes", r.Duration)
+	}
+	layout := "2006-01-02 15:04"
+	if r.Start.YearDay() == r.End.YearDay() && r.Start.Year() == r.End.Year() {
+		return fmt.Sprintf("%s → %s (%d minutes)", r.Start.Format(layout), r.End.Format("15:04 MST"), r.Duration)
+	}
+	return fmt.Sprintf("%s → %s (%d minutes)", r.Start.Format(layout), r.End.Format(layout+" MST"), r.Duration)
+}
+
 func truncate(s string, n int) string {
 	if len(s) > n {
 		return s[:n] + "..."
@@ -3,9 +3,14 @@ package report
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/lbarahona/argus/internal/ownership"
 	"github.com/lbarahona/argus/pkg/types"
 )
 
@@ -48,6 +53,18 @@ func (m *mockSignozClient) QueryMetrics(ctx context.Context, metricName string,
 	return &types.QueryResult{}, nil
 }
 
+func (m *mockSignozClient) ListServicesRange(ctx context.Context, start, end time.Time) ([]types.Service, error) {
+	return m.ListServices(ctx)
+}
+
+func (m *mockSignozClient) QueryLogsRange(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+	return &types.QueryResult{}, nil
+}
+
+func (m *mockSignozClient) QueryTracesRange(ctx context.Context, service string, start, end time.Time, limit int) (*types.QueryResult, error) {
+	return &types.QueryResult{}, nil
+}
+
 // ──────────────────────────────────────────────
 // Generate Tests (mock-based)
 // ──────────────────────────────────────────────
@@ -171,6 +188,25 @@ func TestRenderTerminal(t *testing.T) {
 	}
 }
 
+func TestBuildSummaryPromptBudget(t *testing.T) {
+	r := &Report{Duration: 60, TotalCalls: 1000, TotalErrors: 80}
+	r.TopErrors = []ServiceError{{Service: "checkout", Errors: 80, ErrorRate: 8}}
+	for i := 0; i < 50; i++ {
+		r.ErrorPatterns = append(r.ErrorPatterns, ErrorPattern{Service: "checkout", Count: 50 - i, Sample: strings.Repeat("payment gateway timed out ", 4)})
+	}
+
+	p := buildSummaryPrompt(r, 500)
+	if p.Tokens > 500 {
+		t.Errorf("prompt of %d tokens exceeds the budget", p.Tokens)
+	}
+	if !strings.Contains(p.Text, "- checkout: 80 errors (8.0% error rate)") || !strings.Contains(p.Text, "Keep it brief") {
+		t.Errorf("top errors and instructions should be kept:\n%s", p.Text)
+	}
+	if len(p.Dropped) != 1 || p.Dropped[0].Section != "Top error patterns" || p.Dropped[0].Total != 50 {
+		t.Errorf("expected error patterns to be trimmed, got %+v", p.Dropped)
+	}
+}
+
 func TestRenderMarkdown(t *testing.T) {
 	r := &Report{
 		GeneratedAt: time.Now(),
@@ -200,3 +236,184 @@ func TestTruncate(t *testing.T) {
 		t.Errorf("got %q", truncate("hello world this is long", 10))
 	}
 }
+
+func TestDetectPatternsMasksVariables(t *testing.T) {
+	logs := []types.LogEntry{
+		{Body: "order 12345 failed for user 550e8400-e29b-41d4-a716-446655440000", ServiceName: "checkout"},
+		{Body: "order 999 failed for user 123e4567-e89b-12d3-a456-426614174000", ServiceName: "checkout"},
+		{Body: "order 999 failed for user 123e4567-e89b-12d3-a456-426614174000", ServiceName: "billing"},
+	}
+
+	patterns := detectPatterns(logs)
+	if len(patterns) != 2 {
+		t.Fatalf("expected 2 patterns (one per service), got %+v", patterns)
+	}
+	if patterns[0].Service != "checkout" || patterns[0].Count != 2 {
+		t.Errorf("expected checkout pattern with 2 occurrences first, got %+v", patterns[0])
+	}
+	if patterns[0].Pattern != "order <n> failed for user <uuid>" {
+		t.Errorf("unexpected normalized pattern %q", patterns[0].Pattern)
+	}
+}
+
+// ──────────────────────────────────────────────
+// HTML / JSON Tests
+// ──────────────────────────────────────────────
+
+func sampleReport() *Report {
+	return &Report{
+		GeneratedAt: time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+		Duration:    60,
+		Instance:    "production",
+		Health: []types.HealthStatus{
+			{InstanceName: "production", InstanceKey: "production", Healthy: true, Latency: 40 * time.Millisecond},
+		},
+		Services: []types.Service{
+			{Name: "web", NumCalls: 500, NumErrors: 0},
+			{Name: "api", NumCalls: 1000, NumErrors: 50, ErrorRate: 5.0, P99: 250e6},
+		},
+		TotalCalls:    1500,
+		TotalErrors:   50,
+		TopErrors:     []ServiceError{{Service: "api", Errors: 50, ErrorRate: 5.0}},
+		ErrorPatterns: []ErrorPattern{{Service: "api", Pattern: "dial tcp <n>: <script>", Count: 12, Sample: "dial tcp 5432"}},
+		AISummary:     "All good.\n- watch api",
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sampleReport().RenderHTML(&buf); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"<!DOCTYPE html>", "<style>", "<svg", "Error rate per service", "api", "5.00%", "12x", "All good."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("html missing %q", want)
+		}
+	}
+	if strings.Contains(out, "<script") || strings.Contains(out, "http-equiv") || strings.Contains(out, `src="http`) {
+		t.Error("html report should be self-contained and script-free")
+	}
+	if !strings.Contains(out, "&lt;script&gt;") {
+		t.Error("log content should be escaped")
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sampleReport().RenderJSON(&buf); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	for _, key := range []string{"schema_version", "generated_at", "window_minutes", "instance", "health", "overview", "services", "top_errors", "error_patterns", "ai_summary"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("json missing %q", key)
+		}
+	}
+
+	j := sampleReport().JSON()
+	if j.SchemaVersion != SchemaVersion || j.Services[0].Name != "api" || j.Services[0].P99Ms != 250 {
+		t.Errorf("services should be sorted by name with p99 in ms, got %+v", j.Services)
+	}
+	if j.Overview.ErrorRate < 3.33 || j.Overview.ErrorRate > 3.34 {
+		t.Errorf("unexpected overall error rate %v", j.Overview.ErrorRate)
+	}
+
+	empty, _ := json.Marshal((&Report{}).JSON())
+	if !strings.Contains(string(empty), `"services":[]`) {
+		t.Errorf("empty lists should encode as [] not null: %s", empty)
+	}
+}
+
+func TestGenerateJSONWithAIAssessment(t *testing.T) {
+	answer := `{"severity":"medium","summary":"api errors are elevated","root_causes":[],"affected_services":["api"],` +
+		`"evidence":[{"type":"log","service":"api","timestamp":"10:00:00","detail":"connection refused"}],"recommended_actions":[]}`
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct{ Content string } `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt = req.Messages[0].Content
+		text, _ := json.Marshal(answer)
+		w.Write([]byte(`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":` + string(text) + "}}\n\n"))
+	}))
+	defer server.Close()
+
+	mock := &mockSignozClient{listServicesFunc: func(ctx context.Context) ([]types.Service, error) {
+		return []types.Service{{Name: "api", NumCalls: 100, NumErrors: 20, ErrorRate: 20}}, nil
+	}}
+	r, err := Generate(context.Background(), mock, "prod", Options{Duration: 60, Format: "json", WithAI: true, AI: types.AIProfile{APIKey: "k", BaseURL: server.URL}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(prompt, "Answer Format") {
+		t.Errorf("expected a structured request, got:\n%s", prompt)
+	}
+	if r.AISummary != "" || r.Assessment == nil || r.Assessment.Severity != "medium" {
+		t.Fatalf("expected an assessment instead of a summary, got %q %+v", r.AISummary, r.Assessment)
+	}
+	out, _ := json.Marshal(r.JSON())
+	if !strings.Contains(string(out), `"ai_assessment":{"severity":"medium"`) {
+		t.Errorf("json should include the assessment: %s", out)
+	}
+}
+
+func TestGenerateForTeam(t *testing.T) {
+	withTempHome(t)
+	owners, err := ownership.New(map[string]types.Team{
+		"payments": {Services: []string{"payment-*"}, Escalation: "#payments-oncall", Runbook: "https://wiki/payments"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queried []string
+	mock := &mockSignozClient{
+		listServicesFunc: func(ctx context.Context) ([]types.Service, error) {
+			return []types.Service{
+				{Name: "payment-api", NumCalls: 100, NumErrors: 5, ErrorRate: 5},
+				{Name: "search", NumCalls: 900, NumErrors: 90, ErrorRate: 10},
+			}, nil
+		},
+		queryLogsFunc: func(ctx context.Context, service string, d, limit int, sev string) (*types.QueryResult, error) {
+			queried = append(queried, service)
+			return &types.QueryResult{Logs: []types.LogEntry{{ServiceName: service, Body: "card declined"}}}, nil
+		},
+	}
+
+	r, err := Generate(context.Background(), mock, "prod", Options{Duration: 60, Team: "payments", Owners: owners, Persist: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Services) != 1 || r.TotalCalls != 100 || r.TopErrors[0].Service != "payment-api" {
+		t.Errorf("report should only cover payment services, got %+v", r.Services)
+	}
+	for _, s := range queried {
+		if s != "payment-api" {
+			t.Errorf("queried logs for %q", s)
+		}
+	}
+
+	var buf bytes.Buffer
+	r.RenderTerminal(&buf)
+	if !strings.Contains(buf.String(), "Team:      payments") || !strings.Contains(buf.String(), "#payments-oncall") {
+		t.Errorf("terminal output missing owner metadata:\n%s", buf.String())
+	}
+	if j := r.JSON(); j.Team == nil || j.Team.Runbook != "https://wiki/payments" {
+		t.Errorf("json missing team: %+v", j.Team)
+	}
+	if prev, _ := LoadPrevious("prod"); prev != nil {
+		t.Error("team reports should have their own history")
+	}
+	if prev, _ := LoadPrevious(r.historyKey()); prev == nil {
+		t.Error("expected the team report in history")
+	}
+
+	if _, err := Generate(context.Background(), mock, "prod", Options{Team: "search", Owners: owners}); err == nil {
+		t.Error("expected an error for an unknown team")
+	}
+}
@@ -0,0 +1,395 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lbarahona/argus/internal/cron"
+	"github.com/lbarahona/argus/internal/ownership"
+	"github.com/lbarahona/argus/internal/signoz"
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+const (
+	defaultKeep = 30
+	// maxWindow caps how far back a scheduled report reaches after a long
+	// outage of the scheduler, so one report never queries weeks of data.
+	maxWindow = 7 * 24 * time.Hour
+)
+
+// ConnectFunc resolves an instance name ("" for the default) to a client and
+// its instance key.
+type ConnectFunc func(instance string) (signoz.SignozQuerier, string, error)
+
+// Scheduler generates the reports configured under reports.schedules, writes
+// them to the output directory and delivers them.
+type Scheduler struct {
+	cfg        types.ReportsConfig
+	owners     *ownership.Catalog
+	connect    ConnectFunc
+	out        io.Writer
+	jobs       []*job
+	deliverers map[string]Deliverer
+	now        func() time.Time
+}
+
+type job struct {
+	types.ReportSchedule
+	cron *cron.Schedule
+	loc  *time.Location
+	ai   types.AIProfile
+}
+
+// NewScheduler validates the report schedules in cfg.
+func NewScheduler(cfg *types.Config, connect ConnectFunc, out io.Writer) (*Scheduler, error) {
+	if cfg.Reports == nil || len(cfg.Reports.Schedules) == 0 {
+		return nil, fmt.Errorf("no report schedules configured (add reports.schedules to ~/.argus/config.yaml)")
+	}
+	s := &Scheduler{
+		cfg:        *cfg.Reports,
+		connect:    connect,
+		out:        out,
+		deliverers: make(map[string]Deliverer),
+		now:        time.Now,
+	}
+	owners, err := ownership.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.owners = owners
+	if s.cfg.Keep <= 0 {
+		s.cfg.Keep = defaultKeep
+	}
+	if s.cfg.OutputDir == "" {
+		home, _ := os.UserHomeDir()
+		s.cfg.OutputDir = filepath.Join(home, ".argus", "reports")
+	} else if strings.HasPrefix(s.cfg.OutputDir, "~/") {
+		home, _ := os.UserHomeDir()
+		s.cfg.OutputDir = filepath.Join(home, s.cfg.OutputDir[2:])
+	}
+	if s.cfg.SMTP != nil {
+		s.deliverers["smtp"] = NewSMTPDeliverer(*s.cfg.SMTP)
+	}
+	if s.cfg.Slack != nil {
+		s.deliverers["slack"] = NewSlackDeliverer(*s.cfg.Slack)
+	}
+
+	seen := make(map[string]bool)
+	for _, sc := range s.cfg.Schedules {
+		if sc.Name == "" {
+			return nil, fmt.Errorf("report schedule %q needs a name", sc.Cron)
+		}
+		if seen[sc.Name] {
+			return nil, fmt.Errorf("duplicate report schedule %q", sc.Name)
+		}
+		seen[sc.Name] = true
+
+		c, err := cron.Parse(sc.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", sc.Name, err)
+		}
+		loc := time.Local
+		if sc.Timezone != "" {
+			if loc, err = time.LoadLocation(sc.Timezone); err != nil {
+				return nil, fmt.Errorf("schedule %s: %w", sc.Name, err)
+			}
+		}
+		if sc.Format == "" {
+			sc.Format = "html"
+		}
+		if _, ok := extensions[sc.Format]; !ok {
+			return nil, fmt.Errorf("schedule %s: unknown format %q", sc.Name, sc.Format)
+		}
+		if _, ok := owners.Team(sc.Team); sc.Team != "" && !ok {
+			return nil, fmt.Errorf("schedule %s: unknown team %q", sc.Name, sc.Team)
+		}
+		for _, d := range sc.Deliver {
+			if _, ok := s.deliverers[d]; !ok {
+				return nil, fmt.Errorf("schedule %s: delivery %q is not configured (reports.%s)", sc.Name, d, d)
+			}
+		}
+		j := &job{ReportSchedule: sc, cron: c, loc: loc}
+		if sc.AI {
+			if j.ai, err = cfg.ResolveAIProfile(sc.AIProfile); err != nil {
+				return nil, fmt.Errorf("schedule %s: %w", sc.Name, err)
+			}
+		}
+		s.jobs = append(s.jobs, j)
+	}
+	return s, nil
+}
+
+var extensions = map[string]string{
+	"html":     ".html",
+	"json":     ".json",
+	"markdown": ".md",
+	"terminal": ".txt",
+}
+
+// Upcoming is the next run of a schedule.
+type Upcoming struct {
+	Name string
+	Cron string
+	Next time.Time
+}
+
+// Upcoming lists the next run of every schedule, soonest first.
+func (s *Scheduler) Upcoming() []Upcoming {
+	now := s.now()
+	var out []Upcoming
+	for _, j := range s.jobs {
+		out = append(out, Upcoming{Name: j.Name, Cron: j.Cron, Next: j.cron.Next(now.In(j.loc))})
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Next.Before(out[k].Next) })
+	return out
+}
+
+// Run fires schedules as they come due. Blocks until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		now := s.now()
+		var next time.Time
+		var due []*job
+		for _, j := range s.jobs {
+			t := j.cron.Next(now.In(j.loc))
+			if t.IsZero() {
+				continue
+			}
+			switch {
+			case next.IsZero() || t.Before(next):
+				next, due = t, []*job{j}
+			case t.Equal(next):
+				due = append(due, j)
+			}
+		}
+		if next.IsZero() {
+			return fmt.Errorf("no schedule will ever fire")
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		for _, j := range due {
+			s.run(ctx, j, next)
+		}
+	}
+}
+
+// RunNow generates the named schedule (or every schedule when name is "")
+// immediately, continuing the tiling from the previous report.
+func (s *Scheduler) RunNow(ctx context.Context, name string) error {
+	at := s.now().Truncate(time.Minute)
+	ran := false
+	var firstErr error
+	for _, j := range s.jobs {
+		if name != "" && j.Name != name {
+			continue
+		}
+		ran = true
+		if _, err := s.run(ctx, j, at); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if !ran {
+		return fmt.Errorf("no report schedule named %q", name)
+	}
+	return firstErr
+}
+
+// run generates, stores and delivers one report ending at `at`, logging the
+// outcome. Failures are returned but never stop the scheduler.
+func (s *Scheduler) run(ctx context.Context, j *job, at time.Time) (string, error) {
+	path, err := s.generate(ctx, j, at)
+	if err != nil {
+		fmt.Fprintf(s.out, "⚠️  %s: %v\n", j.Name, err)
+		return "", err
+	}
+	fmt.Fprintf(s.out, "📄 %s: wrote %s\n", j.Name, path)
+	return path, nil
+}
+
+func (s *Scheduler) generate(ctx context.Context, j *job, at time.Time) (string, error) {
+	st := loadScheduleState(j.Name)
+	start, end := s.window(j, st, at)
+
+	client, instKey, err := s.connect(j.Instance)
+	if err != nil {
+		return "", err
+	}
+	slos, rules := LoadChecks()
+	r, err := Generate(ctx, client, instKey, Options{
+		WithAI:     j.AI,
+		Format:     j.Format,
+		AI:         j.ai,
+		Start:      start,
+		End:        end,
+		SLOs:       slos,
+		AlertRules: rules,
+		Persist:    true,
+		Team:       j.Team,
+		Owners:     s.owners,
+	})
+	if err != nil {
+		return "", fmt.Errorf("generating report: %w", err)
+	}
+
+	path, err := s.write(j, instKey, r)
+	if err != nil {
+		return "", err
+	}
+
+	// Record the window before delivering: the report exists on disk either
+	// way, and the next one must start where this one ended.
+	if err := saveScheduleState(j.Name, &scheduleState{LastStart: start, LastEnd: end, LastPath: path}); err != nil {
+		fmt.Fprintf(s.out, "⚠️  %s: %v\n", j.Name, err)
+	}
+
+	var failed []string
+	for _, name := range j.Deliver {
+		if err := s.deliverers[name].Deliver(ctx, Delivery{Schedule: j.Name, Path: path, Report: r}); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return path, fmt.Errorf("wrote %s but delivery failed: %s", path, strings.Join(failed, "; "))
+	}
+	return path, nil
+}
+
+// window makes consecutive reports tile: each starts where the previous one
+// ended. The first report of a schedule covers the interval since the
+// schedule's previous slot.
+func (s *Scheduler) window(j *job, st *scheduleState, at time.Time) (time.Time, time.Time) {
+	end := at
+	start := st.LastEnd
+	if start.IsZero() || !start.Before(end) {
+		start = j.cron.Prev(end.In(j.loc).Add(-time.Minute))
+		if start.IsZero() {
+			start = end.Add(-time.Hour)
+		}
+	}
+	if end.Sub(start) > maxWindow {
+		fmt.Fprintf(s.out, "⚠️  %s: previous report ended %s, limiting window to %s\n", j.Name, start.Format(time.RFC3339), maxWindow)
+		start = end.Add(-maxWindow)
+	}
+	return start, end
+}
+
+// write renders the report into <output_dir>/<schedule>/ and removes the
+// oldest reports beyond the configured count.
+func (s *Scheduler) write(j *job, instKey string, r *Report) (string, error) {
+	dir := filepath.Join(s.cfg.OutputDir, sanitize(j.Name))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("creating %s: %w", dir, err)
+	}
+	name := fmt.Sprintf("%s-%s-%s%s", sanitize(j.Name), sanitize(instKey), r.End.UTC().Format("20060102-1504"), extensions[j.Format])
+	path := filepath.Join(dir, name)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", fmt.Errorf("creating report file: %w", err)
+	}
+	switch j.Format {
+	case "json":
+		err = r.RenderJSON(f)
+	case "markdown":
+		r.RenderMarkdown(f)
+	case "terminal":
+		r.RenderTerminal(f)
+	default:
+		err = r.RenderHTML(f)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	if err := rotate(dir, sanitize(j.Name)+"-", s.cfg.Keep); err != nil {
+		fmt.Fprintf(s.out, "⚠️  %s: rotating reports: %v\n", j.Name, err)
+	}
+	return path, nil
+}
+
+// rotate keeps the newest `keep` files in dir starting with prefix. File
+// names embed a UTC timestamp, so lexical order is chronological.
+func rotate(dir, prefix string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), prefix) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	for len(names) > keep {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// scheduleState remembers the last report of a schedule, so the next one can
+// pick up where it ended.
+type scheduleState struct {
+	LastStart time.Time `json:"last_start"`
+	LastEnd   time.Time `json:"last_end"`
+	LastPath  string    `json:"last_path"`
+}
+
+func scheduleStatePath(name string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".argus", "state", "report-"+sanitize(name)+".json")
+}
+
+// loadScheduleState returns the saved state, or an empty one if there is
+// none or it can't be read.
+func loadScheduleState(name string) *scheduleState {
+	st := &scheduleState{}
+	if data, err := os.ReadFile(scheduleStatePath(name)); err == nil {
+		json.Unmarshal(data, st)
+	}
+	return st
+}
+
+func saveScheduleState(name string, st *scheduleState) error {
+	path := scheduleStatePath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("creating state dir: %w", err)
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling report state: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing report state: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
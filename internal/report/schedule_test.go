@@ -0,0 +1,225 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbarahona/argus/internal/signoz"
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+func withTempHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+	t.Cleanup(func() { os.Setenv("HOME", origHome) })
+	return home
+}
+
+func newTestScheduler(t *testing.T, reports types.ReportsConfig) *Scheduler {
+	t.Helper()
+	mock := &mockSignozClient{
+		listServicesFunc: func(ctx context.Context) ([]types.Service, error) {
+			return []types.Service{{Name: "api", NumCalls: 100, NumErrors: 5, ErrorRate: 5}}, nil
+		},
+	}
+	connect := func(instance string) (signoz.SignozQuerier, string, error) {
+		return mock, "prod", nil
+	}
+	s, err := NewScheduler(&types.Config{Reports: &reports}, connect, io.Discard)
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+	return s
+}
+
+func TestNewSchedulerValidates(t *testing.T) {
+	connect := func(string) (signoz.SignozQuerier, string, error) { return nil, "", nil }
+	for _, reports := range []*types.ReportsConfig{
+		nil,
+		{Schedules: []types.ReportSchedule{{Name: "a", Cron: "bogus"}}},
+		{Schedules: []types.ReportSchedule{{Name: "a", Cron: "@daily", Format: "pdf"}}},
+		{Schedules: []types.ReportSchedule{{Name: "a", Cron: "@daily", Deliver: []string{"smtp"}}}},
+		{Schedules: []types.ReportSchedule{{Name: "a", Cron: "@daily"}, {Name: "a", Cron: "@hourly"}}},
+	} {
+		if _, err := NewScheduler(&types.Config{Reports: reports}, connect, io.Discard); err == nil {
+			t.Errorf("expected error for %+v", reports)
+		}
+	}
+}
+
+func TestScheduledReportsTile(t *testing.T) {
+	withTempHome(t)
+	dir := t.TempDir()
+	s := newTestScheduler(t, types.ReportsConfig{
+		OutputDir: dir,
+		Schedules: []types.ReportSchedule{{Name: "shift", Cron: "0 7,19 * * *", Timezone: "UTC", Format: "json"}},
+	})
+	j := s.jobs[0]
+
+	first := time.Date(2026, 3, 1, 19, 0, 0, 0, time.UTC)
+	path, err := s.generate(context.Background(), j, first)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if filepath.Base(path) != "shift-prod-20260301-1900.json" {
+		t.Errorf("unexpected report path %s", path)
+	}
+	var got JSONReport
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid report: %v", err)
+	}
+	if !got.WindowStart.Equal(first.Add(-12*time.Hour)) || !got.WindowEnd.Equal(first) || got.WindowMinutes != 720 {
+		t.Errorf("first report should cover the previous slot, got %v → %v", got.WindowStart, got.WindowEnd)
+	}
+
+	// A late, manual run continues exactly where the last report ended.
+	second := first.Add(3*time.Hour + 17*time.Minute)
+	path, err = s.generate(context.Background(), j, second)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	json.Unmarshal(data, &got)
+	if !got.WindowStart.Equal(first) || !got.WindowEnd.Equal(second) {
+		t.Errorf("second report should start at %v, got %v → %v", first, got.WindowStart, got.WindowEnd)
+	}
+}
+
+func TestScheduledReportsRotate(t *testing.T) {
+	withTempHome(t)
+	dir := t.TempDir()
+	s := newTestScheduler(t, types.ReportsConfig{
+		OutputDir: dir,
+		Keep:      2,
+		Schedules: []types.ReportSchedule{{Name: "hourly", Cron: "@hourly", Timezone: "UTC"}},
+	})
+
+	at := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		if _, err := s.generate(context.Background(), s.jobs[0], at.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "hourly"))
+	if len(entries) != 2 || entries[0].Name() != "hourly-prod-20260301-0200.html" {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("expected the 2 newest reports, got %v", names)
+	}
+}
+
+func TestSlackUpload(t *testing.T) {
+	var calls []string
+	var uploaded []byte
+	var complete map[string]interface{}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.URL.Path)
+		switch r.URL.Path {
+		case "/api/files.getUploadURLExternal":
+			if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+				t.Errorf("missing bot token")
+			}
+			r.ParseForm()
+			if r.Form.Get("filename") != "report.html" {
+				t.Errorf("unexpected filename %q", r.Form.Get("filename"))
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "upload_url": srv.URL + "/upload", "file_id": "F123"})
+		case "/upload":
+			uploaded, _ = io.ReadAll(r.Body)
+		case "/api/files.completeUploadExternal":
+			json.NewDecoder(r.Body).Decode(&complete)
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": true})
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "report.html")
+	os.WriteFile(path, []byte("<html>report</html>"), 0600)
+
+	d := NewSlackDeliverer(types.SlackConfig{Token: "xoxb-test", Channel: "C42"})
+	d.apiURL = srv.URL + "/api"
+	if err := d.Deliver(context.Background(), Delivery{Path: path, Report: sampleReport()}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if len(calls) != 3 || string(uploaded) != "<html>report</html>" {
+		t.Errorf("unexpected upload flow %v (%q)", calls, uploaded)
+	}
+	if complete["channel_id"] != "C42" || !strings.Contains(complete["initial_comment"].(string), "production") {
+		t.Errorf("unexpected completion payload %+v", complete)
+	}
+}
+
+func TestSlackErrorsSurface(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":false,"error":"not_in_channel"}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "report.html")
+	os.WriteFile(path, []byte("x"), 0600)
+	d := NewSlackDeliverer(types.SlackConfig{Token: "t", Channel: "C1"})
+	d.apiURL = srv.URL
+	if err := d.Deliver(context.Background(), Delivery{Path: path, Report: sampleReport()}); err == nil || !strings.Contains(err.Error(), "not_in_channel") {
+		t.Errorf("expected slack error, got %v", err)
+	}
+}
+
+func TestSMTPMessage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shift-prod.html")
+	os.WriteFile(path, []byte("<html>report</html>"), 0600)
+
+	var sent []byte
+	var to []string
+	d := NewSMTPDeliverer(types.SMTPConfig{Host: "mail.example.com", From: "argus@example.com", To: []string{"oncall@example.com"}})
+	d.send = func(addr string, a smtp.Auth, from string, rcpt []string, msg []byte) error {
+		if addr != "mail.example.com:587" {
+			t.Errorf("unexpected addr %s", addr)
+		}
+		sent, to = msg, rcpt
+		return nil
+	}
+	if err := d.Deliver(context.Background(), Delivery{Path: path, Report: sampleReport()}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if len(to) != 1 {
+		t.Errorf("unexpected recipients %v", to)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(sent))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if !strings.Contains(subject, "Argus report production") {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	_, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	mr.NextPart() // text body
+	att, err := mr.NextPart()
+	if err != nil {
+		t.Fatalf("missing attachment: %v", err)
+	}
+	if att.FileName() != "shift-prod.html" {
+		t.Errorf("unexpected attachment name %q", att.FileName())
+	}
+}
@@ -0,0 +1,216 @@
+// Package session records a command run — the data it collected, every AI
+// exchange and everything it printed — to a bundle file that argus replay
+// renders later without network access, for postmortems and tests.
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// Version is the bundle format version.
+const Version = 1
+
+// Bundle is a recorded session.
+type Bundle struct {
+	Version    int                `json:"version"`
+	Command    string             `json:"command"` // e.g. "explain checkout --one-shot"
+	RecordedAt time.Time          `json:"recorded_at"`
+	Data       json.RawMessage    `json:"data,omitempty"` // what the command collected, e.g. explain's correlated data
+	Exchanges  []types.AIExchange `json:"exchanges"`
+	Output     string             `json:"output"`          // stdout and stderr as printed
+	Error      string             `json:"error,omitempty"` // when the command failed
+}
+
+// Recorder captures a command's stdout and stderr while passing them
+// through, and collects its AI exchanges in AI.
+type Recorder struct {
+	AI types.Recording
+
+	bundle         Bundle
+	stdout, stderr *os.File // the originals, restored by Finish
+	pipes          []*os.File
+	wg             sync.WaitGroup
+	mu             sync.Mutex
+	output         bytes.Buffer
+}
+
+// Start begins recording command, redirecting os.Stdout and os.Stderr.
+func Start(command string) (*Recorder, error) {
+	r := &Recorder{
+		bundle: Bundle{Version: Version, Command: command, RecordedAt: time.Now().UTC()},
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+	out, err := r.tee(os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	errOut, err := r.tee(os.Stderr)
+	if err != nil {
+		out.Close()
+		return nil, err
+	}
+	os.Stdout, os.Stderr = out, errOut
+	return r, nil
+}
+
+// tee returns a pipe whose output is copied to dst and to the recording.
+func (r *Recorder) tee(dst *os.File) (*os.File, error) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("recording output: %w", err)
+	}
+	r.pipes = append(r.pipes, pw)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer pr.Close()
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := pr.Read(buf)
+			if n > 0 {
+				dst.Write(buf[:n])
+				r.mu.Lock()
+				r.output.Write(buf[:n])
+				r.mu.Unlock()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return pw, nil
+}
+
+// SetData records what the command collected.
+func (r *Recorder) SetData(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("recording data: %w", err)
+	}
+	r.bundle.Data = data
+	return nil
+}
+
+// Finish restores os.Stdout and os.Stderr and writes the bundle to path,
+// noting runErr when the command failed.
+func (r *Recorder) Finish(path string, runErr error) error {
+	os.Stdout, os.Stderr = r.stdout, r.stderr
+	for _, p := range r.pipes {
+		p.Close()
+	}
+	r.wg.Wait()
+
+	b := r.bundle
+	b.Exchanges = r.AI.Exchanges
+	if b.Exchanges == nil {
+		b.Exchanges = []types.AIExchange{}
+	}
+	b.Output = r.output.String()
+	if runErr != nil {
+		b.Error = runErr.Error()
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Bundles hold logs and prompts, so keep them private like the config.
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("writing bundle: %w", err)
+	}
+	return nil
+}
+
+// Load reads a bundle.
+func Load(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading bundle: %w", err)
+	}
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parsing bundle %s: %w", path, err)
+	}
+	if b.Version > Version {
+		return nil, fmt.Errorf("bundle %s has version %d; this argus reads up to %d", path, b.Version, Version)
+	}
+	return &b, nil
+}
+
+// Render writes the output as it was printed, including any error.
+func (b *Bundle) Render(w io.Writer) {
+	io.WriteString(w, b.Output)
+}
+
+// RenderExchanges writes every AI request and its answer, in the
+// --show-prompt format.
+func (b *Bundle) RenderExchanges(w io.Writer) {
+	for i, e := range b.Exchanges {
+		cached := ""
+		if e.Cached {
+			cached = " (from cache)"
+		}
+		fmt.Fprintf(w, "──── exchange %d of %d%s ────\n", i+1, len(b.Exchanges), cached)
+		fmt.Fprintf(w, "──── prompt: system ────\n%s\n", e.System)
+		var messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		}
+		json.Unmarshal(e.Messages, &messages)
+		for _, m := range messages {
+			fmt.Fprintf(w, "──── prompt: %s ────\n%s\n", m.Role, content(m.Content))
+		}
+		fmt.Fprintln(w, "──── answer ────")
+		if e.Response != nil {
+			var resp struct {
+				Content json.RawMessage `json:"content"`
+			}
+			json.Unmarshal(e.Response, &resp)
+			fmt.Fprintln(w, content(resp.Content))
+		} else {
+			fmt.Fprintln(w, strings.TrimRight(e.Answer, "\n"))
+		}
+	}
+	if len(b.Exchanges) > 0 {
+		fmt.Fprintln(w, "──── end of exchanges ────")
+	}
+}
+
+// content renders message content: a string, or the blocks of a tool-use
+// conversation.
+func content(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+	var blocks []struct {
+		Type      string          `json:"type"`
+		Text      string          `json:"text"`
+		Name      string          `json:"name"`
+		Input     json.RawMessage `json:"input"`
+		ToolUseID string          `json:"tool_use_id"`
+		Content   string          `json:"content"`
+	}
+	json.Unmarshal(raw, &blocks)
+	var lines []string
+	for _, bl := range blocks {
+		switch bl.Type {
+		case "text":
+			lines = append(lines, bl.Text)
+		case "tool_use":
+			lines = append(lines, fmt.Sprintf("→ %s %s", bl.Name, bl.Input))
+		case "tool_result":
+			lines = append(lines, fmt.Sprintf("← %s\n%s", bl.ToolUseID, bl.Content))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+func TestRecordAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	r, err := Start("explain checkout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("🔍 Collecting...")
+	fmt.Fprintln(os.Stderr, "progress")
+	r.SetData(map[string]int{"error_logs": 3})
+	r.AI.Exchanges = append(r.AI.Exchanges, types.AIExchange{System: "sys", Messages: json.RawMessage(`[{"role":"user","content":"why?"}]`), Answer: "the db\n"})
+	if err := r.Finish(path, errors.New("boom")); err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("bundles should be private, got %v", info.Mode().Perm())
+	}
+
+	b, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if b.Version != Version || b.Command != "explain checkout" || b.Error != "boom" {
+		t.Errorf("unexpected bundle %+v", b)
+	}
+	if !strings.Contains(b.Output, "🔍 Collecting...\n") || !strings.Contains(b.Output, "progress\n") {
+		t.Errorf("stdout and stderr should be captured, got %q", b.Output)
+	}
+	var data map[string]int
+	if err := json.Unmarshal(b.Data, &data); err != nil || data["error_logs"] != 3 {
+		t.Errorf("unexpected data %s", b.Data)
+	}
+
+	var out bytes.Buffer
+	b.Render(&out)
+	if out.String() != b.Output {
+		t.Errorf("Render should print the output as recorded, got %q", out.String())
+	}
+	out.Reset()
+	b.RenderExchanges(&out)
+	for _, want := range []string{"──── exchange 1 of 1 ────", "──── prompt: system ────\nsys\n", "──── prompt: user ────\nwhy?\n", "──── answer ────\nthe db\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("exchanges missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRenderToolExchanges(t *testing.T) {
+	b := &Bundle{Exchanges: []types.AIExchange{{
+		System:   "sys",
+		Messages: json.RawMessage(`[{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"3 logs"}]}]`),
+		Response: json.RawMessage(`{"content":[{"type":"text","text":"checking"},{"type":"tool_use","name":"query_logs","input":{"service":"api"}}]}`),
+		Cached:   true,
+	}}}
+	var out bytes.Buffer
+	b.RenderExchanges(&out)
+	for _, want := range []string{"(from cache)", "← t1\n3 logs", "checking\n→ query_logs {\"service\":\"api\"}"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestLoadRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	os.WriteFile(path, []byte(`{"version": 99}`), 0600)
+	if _, err := Load(path); err == nil {
+		t.Error("expected an error for a bundle from a newer argus")
+	}
+	os.WriteFile(path, []byte(`not json`), 0600)
+	if _, err := Load(path); err == nil {
+		t.Error("expected an error for a corrupt bundle")
+	}
+}
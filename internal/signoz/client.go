@@ -19,6 +19,11 @@ type SignozQuerier interface {
 	QueryLogs(ctx context.Context, service string, durationMinutes, limit int, severityFilter string) (*types.QueryResult, error)
 	QueryTraces(ctx context.Context, service string, durationMinutes, limit int) (*types.QueryResult, error)
 	QueryMetrics(ctx context.Context, metricName string, durationMinutes int) (*types.QueryResult, error)
+
+	// Time-ranged variants for comparing arbitrary windows (e.g. around a deploy).
+	ListServicesRange(ctx context.Context, start, end time.Time) ([]types.Service, error)
+	QueryLogsRange(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error)
+	QueryTracesRange(ctx context.Context, service string, start, end time.Time, limit int) (*types.QueryResult, error)
 }
 
 // Compile-time check that Client implements SignozQuerier.
@@ -78,15 +83,18 @@ func (c *Client) Health(ctx context.Context) (bool, time.Duration, error) {
 	return false, latency, fmt.Errorf("status %d", resp.StatusCode)
 }
 
-// ListServices returns services known to Signoz.
+// ListServices returns services known to Signoz over the last 6 hours.
 func (c *Client) ListServices(ctx context.Context) ([]types.Service, error) {
 	now := time.Now()
-	start := now.Add(-6 * time.Hour)
+	return c.ListServicesRange(ctx, now.Add(-6*time.Hour), now)
+}
 
+// ListServicesRange returns services with call/error counts for the given time range.
+func (c *Client) ListServicesRange(ctx context.Context, start, end time.Time) ([]types.Service, error) {
 	// Signoz v1/services requires a POST with start/end timestamps (epoch nanoseconds as strings).
 	reqBody := map[string]interface{}{
 		"start": fmt.Sprintf("%d", start.UnixNano()),
-		"end":   fmt.Sprintf("%d", now.UnixNano()),
+		"end":   fmt.Sprintf("%d", end.UnixNano()),
 	}
 	body, err := json.Marshal(reqBody)
 	if err != nil {
@@ -210,16 +218,23 @@ type QueryRangeParams struct {
 	SelectColumns      []SelectColumn
 	Limit              int
 	DurationMinutes    int
+	Start              time.Time // optional absolute range; overrides DurationMinutes when both are set
+	End                time.Time
 }
 
 // BuildQueryRangePayload constructs a v3-compatible query_range request.
 func BuildQueryRangePayload(params QueryRangeParams) QueryRangePayload {
 	now := time.Now()
 	start := now.Add(-time.Duration(params.DurationMinutes) * time.Minute)
+	durationMinutes := params.DurationMinutes
+	if !params.Start.IsZero() && !params.End.IsZero() {
+		start, now = params.Start, params.End
+		durationMinutes = int(now.Sub(start).Minutes())
+	}
 
 	step := 60
-	if params.PanelType == "graph" && params.DurationMinutes > 0 {
-		step = params.DurationMinutes * 60 / 60 // ~60 data points
+	if params.PanelType == "graph" && durationMinutes > 0 {
+		step = durationMinutes * 60 / 60 // ~60 data points
 		if step < 60 {
 			step = 60
 		}
@@ -292,6 +307,7 @@ func defaultSelectColumns(dataSource string) []SelectColumn {
 			{Key: "responseStatusCode", DataType: "string", Type: "tag", IsColumn: true},
 			{Key: "traceID", DataType: "string", Type: "tag", IsColumn: true},
 			{Key: "spanID", DataType: "string", Type: "tag", IsColumn: true},
+			{Key: "parentSpanID", DataType: "string", Type: "tag", IsColumn: true},
 			{Key: "statusCode", DataType: "int64", Type: "tag", IsColumn: true},
 		}
 	default:
@@ -338,6 +354,12 @@ func (c *Client) postQueryRange(ctx context.Context, payload QueryRangePayload)
 
 // QueryLogs queries logs from Signoz.
 func (c *Client) QueryLogs(ctx context.Context, service string, durationMinutes, limit int, severityFilter string) (*types.QueryResult, error) {
+	now := time.Now()
+	return c.QueryLogsRange(ctx, service, now.Add(-time.Duration(durationMinutes)*time.Minute), now, limit, severityFilter)
+}
+
+// QueryLogsRange queries logs from Signoz between start and end.
+func (c *Client) QueryLogsRange(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
 	if limit <= 0 {
 		limit = 100
 	}
@@ -365,7 +387,8 @@ func (c *Client) QueryLogs(ctx context.Context, service string, durationMinutes,
 		Filters:           filters,
 		OrderBy:           []OrderByItem{{ColumnName: "timestamp", Order: "desc"}},
 		Limit:             limit,
-		DurationMinutes:   durationMinutes,
+		Start:             start,
+		End:               end,
 	})
 
 	respBody, err := c.postQueryRange(ctx, payload)
@@ -422,6 +445,12 @@ func (c *Client) QueryMetrics(ctx context.Context, metricName string, durationMi
 
 // QueryTraces queries traces from Signoz.
 func (c *Client) QueryTraces(ctx context.Context, service string, durationMinutes, limit int) (*types.QueryResult, error) {
+	now := time.Now()
+	return c.QueryTracesRange(ctx, service, now.Add(-time.Duration(durationMinutes)*time.Minute), now, limit)
+}
+
+// QueryTracesRange queries traces from Signoz between start and end.
+func (c *Client) QueryTracesRange(ctx context.Context, service string, start, end time.Time, limit int) (*types.QueryResult, error) {
 	if limit <= 0 {
 		limit = 100
 	}
@@ -442,7 +471,8 @@ func (c *Client) QueryTraces(ctx context.Context, service string, durationMinute
 		Filters:           filters,
 		OrderBy:           []OrderByItem{{ColumnName: "timestamp", Order: "desc"}},
 		Limit:             limit,
-		DurationMinutes:   durationMinutes,
+		Start:             start,
+		End:               end,
 	})
 
 	respBody, err := c.postQueryRange(ctx, payload)
@@ -647,6 +677,8 @@ func mapToTraceEntry(m map[string]interface{}) types.TraceEntry {
 	}
 	if v, ok := m["parentSpanID"].(string); ok {
 		entry.ParentSpanID = v
+	} else if v, ok := m["parent_span_id"].(string); ok {
+		entry.ParentSpanID = v
 	}
 	if v, ok := m["serviceName"].(string); ok {
 		entry.ServiceName = v
@@ -669,6 +701,14 @@ func mapToTraceEntry(m map[string]interface{}) types.TraceEntry {
 		entry.StatusCode = v
 	} else if v, ok := m["status_code"].(string); ok {
 		entry.StatusCode = v
+	} else if v, ok := m["statusCode"].(float64); ok {
+		// OpenTelemetry status codes: 0 unset, 1 ok, 2 error.
+		switch v {
+		case 1:
+			entry.StatusCode = "OK"
+		case 2:
+			entry.StatusCode = "ERROR"
+		}
 	}
 
 	if v, ok := m["timestamp"].(string); ok {
@@ -571,3 +571,66 @@ func TestSignozQuerierInterface(t *testing.T) {
 		t.Error("Client should implement SignozQuerier")
 	}
 }
+
+func TestBuildPayloadAbsoluteRange(t *testing.T) {
+	start := time.Date(2026, 3, 1, 14, 0, 0, 0, time.UTC)
+	end := start.Add(30 * time.Minute)
+
+	payload := BuildQueryRangePayload(QueryRangeParams{
+		DataSource:        "logs",
+		PanelType:         "list",
+		AggregateOperator: "noop",
+		DurationMinutes:   60,
+		Start:             start,
+		End:               end,
+	})
+
+	if payload.Start != start.UnixMilli() || payload.End != end.UnixMilli() {
+		t.Errorf("expected absolute range %d-%d, got %d-%d", start.UnixMilli(), end.UnixMilli(), payload.Start, payload.End)
+	}
+}
+
+func TestListServicesRange(t *testing.T) {
+	start := time.Date(2026, 3, 1, 14, 0, 0, 0, time.UTC)
+	end := start.Add(30 * time.Minute)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody map[string]string
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		if reqBody["start"] != "1772373600000000000" {
+			t.Errorf("unexpected start: %s", reqBody["start"])
+		}
+		w.Write([]byte(`[{"serviceName":"api","numCalls":200,"numErrors":2,"p99":250000000,"callRate":0.11}]`))
+	}))
+	defer server.Close()
+
+	client := New(types.Instance{URL: server.URL})
+	services, err := client.ListServicesRange(context.Background(), start, end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(services) != 1 {
+		t.Fatalf("expected 1 service, got %d", len(services))
+	}
+	if services[0].P99Ms() != 250 {
+		t.Errorf("expected p99=250ms, got %.1f", services[0].P99Ms())
+	}
+	if services[0].ErrorRate != 1.0 {
+		t.Errorf("expected error rate 1.0, got %.1f", services[0].ErrorRate)
+	}
+}
+
+func TestMapToTraceEntryParentAndNumericStatus(t *testing.T) {
+	e := mapToTraceEntry(map[string]interface{}{
+		"traceID":      "t1",
+		"spanID":       "b",
+		"parentSpanID": "a",
+		"statusCode":   float64(2),
+	})
+	if e.ParentSpanID != "a" || e.StatusCode != "ERROR" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if e := mapToTraceEntry(map[string]interface{}{"statusCode": float64(0)}); e.StatusCode != "" {
+		t.Errorf("unset status should stay empty, got %q", e.StatusCode)
+	}
+}
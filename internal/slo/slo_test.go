@@ -44,6 +44,18 @@ func (m *mockSignozClient) QueryMetrics(ctx context.Context, metricName string,
 	return &types.QueryResult{}, nil
 }
 
+func (m *mockSignozClient) ListServicesRange(ctx context.Context, start, end time.Time) ([]types.Service, error) {
+	return m.ListServices(ctx)
+}
+
+func (m *mockSignozClient) QueryLogsRange(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+	return &types.QueryResult{}, nil
+}
+
+func (m *mockSignozClient) QueryTracesRange(ctx context.Context, service string, start, end time.Time, limit int) (*types.QueryResult, error) {
+	return &types.QueryResult{}, nil
+}
+
 // ──────────────────────────────────────────────
 // SLO Config Tests
 // ──────────────────────────────────────────────
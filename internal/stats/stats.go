@@ -0,0 +1,100 @@
+// Package stats provides the significance tests used to decide whether a
+// change in error counts is real or just noise from low traffic.
+package stats
+
+import "math"
+
+// exactLimit is the largest trial count for which binomial tests are computed
+// exactly; above it the normal approximation is accurate enough.
+const exactLimit = 10000
+
+// NormalCDF returns the standard normal cumulative distribution at z.
+func NormalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+// TwoProportionZTest compares the proportions x1/n1 and x2/n2 and returns the
+// z statistic (positive when the second proportion is higher) and the
+// two-sided p-value. It returns p=1 when either sample is empty.
+func TwoProportionZTest(x1, n1, x2, n2 int) (z, p float64) {
+	if n1 <= 0 || n2 <= 0 {
+		return 0, 1
+	}
+	p1 := float64(x1) / float64(n1)
+	p2 := float64(x2) / float64(n2)
+	pooled := float64(x1+x2) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		return 0, 1
+	}
+	z = (p2 - p1) / se
+	return z, 2 * (1 - NormalCDF(math.Abs(z)))
+}
+
+// PoissonRateTest compares event counts c1 over exposure t1 and c2 over
+// exposure t2 (e.g. errors per minute in two windows) and returns the
+// two-sided p-value of the conditional binomial test for equal rates.
+func PoissonRateTest(c1 int, t1 float64, c2 int, t2 float64) float64 {
+	n := c1 + c2
+	if n == 0 || t1 <= 0 || t2 <= 0 {
+		return 1
+	}
+	prob := t2 / (t1 + t2)
+
+	if n > exactLimit {
+		mean := float64(n) * prob
+		sd := math.Sqrt(float64(n) * prob * (1 - prob))
+		z := (float64(c2) - mean) / sd
+		return 2 * (1 - NormalCDF(math.Abs(z)))
+	}
+
+	// Exact two-sided test: sum the probabilities of all outcomes at least as
+	// unlikely as the observed one.
+	observed := binomialLogPMF(c2, n, prob)
+	var p float64
+	for k := 0; k <= n; k++ {
+		lp := binomialLogPMF(k, n, prob)
+		if lp <= observed+1e-7 {
+			p += math.Exp(lp)
+		}
+	}
+	return math.Min(1, p)
+}
+
+// PoissonUpperTail returns P(X >= k) for X ~ Poisson(lambda), i.e. how
+// surprising it is to see at least k events when lambda were expected.
+func PoissonUpperTail(k int, lambda float64) float64 {
+	if k <= 0 {
+		return 1
+	}
+	if lambda <= 0 {
+		return 0
+	}
+	var below float64
+	for i := 0; i < k; i++ {
+		below += math.Exp(float64(i)*math.Log(lambda) - lambda - lgamma(float64(i)+1))
+	}
+	return math.Max(0, 1-below)
+}
+
+func binomialLogPMF(k, n int, p float64) float64 {
+	switch {
+	case p <= 0:
+		if k == 0 {
+			return 0
+		}
+		return math.Inf(-1)
+	case p >= 1:
+		if k == n {
+			return 0
+		}
+		return math.Inf(-1)
+	}
+	return lgamma(float64(n)+1) - lgamma(float64(k)+1) - lgamma(float64(n-k)+1) +
+		float64(k)*math.Log(p) + float64(n-k)*math.Log(1-p)
+}
+
+func lgamma(x float64) float64 {
+	v, _ := math.Lgamma(x)
+	return v
+}
@@ -0,0 +1,75 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func approx(a, b, tol float64) bool {
+	return math.Abs(a-b) <= tol
+}
+
+func TestNormalCDF(t *testing.T) {
+	if got := NormalCDF(0); !approx(got, 0.5, 1e-9) {
+		t.Errorf("NormalCDF(0) = %f, want 0.5", got)
+	}
+	if got := NormalCDF(1.96); !approx(got, 0.975, 1e-3) {
+		t.Errorf("NormalCDF(1.96) = %f, want ~0.975", got)
+	}
+}
+
+func TestTwoProportionZTest(t *testing.T) {
+	// 1% vs 5% error rate on 1000 calls each is clearly significant.
+	z, p := TwoProportionZTest(10, 1000, 50, 1000)
+	if z <= 0 {
+		t.Errorf("expected positive z for an increase, got %f", z)
+	}
+	if p >= 0.001 {
+		t.Errorf("expected p < 0.001, got %f", p)
+	}
+
+	// 1 vs 2 errors on 100 calls is not.
+	_, p = TwoProportionZTest(1, 100, 2, 100)
+	if p < 0.5 {
+		t.Errorf("expected large p-value for 1 → 2 errors, got %f", p)
+	}
+
+	if _, p := TwoProportionZTest(0, 0, 5, 100); p != 1 {
+		t.Errorf("expected p=1 for empty sample, got %f", p)
+	}
+}
+
+func TestPoissonRateTest(t *testing.T) {
+	if p := PoissonRateTest(1, 60, 2, 60); p != 1 {
+		t.Errorf("1 → 2 errors should have p=1, got %f", p)
+	}
+	if p := PoissonRateTest(10, 60, 40, 60); p >= 0.001 {
+		t.Errorf("10 → 40 errors should be significant, got p=%f", p)
+	}
+	// Exposure matters: the same count over half the time is a doubled rate.
+	equal := PoissonRateTest(20, 60, 20, 60)
+	doubled := PoissonRateTest(20, 60, 20, 30)
+	if doubled >= equal {
+		t.Errorf("expected smaller p for doubled rate: equal=%f doubled=%f", equal, doubled)
+	}
+	// Large counts use the normal approximation.
+	if p := PoissonRateTest(10000, 1, 12000, 1); p >= 0.001 {
+		t.Errorf("expected significant large-count change, got p=%f", p)
+	}
+}
+
+func TestPoissonUpperTail(t *testing.T) {
+	// P(X >= 1 | λ=1) = 1 - e^-1
+	if got := PoissonUpperTail(1, 1); !approx(got, 1-math.Exp(-1), 1e-9) {
+		t.Errorf("PoissonUpperTail(1, 1) = %f", got)
+	}
+	if got := PoissonUpperTail(40, 10); got > 1e-6 {
+		t.Errorf("40 events at λ=10 should be very unlikely, got %g", got)
+	}
+	if got := PoissonUpperTail(0, 5); got != 1 {
+		t.Errorf("P(X >= 0) should be 1, got %f", got)
+	}
+	if got := PoissonUpperTail(3, 0); got != 0 {
+		t.Errorf("P(X >= 3 | λ=0) should be 0, got %f", got)
+	}
+}
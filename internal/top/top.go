@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lbarahona/argus/internal/ownership"
 	"github.com/lbarahona/argus/internal/signoz"
 )
 
@@ -25,7 +26,9 @@ const (
 type Options struct {
 	Limit    int
 	SortBy   SortField
-	Duration int // minutes for log lookup
+	Duration int                // minutes for log lookup
+	Owners   *ownership.Catalog // adds a team column when set
+	Team     string             // only show this team's services
 }
 
 // ServiceInfo aggregates service data for the top view.
@@ -34,8 +37,9 @@ type ServiceInfo struct {
 	Calls        int
 	Errors       int
 	ErrorRate    float64
-	RecentErrors int // errors from logs in the duration window
+	RecentErrors int    // errors from logs in the duration window
 	Severity     string // "critical", "warning", "healthy"
+	Team         string // owning team, empty when unowned
 }
 
 // Result holds the top view data.
@@ -44,6 +48,9 @@ type Result struct {
 	GeneratedAt time.Time
 	Instance    string
 	Duration    int
+	Team        string // set when filtered to one team
+
+	owned bool // an ownership catalog is configured
 }
 
 // Run fetches and ranks services.
@@ -68,6 +75,10 @@ func Run(ctx context.Context, client signoz.SignozQuerier, instKey string, opts
 
 	var infos []ServiceInfo
 	for _, s := range services {
+		owner, _ := opts.Owners.Owner(s.Name)
+		if opts.Team != "" && owner.Team != opts.Team {
+			continue
+		}
 		severity := "healthy"
 		if s.ErrorRate > 5 {
 			severity = "critical"
@@ -84,6 +95,7 @@ func Run(ctx context.Context, client signoz.SignozQuerier, instKey string, opts
 			ErrorRate:    s.ErrorRate,
 			RecentErrors: recentErrorCounts[s.Name],
 			Severity:     severity,
+			Team:         owner.Team,
 		})
 	}
 
@@ -112,12 +124,18 @@ func Run(ctx context.Context, client signoz.SignozQuerier, instKey string, opts
 		GeneratedAt: time.Now(),
 		Instance:    instKey,
 		Duration:    dur,
+		Team:        opts.Team,
+		owned:       opts.Owners != nil,
 	}, nil
 }
 
 // RenderTerminal displays the top view.
 func (r *Result) RenderTerminal(w io.Writer) {
-	fmt.Fprintf(w, "\n🔭 ARGUS TOP — %s\n", r.Instance)
+	title := r.Instance
+	if r.Team != "" {
+		title += " · team " + r.Team
+	}
+	fmt.Fprintf(w, "\n🔭 ARGUS TOP — %s\n", title)
 	fmt.Fprintf(w, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 	fmt.Fprintf(w, "  %s | Recent errors: last %d min\n\n", r.GeneratedAt.Format("15:04:05"), r.Duration)
 
@@ -126,17 +144,32 @@ func (r *Result) RenderTerminal(w io.Writer) {
 		return
 	}
 
+	// The team column only appears when an ownership catalog is configured.
+	teamCol := func(team string) string {
+		if !r.owned {
+			return ""
+		}
+		if team == "" {
+			team = "-"
+		}
+		return fmt.Sprintf(" %-14s", truncate(team, 14))
+	}
+	width := 82
+	if r.owned {
+		width += 15
+	}
+
 	// Header
-	fmt.Fprintf(w, "  %-35s %10s %10s %9s %8s  %s\n",
-		"SERVICE", "CALLS", "ERRORS", "ERR RATE", "RECENT", "HEALTH")
-	fmt.Fprintf(w, "  %s\n", strings.Repeat("─", 82))
+	fmt.Fprintf(w, "  %-35s%s %10s %10s %9s %8s  %s\n",
+		"SERVICE", teamCol("TEAM"), "CALLS", "ERRORS", "ERR RATE", "RECENT", "HEALTH")
+	fmt.Fprintf(w, "  %s\n", strings.Repeat("─", width))
 
 	for _, s := range r.Services {
 		icon := severityIcon(s.Severity)
 		bar := errorBar(s.ErrorRate)
 
-		fmt.Fprintf(w, "  %-35s %10d %10d %8.1f%% %8d  %s %s\n",
-			truncate(s.Name, 35), s.Calls, s.Errors, s.ErrorRate, s.RecentErrors, icon, bar)
+		fmt.Fprintf(w, "  %-35s%s %10d %10d %8.1f%% %8d  %s %s\n",
+			truncate(s.Name, 35), teamCol(s.Team), s.Calls, s.Errors, s.ErrorRate, s.RecentErrors, icon, bar)
 	}
 
 	fmt.Fprintf(w, "\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
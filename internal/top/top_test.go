@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/lbarahona/argus/internal/ownership"
 	"github.com/lbarahona/argus/pkg/types"
 )
 
@@ -44,6 +45,18 @@ func (m *mockSignozClient) QueryMetrics(ctx context.Context, metricName string,
 	return &types.QueryResult{}, nil
 }
 
+func (m *mockSignozClient) ListServicesRange(ctx context.Context, start, end time.Time) ([]types.Service, error) {
+	return m.ListServices(ctx)
+}
+
+func (m *mockSignozClient) QueryLogsRange(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+	return &types.QueryResult{}, nil
+}
+
+func (m *mockSignozClient) QueryTracesRange(ctx context.Context, service string, start, end time.Time, limit int) (*types.QueryResult, error) {
+	return &types.QueryResult{}, nil
+}
+
 // ──────────────────────────────────────────────
 // Run Tests (mock-based)
 // ──────────────────────────────────────────────
@@ -195,3 +208,35 @@ func TestSeverityIcon(t *testing.T) {
 		t.Error("expected green for healthy")
 	}
 }
+
+func TestRunWithOwners(t *testing.T) {
+	owners, err := ownership.New(map[string]types.Team{
+		"payments": {Services: []string{"payment-*"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := &mockSignozClient{
+		listServicesFunc: func(ctx context.Context) ([]types.Service, error) {
+			return []types.Service{
+				{Name: "payment-api", NumCalls: 100, NumErrors: 5, ErrorRate: 5},
+				{Name: "search", NumCalls: 900, NumErrors: 90, ErrorRate: 10},
+			}, nil
+		},
+	}
+
+	r, err := Run(context.Background(), mock, "prod", Options{Owners: owners})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	r.RenderTerminal(&buf)
+	if !bytes.Contains(buf.Bytes(), []byte("TEAM")) || !bytes.Contains(buf.Bytes(), []byte("payments")) {
+		t.Errorf("expected a team column:\n%s", buf.String())
+	}
+
+	r, _ = Run(context.Background(), mock, "prod", Options{Owners: owners, Team: "payments"})
+	if len(r.Services) != 1 || r.Services[0].Team != "payments" {
+		t.Errorf("expected only payment services, got %+v", r.Services)
+	}
+}
@@ -0,0 +1,186 @@
+package topology
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Neighborhood returns the subgraph of services within depth hops of service
+// in either direction (depth <= 0 means unlimited), keeping every edge
+// between them.
+func (g *Graph) Neighborhood(service string, depth int) *Graph {
+	keep := map[string]bool{service: true}
+	for _, h := range g.Upstream(service, depth) {
+		keep[h.Service] = true
+	}
+	for _, h := range g.Downstream(service, depth) {
+		keep[h.Service] = true
+	}
+
+	sub := &Graph{
+		edges:    make(map[[2]string]*Edge),
+		out:      make(map[string][]*Edge),
+		in:       make(map[string][]*Edge),
+		services: make(map[string]bool),
+	}
+	for s := range keep {
+		if g.services[s] {
+			sub.services[s] = true
+		}
+	}
+	for _, e := range g.Edges() {
+		if keep[e.From] && keep[e.To] {
+			sub.edges[[2]string{e.From, e.To}] = e
+			sub.out[e.From] = append(sub.out[e.From], e)
+			sub.in[e.To] = append(sub.in[e.To], e)
+		}
+	}
+	return sub
+}
+
+// Has reports whether service appears in the graph.
+func (g *Graph) Has(service string) bool { return g.services[service] }
+
+// Roots returns the services nobody calls, sorted: the entry points.
+func (g *Graph) Roots() []string {
+	var roots []string
+	for _, s := range g.Services() {
+		if len(g.in[s]) == 0 {
+			roots = append(roots, s)
+		}
+	}
+	return roots
+}
+
+// Label summarises the edge's traffic, e.g. "120 calls · 2.5% err · p99 34ms".
+func (e *Edge) Label() string {
+	return fmt.Sprintf("%d calls · %.1f%% err · p99 %.0fms", e.Calls, e.ErrorRate(), e.P99())
+}
+
+// RenderTree prints the graph as an indented call tree starting from roots.
+// A service that was already expanded is shown again with ↺ instead of its
+// callees, which also keeps cycles finite. Without roots the tree starts at
+// the entry points, and then at any service only reachable through a cycle.
+func (g *Graph) RenderTree(w io.Writer, roots ...string) {
+	whole := len(roots) == 0
+	if whole {
+		roots = g.Roots()
+	}
+	expanded := make(map[string]bool)
+	var walk func(service, prefix string)
+	walk = func(service, prefix string) {
+		expanded[service] = true
+		callees := g.out[service]
+		for i, e := range callees {
+			connector, indent := "├─ ", "│  "
+			if i == len(callees)-1 {
+				connector, indent = "└─ ", "   "
+			}
+			icon := "🟢"
+			if e.Errors > 0 {
+				icon = "🔴"
+			}
+			if expanded[e.To] {
+				fmt.Fprintf(w, "%s%s%s %s ↺  %s\n", prefix, connector, icon, e.To, e.Label())
+				continue
+			}
+			fmt.Fprintf(w, "%s%s%s %s  %s\n", prefix, connector, icon, e.To, e.Label())
+			walk(e.To, prefix+indent)
+		}
+	}
+
+	first := true
+	start := func(root string) {
+		if !first {
+			fmt.Fprintln(w)
+		}
+		first = false
+		fmt.Fprintln(w, root)
+		walk(root, "")
+	}
+	for _, r := range roots {
+		if !expanded[r] {
+			start(r)
+		}
+	}
+	for _, s := range g.Services() {
+		if whole && !expanded[s] && len(g.out[s]) > 0 {
+			start(s)
+		}
+	}
+}
+
+// RenderCallers prints who calls service, as an indented tree walking
+// upstream up to depth hops (depth <= 0 means unlimited).
+func (g *Graph) RenderCallers(w io.Writer, service string, depth int) {
+	seen := map[string]bool{service: true}
+	var walk func(service, prefix string, d int)
+	walk = func(service, prefix string, d int) {
+		callers := g.in[service]
+		for i, e := range callers {
+			connector, indent := "├─ ", "│  "
+			if i == len(callers)-1 {
+				connector, indent = "└─ ", "   "
+			}
+			icon := "🟢"
+			if e.Errors > 0 {
+				icon = "🔴"
+			}
+			fmt.Fprintf(w, "%s%s%s %s  %s\n", prefix, connector, icon, e.From, e.Label())
+			if !seen[e.From] && (depth <= 0 || d < depth) {
+				seen[e.From] = true
+				walk(e.From, prefix+indent, d+1)
+			}
+		}
+	}
+	walk(service, "", 1)
+}
+
+// DOT renders the graph in Graphviz DOT format. Edges with failed calls are
+// drawn in red.
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph services {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+	for _, s := range g.Services() {
+		b.WriteString(fmt.Sprintf("  %q;\n", s))
+	}
+	for _, e := range g.Edges() {
+		attrs := fmt.Sprintf("label=%q", e.Label())
+		if e.Errors > 0 {
+			attrs += ", color=red, fontcolor=red"
+		}
+		b.WriteString(fmt.Sprintf("  %q -> %q [%s];\n", e.From, e.To, attrs))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart. Service names are used
+// as labels only, since Mermaid node IDs can't hold arbitrary characters.
+func (g *Graph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	ids := make(map[string]string, len(g.services))
+	for i, s := range g.Services() {
+		ids[s] = fmt.Sprintf("s%d", i)
+		b.WriteString(fmt.Sprintf("  %s[\"%s\"]\n", ids[s], mermaidEscape(s)))
+	}
+	var failing []string
+	for i, e := range g.Edges() {
+		b.WriteString(fmt.Sprintf("  %s -->|\"%s\"| %s\n", ids[e.From], mermaidEscape(e.Label()), ids[e.To]))
+		if e.Errors > 0 {
+			failing = append(failing, fmt.Sprint(i))
+		}
+	}
+	if len(failing) > 0 {
+		b.WriteString(fmt.Sprintf("  linkStyle %s stroke:red,color:red\n", strings.Join(failing, ",")))
+	}
+	return b.String()
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
@@ -0,0 +1,97 @@
+package topology
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNeighborhood(t *testing.T) {
+	g := Build(testSpans())
+
+	sub := g.Neighborhood("db", 1)
+	if got := strings.Join(sub.Services(), ","); got != "api,db" {
+		t.Errorf("depth 1 around db: got %s", got)
+	}
+	if len(sub.Edges()) != 1 {
+		t.Errorf("expected only api→db, got %d edges", len(sub.Edges()))
+	}
+
+	sub = g.Neighborhood("db", 2)
+	if got := strings.Join(sub.Services(), ","); got != "api,db,mobile,web" {
+		t.Errorf("depth 2 around db: got %s", got)
+	}
+	if sub.Has("cache") || !sub.Has("web") {
+		t.Error("cache is a sibling, not an ancestor or descendant, of db")
+	}
+}
+
+func TestRenderTree(t *testing.T) {
+	var out bytes.Buffer
+	Build(testSpans()).RenderTree(&out)
+
+	want := `mobile
+└─ 🟢 api  1 calls · 0.0% err · p99 40ms
+   ├─ 🟢 cache  1 calls · 0.0% err · p99 2ms
+   └─ 🔴 db  2 calls · 50.0% err · p99 80ms
+
+web
+└─ 🟢 api ↺  1 calls · 0.0% err · p99 100ms
+
+worker
+`
+	if out.String() != want {
+		t.Errorf("unexpected tree:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestRenderTreeCycle(t *testing.T) {
+	g := Build(append(testSpans(),
+		span("t4", "1", "", "a", 5, ""),
+		span("t4", "2", "1", "b", 4, ""),
+		span("t4", "3", "2", "a", 3, ""),
+	))
+	var out bytes.Buffer
+	g.RenderTree(&out, "a")
+	if want := "a\n└─ 🟢 b  1 calls · 0.0% err · p99 4ms\n   └─ 🟢 a ↺  1 calls · 0.0% err · p99 3ms\n"; !strings.HasPrefix(out.String(), want) {
+		t.Errorf("cycle should stop at the repeated service:\n%s", out.String())
+	}
+}
+
+func TestRenderCallers(t *testing.T) {
+	var out bytes.Buffer
+	Build(testSpans()).RenderCallers(&out, "db", 0)
+	for _, want := range []string{"└─ 🔴 api  2 calls", "   ├─ 🟢 mobile", "   └─ 🟢 web"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestDOT(t *testing.T) {
+	dot := Build(testSpans()).DOT()
+	for _, want := range []string{
+		"digraph services {",
+		`"api" -> "db" [label="2 calls · 50.0% err · p99 80ms", color=red, fontcolor=red];`,
+		`"web" -> "api" [label="1 calls · 0.0% err · p99 100ms"];`,
+		`"worker";`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT missing %q:\n%s", want, dot)
+		}
+	}
+}
+
+func TestMermaid(t *testing.T) {
+	m := Build(testSpans()).Mermaid()
+	for _, want := range []string{
+		"flowchart LR",
+		`s0["api"]`,
+		`s0 -->|"2 calls · 50.0% err · p99 80ms"| s2`,
+		"linkStyle 1 stroke:red,color:red",
+	} {
+		if !strings.Contains(m, want) {
+			t.Errorf("Mermaid missing %q:\n%s", want, m)
+		}
+	}
+}
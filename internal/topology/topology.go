@@ -0,0 +1,180 @@
+// Package topology derives the service dependency graph from trace spans:
+// whenever a span's parent belongs to another service, the parent's service
+// called the child's.
+package topology
+
+import (
+	"sort"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// Edge is one caller → callee relationship, measured by the callee's spans.
+type Edge struct {
+	From   string
+	To     string
+	Calls  int
+	Errors int
+
+	durations []float64 // ms, one per call
+}
+
+// ErrorRate returns the share of failed calls in percent.
+func (e *Edge) ErrorRate() float64 {
+	if e.Calls == 0 {
+		return 0
+	}
+	return float64(e.Errors) / float64(e.Calls) * 100
+}
+
+// P99 returns the 99th percentile call duration in ms.
+func (e *Edge) P99() float64 {
+	sorted := append([]float64(nil), e.durations...)
+	sort.Float64s(sorted)
+	return percentile(sorted, 99)
+}
+
+// Graph is a directed service dependency graph.
+type Graph struct {
+	edges    map[[2]string]*Edge
+	out, in  map[string][]*Edge
+	services map[string]bool
+}
+
+// Build derives the graph from spans. Spans whose parent wasn't sampled
+// still count their service as a node but add no edge, so the graph is only
+// as complete as the span sample.
+func Build(spans []types.TraceEntry) *Graph {
+	g := &Graph{
+		edges:    make(map[[2]string]*Edge),
+		out:      make(map[string][]*Edge),
+		in:       make(map[string][]*Edge),
+		services: make(map[string]bool),
+	}
+	owner := make(map[[2]string]string, len(spans)) // (trace, span) → service
+	for _, s := range spans {
+		owner[[2]string{s.TraceID, s.SpanID}] = s.ServiceName
+		if s.ServiceName != "" {
+			g.services[s.ServiceName] = true
+		}
+	}
+	for _, s := range spans {
+		if s.ParentSpanID == "" || s.ServiceName == "" {
+			continue
+		}
+		caller := owner[[2]string{s.TraceID, s.ParentSpanID}]
+		if caller == "" || caller == s.ServiceName {
+			continue
+		}
+		key := [2]string{caller, s.ServiceName}
+		e, ok := g.edges[key]
+		if !ok {
+			e = &Edge{From: caller, To: s.ServiceName}
+			g.edges[key] = e
+			g.out[caller] = append(g.out[caller], e)
+			g.in[s.ServiceName] = append(g.in[s.ServiceName], e)
+		}
+		e.Calls++
+		if s.IsError() {
+			e.Errors++
+		}
+		e.durations = append(e.durations, s.DurationMs())
+	}
+	for _, m := range []map[string][]*Edge{g.out, g.in} {
+		for _, edges := range m {
+			sortEdges(edges)
+		}
+	}
+	return g
+}
+
+// Services returns every service seen in the spans, sorted.
+func (g *Graph) Services() []string {
+	out := make([]string, 0, len(g.services))
+	for s := range g.services {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Edges returns every edge, ordered by caller then callee.
+func (g *Graph) Edges() []*Edge {
+	out := make([]*Edge, 0, len(g.edges))
+	for _, e := range g.edges {
+		out = append(out, e)
+	}
+	sortEdges(out)
+	return out
+}
+
+// Callers returns the edges into service: who calls it.
+func (g *Graph) Callers(service string) []*Edge { return g.in[service] }
+
+// Callees returns the edges out of service: what it depends on.
+func (g *Graph) Callees(service string) []*Edge { return g.out[service] }
+
+// Hop is a service reached while walking the graph from a starting service.
+type Hop struct {
+	Service  string
+	Distance int   // 1 for direct neighbours
+	Edge     *Edge // the edge through which the service was first reached
+}
+
+// Upstream returns the services that call service, directly or through
+// others, up to depth hops away (depth <= 0 means unlimited), nearest first.
+func (g *Graph) Upstream(service string, depth int) []Hop {
+	return g.walk(service, depth, g.in, func(e *Edge) string { return e.From })
+}
+
+// Downstream returns the services that service depends on, directly or
+// transitively, up to depth hops away (depth <= 0 means unlimited).
+func (g *Graph) Downstream(service string, depth int) []Hop {
+	return g.walk(service, depth, g.out, func(e *Edge) string { return e.To })
+}
+
+func (g *Graph) walk(start string, depth int, adj map[string][]*Edge, next func(*Edge) string) []Hop {
+	seen := map[string]bool{start: true}
+	var hops []Hop
+	frontier := []string{start}
+	for d := 1; len(frontier) > 0 && (depth <= 0 || d <= depth); d++ {
+		var following []string
+		for _, svc := range frontier {
+			for _, e := range adj[svc] {
+				n := next(e)
+				if seen[n] {
+					continue
+				}
+				seen[n] = true
+				hops = append(hops, Hop{Service: n, Distance: d, Edge: e})
+				following = append(following, n)
+			}
+		}
+		frontier = following
+	}
+	return hops
+}
+
+func sortEdges(edges []*Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+}
+
+// percentile returns the nearest-rank percentile of an ascending slice.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,72 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// span builds a span of svc in trace, parented by parent (empty for roots).
+func span(trace, id, parent, svc string, ms int64, status string) types.TraceEntry {
+	return types.TraceEntry{TraceID: trace, SpanID: id, ParentSpanID: parent, ServiceName: svc, DurationNano: ms * 1e6, StatusCode: status}
+}
+
+func testSpans() []types.TraceEntry {
+	return []types.TraceEntry{
+		// web → api → db, api → cache
+		span("t1", "1", "", "web", 120, ""),
+		span("t1", "2", "1", "api", 100, ""),
+		span("t1", "3", "2", "api", 90, ""), // internal span, no edge
+		span("t1", "4", "3", "db", 80, "ERROR"),
+		span("t1", "5", "2", "cache", 2, ""),
+		// mobile → api → db
+		span("t2", "1", "", "mobile", 50, ""),
+		span("t2", "2", "1", "api", 40, ""),
+		span("t2", "3", "2", "db", 30, ""),
+		// orphan whose parent wasn't sampled
+		span("t3", "9", "missing", "worker", 10, ""),
+	}
+}
+
+func TestBuild(t *testing.T) {
+	g := Build(testSpans())
+
+	edges := g.Edges()
+	want := []string{"api→cache", "api→db", "mobile→api", "web→api"}
+	if len(edges) != len(want) {
+		t.Fatalf("expected %d edges, got %d", len(want), len(edges))
+	}
+	for i, e := range edges {
+		if e.From+"→"+e.To != want[i] {
+			t.Errorf("edge %d = %s→%s, want %s", i, e.From, e.To, want[i])
+		}
+	}
+
+	db := g.Callers("db")
+	if len(db) != 1 || db[0].Calls != 2 || db[0].Errors != 1 || db[0].ErrorRate() != 50 || db[0].P99() != 80 {
+		t.Errorf("unexpected api→db edge %+v", db[0])
+	}
+	if len(g.Callees("api")) != 2 || len(g.Callers("api")) != 2 {
+		t.Error("api should have 2 callers and 2 callees")
+	}
+	if svcs := g.Services(); len(svcs) != 6 || svcs[5] != "worker" {
+		t.Errorf("unexpected services %v", svcs)
+	}
+}
+
+func TestUpstreamDownstream(t *testing.T) {
+	g := Build(testSpans())
+
+	up := g.Upstream("db", 0)
+	if len(up) != 3 || up[0].Service != "api" || up[0].Distance != 1 || up[1].Distance != 2 {
+		t.Errorf("unexpected upstream %+v", up)
+	}
+	if up := g.Upstream("db", 1); len(up) != 1 {
+		t.Errorf("depth 1 should only reach direct callers, got %+v", up)
+	}
+
+	down := g.Downstream("web", 0)
+	if len(down) != 3 || down[0].Service != "api" || down[1].Edge.From != "api" {
+		t.Errorf("unexpected downstream %+v", down)
+	}
+}
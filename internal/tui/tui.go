@@ -11,7 +11,10 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/lbarahona/argus/internal/ai"
+	"github.com/lbarahona/argus/internal/cite"
+	"github.com/lbarahona/argus/internal/prompt"
 	"github.com/lbarahona/argus/internal/signoz"
+	"github.com/lbarahona/argus/pkg/types"
 )
 
 const tuiSystemPrompt = `You are an expert Site Reliability Engineer (SRE) in an interactive troubleshooting session.
@@ -54,8 +57,9 @@ var (
 type Options struct {
 	InstanceKey  string
 	InstanceName string
-	AnthropicKey string
+	AI           types.AIProfile
 	MaxHistory   int
+	SignozURL    string // Signoz UI that cited logs link to; empty disables citations
 }
 
 // Session holds the state for an interactive TUI session.
@@ -63,9 +67,10 @@ type Session struct {
 	client       signoz.SignozQuerier
 	instanceKey  string
 	instanceName string
-	anthropicKey string
+	ai           types.AIProfile
 	history      []ai.Message
 	maxHistory   int
+	refs         *cite.Refs // reference IDs, stable for the whole session
 	stdin        io.Reader
 	stdout       io.Writer
 }
@@ -84,8 +89,9 @@ func New(client signoz.SignozQuerier, opts Options) *Session {
 		client:       client,
 		instanceKey:  opts.InstanceKey,
 		instanceName: name,
-		anthropicKey: opts.AnthropicKey,
+		ai:           opts.AI,
 		maxHistory:   maxHistory,
+		refs:         cite.New(opts.SignozURL),
 		stdin:        os.Stdin,
 		stdout:       os.Stdout,
 	}
@@ -121,27 +127,44 @@ func (s *Session) Run(ctx context.Context) error {
 		fmt.Fprintf(s.stdout, "\n  %s\n\n", mutedStyle.Render(fmt.Sprintf("Gathering data from %s...", accentStyle.Render(s.instanceKey))))
 
 		signozContext := s.gatherContext(ctx)
+		if signozContext.Trimmed() {
+			fmt.Fprintf(s.stdout, "  %s\n\n", mutedStyle.Render("Context trimmed to fit: "+signozContext.Summary()))
+		}
 
 		// Build the user message with Signoz data appended
 		userContent := input
-		if signozContext != "" {
-			userContent += "\n\n---\n[Live Signoz data from " + s.instanceKey + "]\n" + signozContext
+		if signozContext.Text != "" {
+			userContent += "\n\n---\n[Live Signoz data from " + s.instanceKey + "]\n" + signozContext.Text
 		}
 
 		s.history = append(s.history, ai.Message{Role: "user", Content: userContent})
 
-		// Stream AI response, capturing it for history
+		// Stream AI response, capturing it for history; citations are
+		// linked on screen only
 		var responseBuf bytes.Buffer
-		multiWriter := io.MultiWriter(s.stdout, &responseBuf)
+		linked := s.refs.NewWriter(s.stdout)
+		multiWriter := io.MultiWriter(linked, &responseBuf)
 
-		analyzer := ai.New(s.anthropicKey)
-		if err := analyzer.AnalyzeWithHistory(tuiSystemPrompt, s.history, multiWriter); err != nil {
+		analyzer, err := ai.FromProfile(s.ai)
+		if err == nil {
+			err = analyzer.AnalyzeWithHistory(ctx, tuiSystemPrompt, s.history, multiWriter)
+		}
+		linked.Flush()
+		if ctx.Err() != nil {
+			// Ctrl+C cancels the answer in progress and ends the session.
+			fmt.Fprintln(s.stdout, mutedStyle.Render("\nSession ended."))
+			return nil
+		}
+		if err != nil {
 			fmt.Fprintf(s.stdout, "\n%s %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444")).Bold(true).Render("Error:"), err)
 			// Remove the failed user message so the conversation stays clean
 			s.history = s.history[:len(s.history)-1]
 		} else {
 			s.history = append(s.history, ai.Message{Role: "assistant", Content: responseBuf.String()})
 			s.trimHistory()
+			if usage := analyzer.Usage(); !usage.Empty() {
+				fmt.Fprintln(s.stdout, mutedStyle.Render("🪙 "+usage.String()))
+			}
 		}
 
 		fmt.Fprintln(s.stdout)
@@ -191,42 +214,41 @@ func (s *Session) handleCommand(input string) bool {
 	}
 }
 
-func (s *Session) gatherContext(ctx context.Context) string {
-	var b strings.Builder
+// gatherContext fetches live data for a question, fitted to the profile's
+// prompt budget with recent errors ahead of the service list.
+func (s *Session) gatherContext(ctx context.Context) prompt.Result {
+	b := prompt.New(s.ai.PromptBudget)
 
 	// Services overview
 	services, err := s.client.ListServices(ctx)
 	if err == nil && len(services) > 0 {
-		b.WriteString("## Services\n")
+		section := b.Section("## Services", prompt.Normal)
 		for _, svc := range services {
 			rate := svc.ErrorRate
 			if svc.NumCalls > 0 && rate == 0 && svc.NumErrors > 0 {
 				rate = float64(svc.NumErrors) / float64(svc.NumCalls) * 100
 			}
-			b.WriteString(fmt.Sprintf("- %s: %d calls, %d errors (%.1f%%)\n",
-				svc.Name, svc.NumCalls, svc.NumErrors, rate))
+			section.Addf("- %s: %d calls, %d errors (%.1f%%)",
+				svc.Name, svc.NumCalls, svc.NumErrors, rate)
 		}
 	}
 
 	// Recent error logs
 	result, err := s.client.QueryLogs(ctx, "", 15, 20, "ERROR")
 	if err == nil && len(result.Logs) > 0 {
-		b.WriteString("\n## Recent Error Logs\n")
+		section := b.Section("## Recent Error Logs", prompt.High)
 		for _, log := range result.Logs {
-			body := log.Body
-			if len(body) > 200 {
-				body = body[:200]
-			}
 			svc := log.ServiceName
 			if svc == "" {
 				svc = "unknown"
 			}
-			b.WriteString(fmt.Sprintf("- [%s] %s: %s\n",
-				log.Timestamp.Format("15:04:05"), svc, body))
+			section.AddKeyed(svc+"\x00"+log.Body, fmt.Sprintf("- %s[%s] %s: %s",
+				s.refs.Log(log), log.Timestamp.Format("15:04:05"), svc, log.Body))
 		}
 	}
+	b.Text(s.refs.Instruction())
 
-	return b.String()
+	return b.Build()
 }
 
 func (s *Session) trimHistory() {
@@ -46,6 +46,18 @@ func (m *mockSignozClient) QueryMetrics(ctx context.Context, metricName string,
 	return &types.QueryResult{}, nil
 }
 
+func (m *mockSignozClient) ListServicesRange(ctx context.Context, start, end time.Time) ([]types.Service, error) {
+	return m.ListServices(ctx)
+}
+
+func (m *mockSignozClient) QueryLogsRange(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+	return &types.QueryResult{}, nil
+}
+
+func (m *mockSignozClient) QueryTracesRange(ctx context.Context, service string, start, end time.Time, limit int) (*types.QueryResult, error) {
+	return &types.QueryResult{}, nil
+}
+
 // ──────────────────────────────────────────────
 // Helper
 // ──────────────────────────────────────────────
@@ -55,7 +67,7 @@ func newTestSession(mock *mockSignozClient) *Session {
 		client:       mock,
 		instanceKey:  "test",
 		instanceName: "Test Instance",
-		anthropicKey: "test-key",
+		ai:           types.AIProfile{APIKey: "test-key"},
 		maxHistory:   20,
 		stdout:       &bytes.Buffer{},
 	}
@@ -83,7 +95,7 @@ func TestGatherContext(t *testing.T) {
 	}
 
 	s := newTestSession(mock)
-	result := s.gatherContext(context.Background())
+	result := s.gatherContext(context.Background()).Text
 
 	if !strings.Contains(result, "api") {
 		t.Error("context should contain service name 'api'")
@@ -105,7 +117,7 @@ func TestGatherContext(t *testing.T) {
 func TestGatherContextNoData(t *testing.T) {
 	mock := &mockSignozClient{}
 	s := newTestSession(mock)
-	result := s.gatherContext(context.Background())
+	result := s.gatherContext(context.Background()).Text
 
 	if result != "" {
 		t.Errorf("expected empty context when no data, got: %q", result)
@@ -0,0 +1,191 @@
+package watch
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lbarahona/argus/internal/glob"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the optional watch config file (~/.argus/watch.yaml). It layers
+// per-service threshold overrides, ignore lists and traffic floors on top of
+// the global thresholds:
+//
+//	min_calls: 20            # skip services with fewer calls per window
+//	ignore: [healthcheck, "*-canary"]
+//	services:
+//	  payment-api:
+//	    error_rate_warning: 0.5
+//	    error_rate_critical: 2
+//	  "batch-*":
+//	    error_rate_warning: 10
+//	    error_rate_critical: 25
+//	    min_calls: 100
+type Config struct {
+	MinCalls float64                `yaml:"min_calls"`
+	Ignore   []string               `yaml:"ignore"`
+	Services map[string]ServiceRule `yaml:"services"` // keyed by service name or glob pattern
+}
+
+// ServiceRule overrides watch settings for the services matching its key.
+// Unset fields keep the value from less specific rules or the global thresholds.
+type ServiceRule struct {
+	Ignore            bool     `yaml:"ignore"`
+	MinCalls          *float64 `yaml:"min_calls"`
+	ErrorRateWarning  *float64 `yaml:"error_rate_warning"`
+	ErrorRateCritical *float64 `yaml:"error_rate_critical"`
+	P99Warning        *float64 `yaml:"p99_warning_ms"`
+	P99Critical       *float64 `yaml:"p99_critical_ms"`
+	ErrorSpike        *float64 `yaml:"error_spike"`
+	NewErrors         *bool    `yaml:"new_errors"`
+	MinErrors         *float64 `yaml:"min_errors"`
+}
+
+// ConfigPath returns the default watch config location.
+func ConfigPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".argus", "watch.yaml")
+}
+
+// LoadConfig reads a watch config file. A missing file at the default
+// location is not an error and yields nil; a missing explicit path is.
+func LoadConfig(file string) (*Config, error) {
+	explicit := file != ""
+	if !explicit {
+		file = ConfigPath()
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading watch config: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing watch config %s: %w", file, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid watch config %s: %w", file, err)
+	}
+	return &cfg, nil
+}
+
+func (c *Config) validate() error {
+	if c.MinCalls < 0 {
+		return fmt.Errorf("min_calls must not be negative")
+	}
+	for _, p := range c.Ignore {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("ignore pattern %q: %w", p, err)
+		}
+	}
+	for p, r := range c.Services {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("service pattern %q: %w", p, err)
+		}
+		for name, v := range map[string]*float64{
+			"min_calls":           r.MinCalls,
+			"error_rate_warning":  r.ErrorRateWarning,
+			"error_rate_critical": r.ErrorRateCritical,
+			"p99_warning_ms":      r.P99Warning,
+			"p99_critical_ms":     r.P99Critical,
+			"error_spike":         r.ErrorSpike,
+			"min_errors":          r.MinErrors,
+		} {
+			if v != nil && *v < 0 {
+				return fmt.Errorf("%s: %s must not be negative", p, name)
+			}
+		}
+		if r.ErrorRateWarning != nil && r.ErrorRateCritical != nil && *r.ErrorRateWarning > *r.ErrorRateCritical {
+			return fmt.Errorf("%s: error_rate_warning is above error_rate_critical", p)
+		}
+		if r.P99Warning != nil && r.P99Critical != nil && *r.P99Warning > *r.P99Critical {
+			return fmt.Errorf("%s: p99_warning_ms is above p99_critical_ms", p)
+		}
+	}
+	return nil
+}
+
+// servicePolicy is the effective watch configuration for one service.
+type servicePolicy struct {
+	Thresholds
+	MinCalls float64
+	Ignored  bool
+}
+
+// policy resolves the settings for a service. Every matching rule applies,
+// from least to most specific, so an exact name refines a "batch-*" rule,
+// which in turn refines a catch-all "*".
+func (c *Config) policy(service string, base Thresholds) servicePolicy {
+	p := servicePolicy{Thresholds: base}
+	if c == nil {
+		return p
+	}
+	p.MinCalls = c.MinCalls
+	for _, pattern := range c.Ignore {
+		if ok, _ := path.Match(pattern, service); ok {
+			p.Ignored = true
+			return p
+		}
+	}
+
+	var matched []string
+	for pattern := range c.Services {
+		if ok, _ := path.Match(pattern, service); ok {
+			matched = append(matched, pattern)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		si, sj := glob.Specificity(matched[i]), glob.Specificity(matched[j])
+		if si != sj {
+			return si < sj
+		}
+		return matched[i] < matched[j]
+	})
+
+	for _, pattern := range matched {
+		r := c.Services[pattern]
+		if r.Ignore {
+			p.Ignored = true
+		}
+		setFloat(&p.MinCalls, r.MinCalls)
+		setFloat(&p.ErrorRateWarning, r.ErrorRateWarning)
+		setFloat(&p.ErrorRateCritical, r.ErrorRateCritical)
+		setFloat(&p.P99Warning, r.P99Warning)
+		setFloat(&p.P99Critical, r.P99Critical)
+		setFloat(&p.ErrorSpike, r.ErrorSpike)
+		setFloat(&p.MinErrors, r.MinErrors)
+		if r.NewErrors != nil {
+			p.NewErrors = *r.NewErrors
+		}
+	}
+	return p
+}
+
+func setFloat(dst *float64, v *float64) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// summary is a one-line description for the watch header.
+func (c *Config) summary() string {
+	if c == nil {
+		return ""
+	}
+	parts := []string{fmt.Sprintf("%d service rules", len(c.Services))}
+	if len(c.Ignore) > 0 {
+		parts = append(parts, fmt.Sprintf("ignoring %s", strings.Join(c.Ignore, ", ")))
+	}
+	if c.MinCalls > 0 {
+		parts = append(parts, fmt.Sprintf("min_calls=%g", c.MinCalls))
+	}
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,122 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testWatchConfig = `
+min_calls: 20
+ignore: [healthcheck, "*-canary"]
+services:
+  "*":
+    p99_warning_ms: 3000
+  payment-api:
+    error_rate_warning: 0.5
+    error_rate_critical: 2
+  "batch-*":
+    error_rate_warning: 10
+    error_rate_critical: 25
+    min_calls: 100
+  batch-nightly:
+    new_errors: false
+`
+
+func writeWatchConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "watch.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	withTempHome(t)
+
+	cfg, err := LoadConfig("")
+	if err != nil || cfg != nil {
+		t.Errorf("missing default config should yield nil, got %+v / %v", cfg, err)
+	}
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "nope.yaml")); err == nil {
+		t.Error("expected error for missing explicit config")
+	}
+
+	cfg, err = LoadConfig(writeWatchConfig(t, testWatchConfig))
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.MinCalls != 20 || len(cfg.Ignore) != 2 || len(cfg.Services) != 4 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	for _, bad := range []string{
+		"ignore: ['[']",
+		"services:\n  api:\n    error_rate_warning: -1",
+		"services:\n  api:\n    p99_warning_ms: 5000\n    p99_critical_ms: 1000",
+	} {
+		if _, err := LoadConfig(writeWatchConfig(t, bad)); err == nil {
+			t.Errorf("expected validation error for %q", bad)
+		}
+	}
+}
+
+func TestConfigPolicy(t *testing.T) {
+	cfg, err := LoadConfig(writeWatchConfig(t, testWatchConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := DefaultThresholds()
+
+	p := cfg.policy("payment-api", base)
+	if p.ErrorRateWarning != 0.5 || p.ErrorRateCritical != 2 || p.P99Warning != 3000 || p.MinCalls != 20 {
+		t.Errorf("unexpected payment-api policy: %+v", p)
+	}
+
+	p = cfg.policy("batch-nightly", base)
+	if p.ErrorRateWarning != 10 || p.MinCalls != 100 || p.NewErrors {
+		t.Errorf("exact rule should refine the glob rule, got %+v", p)
+	}
+
+	p = cfg.policy("orders", base)
+	if p.ErrorRateWarning != base.ErrorRateWarning || p.P99Warning != 3000 {
+		t.Errorf("unmatched service should only get the catch-all, got %+v", p)
+	}
+
+	if !cfg.policy("checkout-canary", base).Ignored || !cfg.policy("healthcheck", base).Ignored {
+		t.Error("expected ignore patterns to apply")
+	}
+
+	var none *Config
+	if p := none.policy("api", base); p.Thresholds != base || p.Ignored || p.MinCalls != 0 {
+		t.Errorf("nil config should keep the global thresholds, got %+v", p)
+	}
+}
+
+func TestAnalyzeAppliesConfig(t *testing.T) {
+	cfg, err := LoadConfig(writeWatchConfig(t, testWatchConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWithOptions(&mockSignozClient{}, "test", 0, DefaultThresholds(), nil, Options{Config: cfg})
+
+	alerts := w.analyze([]ServiceSnapshot{
+		{Name: "payment-api", Calls: 1000, Errors: 8, ErrorRate: 0.8},
+		{Name: "batch-import", Calls: 500, Errors: 40, ErrorRate: 8},
+		{Name: "orders", Calls: 10, Errors: 5, ErrorRate: 50},
+		{Name: "healthcheck", Calls: 1000, Errors: 1000, ErrorRate: 100},
+	})
+
+	if len(alerts) != 1 {
+		t.Fatalf("expected only the payment-api alert, got %+v", alerts)
+	}
+	a := alerts[0]
+	if a.Service != "payment-api" || a.Level != AlertWarning || a.Threshold != 0.5 {
+		t.Errorf("unexpected alert: %+v", a)
+	}
+	if !strings.Contains(a.Message, "threshold: 0.5%") {
+		t.Errorf("message should show the fractional threshold, got %q", a.Message)
+	}
+}
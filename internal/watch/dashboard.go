@@ -0,0 +1,592 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+)
+
+const (
+	altScreenOn  = "\033[?1049h\033[?25l"
+	altScreenOff = "\033[?25h\033[?1049l"
+	clearLine    = "\033[K"
+
+	sparkPoints   = 20 // points kept per sparkline
+	feedSize      = 50 // alerts kept in the feed
+	detailMinutes = 30 // how far back drill-down views look
+
+	detailTimeout = 15 * time.Second // how long a drill-down query may take
+)
+
+type dashView int
+
+const (
+	viewTable dashView = iota
+	viewLogs
+	viewTraces
+)
+
+type key int
+
+const (
+	keyNone key = iota
+	keyUp
+	keyDown
+	keyEnter
+	keyEsc
+	keyQuit
+	keyRune
+)
+
+type keyPress struct {
+	key key
+	r   rune
+}
+
+// Dashboard is a full-screen, redraw-in-place view of a Watcher. It polls on
+// the watcher's interval and reads single keypresses from in.
+type Dashboard struct {
+	w   *Watcher
+	in  io.Reader
+	out io.Writer
+
+	mu        sync.Mutex
+	snapshots []ServiceSnapshot
+	status    map[string]AlertLevel // worst alert per service in the last poll
+	errSeries map[string][]float64
+	p99Series map[string][]float64
+	feed      []Alert
+	lastPoll  time.Time
+	lastErr   error
+	polling   bool
+	notice    string // result of the last keypress, e.g. a clamped threshold
+
+	paused    bool
+	selected  int
+	view      dashView
+	detail    []string
+	detailFor string // service the detail view shows
+	detailSeq int    // bumped by each drill-down, so a late result is dropped
+	scroll    int
+	width     int
+	height    int
+
+	loaded chan struct{} // a drill-down finished loading
+}
+
+// NewDashboard creates a full-screen dashboard for w.
+func NewDashboard(w *Watcher, in io.Reader, out io.Writer) *Dashboard {
+	return &Dashboard{
+		w:         w,
+		in:        in,
+		out:       out,
+		status:    make(map[string]AlertLevel),
+		errSeries: make(map[string][]float64),
+		p99Series: make(map[string][]float64),
+		width:     100,
+		height:    30,
+		loaded:    make(chan struct{}, 1),
+	}
+}
+
+// Run takes over the terminal until ctx is cancelled or the user quits.
+func (d *Dashboard) Run(ctx context.Context) error {
+	d.w.warmStart(ctx)
+	d.w.bootstrap(ctx)
+
+	if f, ok := d.in.(*os.File); ok && term.IsTerminal(f.Fd()) {
+		state, err := term.MakeRaw(f.Fd())
+		if err != nil {
+			return fmt.Errorf("entering raw mode: %w", err)
+		}
+		defer term.Restore(f.Fd(), state)
+	}
+	fmt.Fprint(d.out, altScreenOn)
+	defer fmt.Fprint(d.out, altScreenOff)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	keys := make(chan keyPress)
+	go readKeys(ctx, d.in, keys)
+
+	polled := make(chan struct{}, 1)
+	d.pollAsync(ctx, polled)
+
+	ticker := time.NewTicker(d.w.interval)
+	defer ticker.Stop()
+	redraw := time.NewTicker(time.Second)
+	defer redraw.Stop()
+
+	for {
+		d.render()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-polled:
+		case <-d.loaded:
+		case <-redraw.C:
+		case <-ticker.C:
+			if !d.isPaused() {
+				d.pollAsync(ctx, polled)
+			}
+		case k, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			switch d.handleKey(ctx, k) {
+			case actionQuit:
+				return nil
+			case actionRefresh:
+				d.pollAsync(ctx, polled)
+			}
+		}
+	}
+}
+
+func (d *Dashboard) isPaused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.paused
+}
+
+// pollAsync runs one poll in the background so slow queries never freeze the
+// UI. Overlapping polls are skipped.
+func (d *Dashboard) pollAsync(ctx context.Context, done chan<- struct{}) {
+	d.mu.Lock()
+	if d.polling {
+		d.mu.Unlock()
+		return
+	}
+	d.polling = true
+	d.mu.Unlock()
+
+	go func() {
+		res, err := d.w.poll(ctx, time.Now())
+		d.apply(res, err)
+		select {
+		case done <- struct{}{}:
+		default:
+		}
+	}()
+}
+
+// apply folds a poll result into the dashboard state.
+func (d *Dashboard) apply(res *pollResult, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.polling = false
+	d.lastErr = err
+	if err != nil {
+		return
+	}
+	if res.SaveErr != nil {
+		d.lastErr = fmt.Errorf("saving baseline: %w", res.SaveErr)
+	}
+	if len(res.HookErrs) > 0 {
+		d.lastErr = fmt.Errorf("alert hook failed: %w", res.HookErrs[0])
+	}
+
+	d.lastPoll = res.At
+	d.snapshots = res.Snapshots
+	d.status = make(map[string]AlertLevel)
+	for _, s := range res.Snapshots {
+		d.errSeries[s.Name] = appendPoint(d.errSeries[s.Name], s.ErrorRate)
+		d.p99Series[s.Name] = appendPoint(d.p99Series[s.Name], s.P99)
+	}
+	for _, a := range res.Alerts {
+		if lvl, ok := d.status[a.Service]; !ok || a.Level > lvl {
+			d.status[a.Service] = a.Level
+		}
+		d.feed = append([]Alert{a}, d.feed...)
+	}
+	if len(d.feed) > feedSize {
+		d.feed = d.feed[:feedSize]
+	}
+	if d.selected >= len(d.snapshots) {
+		d.selected = len(d.snapshots) - 1
+	}
+	if d.selected < 0 {
+		d.selected = 0
+	}
+}
+
+func appendPoint(series []float64, v float64) []float64 {
+	series = append(series, v)
+	if len(series) > sparkPoints {
+		series = series[len(series)-sparkPoints:]
+	}
+	return series
+}
+
+type keyAction int
+
+const (
+	actionNone keyAction = iota
+	actionQuit
+	actionRefresh
+)
+
+// handleKey applies a keypress to the dashboard state and tells Run whether to
+// quit or poll now.
+func (d *Dashboard) handleKey(ctx context.Context, k keyPress) keyAction {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.notice = ""
+
+	if k.key == keyQuit || (k.key == keyRune && k.r == 'q') {
+		return actionQuit
+	}
+
+	if d.view != viewTable {
+		switch {
+		case k.key == keyEsc || (k.key == keyRune && (k.r == 'b' || k.r == 'h')):
+			d.view = viewTable
+			d.detail = nil
+		case k.key == keyUp || (k.key == keyRune && k.r == 'k'):
+			if d.scroll > 0 {
+				d.scroll--
+			}
+		case k.key == keyDown || (k.key == keyRune && k.r == 'j'):
+			if d.scroll < len(d.detail)-1 {
+				d.scroll++
+			}
+		}
+		return actionNone
+	}
+
+	switch k.key {
+	case keyUp:
+		d.moveSelection(-1)
+	case keyDown:
+		d.moveSelection(1)
+	case keyEnter:
+		d.openDetail(ctx, viewLogs)
+	case keyRune:
+		th := d.w.currentThresholds()
+		switch k.r {
+		case 'k':
+			d.moveSelection(-1)
+		case 'j':
+			d.moveSelection(1)
+		case 'l':
+			d.openDetail(ctx, viewLogs)
+		case 't':
+			d.openDetail(ctx, viewTraces)
+		case 'p', ' ':
+			d.paused = !d.paused
+		case 'r':
+			return actionRefresh
+		case ']':
+			th.ErrorRateWarning++
+			if th.ErrorRateWarning > th.ErrorRateCritical {
+				th.ErrorRateWarning = th.ErrorRateCritical
+				d.notice = fmt.Sprintf("error rate warning is capped at critical (%.0f%%)", th.ErrorRateCritical)
+			}
+		case '[':
+			th.ErrorRateWarning = max(0, th.ErrorRateWarning-1)
+		case '}':
+			th.P99Warning += 250
+			if th.P99Warning > th.P99Critical {
+				th.P99Warning = th.P99Critical
+				d.notice = fmt.Sprintf("p99 warning is capped at critical (%.0fms)", th.P99Critical)
+			}
+		case '{':
+			th.P99Warning = max(0, th.P99Warning-250)
+		case '+', '=':
+			th.ErrorSpike += 0.5
+		case '-':
+			th.ErrorSpike = max(1, th.ErrorSpike-0.5)
+		}
+		d.w.setThresholds(th)
+	}
+	return actionNone
+}
+
+func (d *Dashboard) moveSelection(delta int) {
+	d.selected += delta
+	if d.selected >= len(d.snapshots) {
+		d.selected = len(d.snapshots) - 1
+	}
+	if d.selected < 0 {
+		d.selected = 0
+	}
+}
+
+// openDetail switches to the logs or traces of the selected service and
+// loads them in the background, so a slow query never freezes the UI. The
+// caller holds d.mu.
+func (d *Dashboard) openDetail(ctx context.Context, view dashView) {
+	if len(d.snapshots) == 0 {
+		return
+	}
+	service := d.snapshots[d.selected].Name
+	d.view = view
+	d.scroll = 0
+	d.detailFor = service
+	d.detailSeq++
+	seq := d.detailSeq
+	d.detail = []string{"Loading…"}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, detailTimeout)
+		defer cancel()
+		lines := d.queryDetail(ctx, service, view)
+
+		d.mu.Lock()
+		if d.detailSeq == seq && d.view == view {
+			d.detail = lines
+		}
+		d.mu.Unlock()
+		select {
+		case d.loaded <- struct{}{}:
+		default:
+		}
+	}()
+}
+
+// queryDetail fetches recent logs or traces for service as display lines.
+func (d *Dashboard) queryDetail(ctx context.Context, service string, view dashView) []string {
+	var lines []string
+	end := time.Now()
+	start := end.Add(-detailMinutes * time.Minute)
+	if view == viewLogs {
+		res, err := d.w.client.QueryLogsRange(ctx, service, start, end, 100, "")
+		if err != nil {
+			return []string{fmt.Sprintf("Failed to query logs: %v", err)}
+		}
+		for _, l := range res.Logs {
+			lines = append(lines, fmt.Sprintf("%s %-5s %s",
+				l.Timestamp.Format("15:04:05"), l.SeverityText, strings.ReplaceAll(l.Body, "\n", " ")))
+		}
+	} else {
+		res, err := d.w.client.QueryTracesRange(ctx, service, start, end, 100)
+		if err != nil {
+			return []string{fmt.Sprintf("Failed to query traces: %v", err)}
+		}
+		for _, t := range res.Traces {
+			status := "ok"
+			if t.IsError() {
+				status = "ERROR"
+			}
+			lines = append(lines, fmt.Sprintf("%s %8.1fms %-5s %s  %s",
+				t.Timestamp.Format("15:04:05"), t.DurationMs(), status, t.OperationName, t.TraceID))
+		}
+	}
+	if len(lines) == 0 {
+		return []string{fmt.Sprintf("No results in the last %d minutes.", detailMinutes)}
+	}
+	return lines
+}
+
+// render redraws the whole screen in place.
+func (d *Dashboard) render() {
+	if f, ok := d.out.(*os.File); ok {
+		if w, h, err := term.GetSize(f.Fd()); err == nil && w > 0 && h > 0 {
+			d.mu.Lock()
+			d.width, d.height = w, h
+			d.mu.Unlock()
+		}
+	}
+	frame := d.frame()
+	fmt.Fprint(d.out, "\033[H"+strings.ReplaceAll(frame, "\n", clearLine+"\r\n")+clearLine+"\033[J")
+}
+
+// frame builds the screen contents for the current state.
+func (d *Dashboard) frame() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	const (
+		reset = "\033[0m"
+		dim   = "\033[2m"
+		bold  = "\033[1m"
+		cyan  = "\033[36m"
+		rev   = "\033[7m"
+	)
+
+	var lines []string
+	mode := "\033[32mLIVE" + reset
+	if d.paused {
+		mode = "\033[33mPAUSED" + reset
+	}
+	updated := "waiting for first poll"
+	if !d.lastPoll.IsZero() {
+		updated = "updated " + d.lastPoll.Format("15:04:05")
+	}
+	lines = append(lines, fmt.Sprintf("%s%s🔭 Argus Watch%s  %s  %s%s | every %s | %s%s",
+		bold, cyan, reset, mode, dim, d.w.instance, d.w.interval, updated, reset))
+	th := d.w.currentThresholds()
+	lines = append(lines, fmt.Sprintf("%sThresholds: error rate %.0f%%/%.0f%%  p99 %.0fms/%.0fms  spike %.1fx%s",
+		dim, th.ErrorRateWarning, th.ErrorRateCritical, th.P99Warning, th.P99Critical, th.ErrorSpike, reset))
+	if d.notice != "" {
+		lines = append(lines, fmt.Sprintf("\033[33m%s%s", d.notice, reset))
+	}
+	if d.lastErr != nil {
+		lines = append(lines, fmt.Sprintf("\033[31m✗ %v%s", d.lastErr, reset))
+	}
+	rule := dim + strings.Repeat("─", max(0, d.width-1)) + reset
+	lines = append(lines, rule)
+
+	footerLines := 2
+	if d.view != viewTable {
+		lines = append(lines, d.detailLines(d.height-len(lines)-footerLines)...)
+		lines = padTo(lines, d.height-footerLines)
+		lines = append(lines, rule,
+			dim+"↑/↓ scroll  esc back  q quit"+reset)
+		return strings.Join(lines, "\n")
+	}
+
+	feedRows := min(8, max(3, d.height/4))
+	tableRows := d.height - len(lines) - footerLines - feedRows - 3
+	lines = append(lines, fmt.Sprintf("%s  %-28s %9s %7s  %-*s %8s  %-*s %s%s",
+		bold, "SERVICE", "CALLS", "ERR%", sparkPoints, "ERROR RATE", "P99 ms", sparkPoints, "LATENCY", "STATUS", reset))
+
+	first := 0
+	if tableRows > 0 && d.selected >= tableRows {
+		first = d.selected - tableRows + 1
+	}
+	for i := first; i < len(d.snapshots) && i-first < tableRows; i++ {
+		s := d.snapshots[i]
+		status := "\033[32m✓ ok" + reset
+		if lvl, ok := d.status[s.Name]; ok {
+			status = lvl.Color() + lvl.String() + reset
+		}
+		row := fmt.Sprintf("  %-28s %9.0f %7.2f  %-*s %8.0f  %-*s %s",
+			truncate(s.Name, 28), s.Calls, s.ErrorRate,
+			sparkPoints, sparkline(d.errSeries[s.Name], true),
+			s.P99, sparkPoints, sparkline(d.p99Series[s.Name], false), status)
+		if i == d.selected {
+			row = rev + ">" + row[1:] + reset
+		}
+		lines = append(lines, row)
+	}
+	if len(d.snapshots) == 0 {
+		lines = append(lines, dim+"  No services yet."+reset)
+	}
+
+	lines = padTo(lines, d.height-footerLines-feedRows-2)
+	lines = append(lines, rule, fmt.Sprintf("%sAlerts%s %s(%d this session)%s", bold, reset, dim, d.w.alertCount(), reset))
+	for i := 0; i < feedRows && i < len(d.feed); i++ {
+		a := d.feed[i]
+		lines = append(lines, fmt.Sprintf("%s%s %s%s%s %s — %s",
+			dim, a.Timestamp.Format("15:04:05"), a.Level.Color(), a.Level.String(), reset, a.Service, a.Message))
+	}
+	if len(d.feed) == 0 {
+		lines = append(lines, dim+"  No alerts."+reset)
+	}
+
+	lines = padTo(lines, d.height-footerLines)
+	lines = append(lines, rule,
+		dim+"↑/↓ select  enter logs  t traces  p pause  r refresh  [/] err%  {/} p99  -/+ spike  q quit"+reset)
+	return strings.Join(lines, "\n")
+}
+
+func (d *Dashboard) detailLines(rows int) []string {
+	title := "Logs"
+	if d.view == viewTraces {
+		title = "Traces"
+	}
+	lines := []string{fmt.Sprintf("\033[1m%s — %s (last %d min)\033[0m", title, d.detailFor, detailMinutes)}
+	for i := d.scroll; i < len(d.detail) && len(lines) < rows; i++ {
+		lines = append(lines, truncate(d.detail[i], max(10, d.width-1)))
+	}
+	return lines
+}
+
+func padTo(lines []string, n int) []string {
+	for len(lines) < n {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders a series as block characters. When fromZero is set the
+// scale starts at zero, so a flat low error rate stays low instead of being
+// stretched to fill the range.
+func sparkline(series []float64, fromZero bool) string {
+	if len(series) == 0 {
+		return ""
+	}
+	lo, hi := series[0], series[0]
+	for _, v := range series {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+	if fromZero {
+		lo = 0
+	}
+	var sb strings.Builder
+	for _, v := range series {
+		idx := 0
+		if hi > lo {
+			idx = int((v - lo) / (hi - lo) * float64(len(sparkChars)-1))
+		}
+		sb.WriteRune(sparkChars[idx])
+	}
+	return sb.String()
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// readKeys decodes keypresses from a raw-mode terminal until ctx is done or
+// the reader fails, then closes keys.
+func readKeys(ctx context.Context, in io.Reader, keys chan<- keyPress) {
+	defer close(keys)
+	buf := make([]byte, 16)
+	for {
+		n, err := in.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			select {
+			case keys <- k:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func parseKeys(b []byte) []keyPress {
+	var out []keyPress
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case c == 0x1b && i+2 < len(b) && b[i+1] == '[':
+			switch b[i+2] {
+			case 'A':
+				out = append(out, keyPress{key: keyUp})
+			case 'B':
+				out = append(out, keyPress{key: keyDown})
+			}
+			i += 2
+		case c == 0x1b:
+			out = append(out, keyPress{key: keyEsc})
+		case c == 0x03:
+			out = append(out, keyPress{key: keyQuit})
+		case c == '\r' || c == '\n':
+			out = append(out, keyPress{key: keyEnter})
+		case c == 0x7f:
+			out = append(out, keyPress{key: keyEsc})
+		default:
+			out = append(out, keyPress{key: keyRune, r: rune(c)})
+		}
+	}
+	return out
+}
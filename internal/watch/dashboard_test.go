@@ -0,0 +1,218 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]float64{0, 1, 2, 3, 4, 5, 6, 7}, false); got != "▁▂▃▄▅▆▇█" {
+		t.Errorf("unexpected sparkline %q", got)
+	}
+	if got := sparkline([]float64{2, 2, 2}, false); got != "▁▁▁" {
+		t.Errorf("flat series should render low, got %q", got)
+	}
+	// Anchored at zero, a steady 1% error rate reads as "full" rather than flat.
+	if got := sparkline([]float64{1, 1}, true); got != "██" {
+		t.Errorf("unexpected zero-anchored sparkline %q", got)
+	}
+	if got := sparkline(nil, true); got != "" {
+		t.Errorf("expected empty sparkline, got %q", got)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys := parseKeys([]byte("\x1b[A\x1b[Bq\r\x1b\x03"))
+	want := []keyPress{{key: keyUp}, {key: keyDown}, {key: keyRune, r: 'q'}, {key: keyEnter}, {key: keyEsc}, {key: keyQuit}}
+	if len(keys) != len(want) {
+		t.Fatalf("expected %d keys, got %+v", len(want), keys)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("key %d: expected %+v, got %+v", i, want[i], keys[i])
+		}
+	}
+}
+
+func newTestDashboard() *Dashboard {
+	w := New(&mockSignozClient{}, "prod", 30*time.Second, DefaultThresholds(), &bytes.Buffer{})
+	return NewDashboard(w, strings.NewReader(""), &bytes.Buffer{})
+}
+
+func TestDashboardFrame(t *testing.T) {
+	d := newTestDashboard()
+	d.apply(&pollResult{
+		At: time.Now(),
+		Snapshots: []ServiceSnapshot{
+			{Name: "checkout", Calls: 1000, Errors: 80, ErrorRate: 8, P99: 450},
+			{Name: "auth", Calls: 500, ErrorRate: 0, P99: 30},
+		},
+		Alerts: []Alert{{Level: AlertWarning, Service: "checkout", Message: "Error rate 8.0% (threshold: 5%)", Timestamp: time.Now()}},
+	}, nil)
+
+	frame := d.frame()
+	for _, want := range []string{"checkout", "auth", "Error rate 8.0%", "LIVE", "q quit"} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("frame missing %q:\n%s", want, frame)
+		}
+	}
+	if n := strings.Count(frame, "\n") + 1; n != d.height {
+		t.Errorf("expected frame to fill %d rows, got %d", d.height, n)
+	}
+}
+
+func TestDashboardKeys(t *testing.T) {
+	d := newTestDashboard()
+	d.apply(&pollResult{Snapshots: []ServiceSnapshot{{Name: "a"}, {Name: "b"}}}, nil)
+	ctx := context.Background()
+
+	d.handleKey(ctx, keyPress{key: keyDown})
+	d.handleKey(ctx, keyPress{key: keyDown})
+	if d.selected != 1 {
+		t.Errorf("selection should stop at last row, got %d", d.selected)
+	}
+
+	d.handleKey(ctx, keyPress{key: keyRune, r: 'p'})
+	if !d.paused || !strings.Contains(d.frame(), "PAUSED") {
+		t.Error("expected p to pause")
+	}
+
+	d.handleKey(ctx, keyPress{key: keyRune, r: ']'})
+	d.handleKey(ctx, keyPress{key: keyRune, r: '}'})
+	th := d.w.currentThresholds()
+	if th.ErrorRateWarning != 6 || th.P99Warning != 2250 {
+		t.Errorf("expected adjusted thresholds, got %+v", th)
+	}
+
+	if got := d.handleKey(ctx, keyPress{key: keyRune, r: 'r'}); got != actionRefresh {
+		t.Errorf("expected refresh action, got %v", got)
+	}
+	if got := d.handleKey(ctx, keyPress{key: keyRune, r: 'q'}); got != actionQuit {
+		t.Errorf("expected quit action, got %v", got)
+	}
+}
+
+func TestDashboardClampsWarningToCritical(t *testing.T) {
+	d := newTestDashboard()
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		d.handleKey(ctx, keyPress{key: keyRune, r: ']'})
+	}
+	th := d.w.currentThresholds()
+	if th.ErrorRateWarning != th.ErrorRateCritical {
+		t.Errorf("warning should stop at critical, got %.0f/%.0f", th.ErrorRateWarning, th.ErrorRateCritical)
+	}
+	if frame := d.frame(); !strings.Contains(frame, "capped at critical (15%)") {
+		t.Errorf("expected the clamp in the status line, got:\n%s", frame)
+	}
+
+	for i := 0; i < 20; i++ {
+		d.handleKey(ctx, keyPress{key: keyRune, r: '}'})
+	}
+	if th := d.w.currentThresholds(); th.P99Warning != th.P99Critical {
+		t.Errorf("p99 warning should stop at critical, got %.0f/%.0f", th.P99Warning, th.P99Critical)
+	}
+
+	d.handleKey(ctx, keyPress{key: keyRune, r: '['})
+	if strings.Contains(d.frame(), "capped") {
+		t.Error("the notice should clear on the next key")
+	}
+}
+
+func TestDashboardDrillIntoLogs(t *testing.T) {
+	d := newTestDashboard()
+	d.w.client = &logsMock{mockSignozClient: &mockSignozClient{}}
+	d.apply(&pollResult{Snapshots: []ServiceSnapshot{{Name: "checkout"}}}, nil)
+
+	d.handleKey(context.Background(), keyPress{key: keyEnter})
+	<-d.loaded
+	frame := d.frame()
+	if !strings.Contains(frame, "Logs — checkout") || !strings.Contains(frame, "payment declined") {
+		t.Errorf("expected log drill-down, got:\n%s", frame)
+	}
+
+	d.handleKey(context.Background(), keyPress{key: keyEsc})
+	if d.view != viewTable {
+		t.Error("esc should return to the table")
+	}
+}
+
+func TestDashboardDrillDownDoesNotBlock(t *testing.T) {
+	d := newTestDashboard()
+	release := make(chan struct{})
+	d.w.client = &slowLogsMock{mockSignozClient: &mockSignozClient{}, release: release}
+	d.apply(&pollResult{Snapshots: []ServiceSnapshot{{Name: "checkout"}}}, nil)
+
+	done := make(chan struct{})
+	go func() {
+		d.handleKey(context.Background(), keyPress{key: keyEnter})
+		if !strings.Contains(d.frame(), "Loading") {
+			t.Error("expected a loading view while the query runs")
+		}
+		if got := d.handleKey(context.Background(), keyPress{key: keyRune, r: 'q'}); got != actionQuit {
+			t.Errorf("expected quit action, got %v", got)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the dashboard froze while the query ran")
+	}
+
+	close(release)
+	<-d.loaded
+	if !strings.Contains(d.frame(), "payment declined") {
+		t.Errorf("expected the logs once loaded, got:\n%s", d.frame())
+	}
+}
+
+func TestDashboardRunQuits(t *testing.T) {
+	mock := &mockSignozClient{
+		listServicesFunc: func(ctx context.Context) ([]types.Service, error) {
+			return []types.Service{{Name: "api", NumCalls: 100}}, nil
+		},
+	}
+	var out bytes.Buffer
+	w := New(mock, "prod", time.Hour, DefaultThresholds(), &bytes.Buffer{})
+	d := NewDashboard(w, strings.NewReader("q"), &out)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := d.Run(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Error("expected q to quit before the timeout")
+	}
+	if !strings.HasPrefix(out.String(), altScreenOn) || !strings.HasSuffix(out.String(), altScreenOff) {
+		t.Error("expected the alternate screen to be entered and restored")
+	}
+}
+
+// logsMock returns a fixed log line for any service.
+type logsMock struct {
+	*mockSignozClient
+}
+
+func (m *logsMock) QueryLogsRange(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+	return &types.QueryResult{Logs: []types.LogEntry{
+		{Timestamp: end, SeverityText: "ERROR", ServiceName: service, Body: "payment declined"},
+	}}, nil
+}
+
+// slowLogsMock returns logsMock's line once release is closed.
+type slowLogsMock struct {
+	*mockSignozClient
+	release chan struct{}
+}
+
+func (m *slowLogsMock) QueryLogsRange(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+	<-m.release
+	return (&logsMock{}).QueryLogsRange(ctx, service, start, end, limit, severityFilter)
+}
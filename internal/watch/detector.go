@@ -0,0 +1,407 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lbarahona/argus/internal/signoz"
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+// Metric names fed to detectors.
+const (
+	MetricErrorRate = "error_rate" // percent
+	MetricP99       = "p99"        // milliseconds
+)
+
+// metricFloor is the smallest deviation worth alerting on for each metric, so
+// a perfectly flat history (zero spread) doesn't turn every blip into an anomaly.
+var metricFloor = map[string]float64{
+	MetricErrorRate: 0.5, // percentage points
+	MetricP99:       50,  // ms
+}
+
+// Anomaly is an unusual observation flagged by a Detector.
+type Anomaly struct {
+	Detector string
+	Metric   string
+	Value    float64
+	Expected float64
+	Score    float64
+	Reason   string
+}
+
+// Detector flags anomalies in per-service metric series. Observe is called
+// once per tick for every service/metric with the latest value; it returns nil
+// when the value looks normal and learns from the value either way.
+//
+// Detectors only flag increases: a falling error rate or latency is never an
+// alert.
+type Detector interface {
+	Name() string
+	Observe(service, metric string, at time.Time, value float64) *Anomaly
+}
+
+// Bootstrapper is implemented by detectors that can warm up from historical
+// data before the first tick. lookback is the window each live value covers.
+// progress, if not nil, is called as queries complete.
+type Bootstrapper interface {
+	Bootstrap(ctx context.Context, client signoz.SignozQuerier, now time.Time, lookback time.Duration, progress func(done, total int)) error
+}
+
+func seriesKey(service, metric string) string {
+	return service + "\x00" + metric
+}
+
+func formatMetric(metric string, v float64) string {
+	if metric == MetricP99 {
+		return fmt.Sprintf("p99 %.0fms", v)
+	}
+	return fmt.Sprintf("error rate %.1f%%", v)
+}
+
+// ──────────────────────────────────────────────
+// Robust z-score (median / MAD)
+// ──────────────────────────────────────────────
+
+// RobustZ flags values far from the rolling median, measured in MADs. Unlike a
+// mean/stddev z-score it isn't dragged around by the outliers it is looking for.
+type RobustZ struct {
+	Threshold  float64 // modified z-score to alert at (default 3.5)
+	Window     int     // observations kept per series (default 30)
+	MinSamples int     // observations needed before alerting (default 8)
+
+	history map[string][]float64
+}
+
+// NewRobustZ creates a median/MAD detector. A threshold <= 0 uses the default.
+func NewRobustZ(threshold float64) *RobustZ {
+	if threshold <= 0 {
+		threshold = 3.5
+	}
+	return &RobustZ{
+		Threshold:  threshold,
+		Window:     30,
+		MinSamples: 8,
+		history:    make(map[string][]float64),
+	}
+}
+
+func (d *RobustZ) Name() string { return "mad" }
+
+func (d *RobustZ) Observe(service, metric string, at time.Time, value float64) *Anomaly {
+	key := seriesKey(service, metric)
+	h := d.history[key]
+
+	var anomaly *Anomaly
+	if len(h) >= d.MinSamples {
+		med := median(h)
+		dev := make([]float64, len(h))
+		for i, v := range h {
+			dev[i] = math.Abs(v - med)
+		}
+		// 1.4826 makes the MAD a consistent estimator of the standard deviation.
+		// The floor means a deviation must reach metricFloor to score Threshold.
+		scale := math.Max(1.4826*median(dev), metricFloor[metric]/d.Threshold)
+		score := (value - med) / scale
+		if score >= d.Threshold {
+			anomaly = &Anomaly{
+				Detector: d.Name(),
+				Metric:   metric,
+				Value:    value,
+				Expected: med,
+				Score:    score,
+				Reason:   fmt.Sprintf("%s vs median %s (robust z=%.1f)", formatMetric(metric, value), formatMetric(metric, med), score),
+			}
+		}
+	}
+
+	h = append(h, value)
+	if len(h) > d.Window {
+		h = h[len(h)-d.Window:]
+	}
+	d.history[key] = h
+	return anomaly
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// ──────────────────────────────────────────────
+// Seasonal (hour-of-week) baseline
+// ──────────────────────────────────────────────
+
+// running accumulates mean and variance incrementally (Welford's algorithm).
+type running struct {
+	n    int
+	mean float64
+	m2   float64
+}
+
+func (r *running) add(v float64) {
+	r.n++
+	delta := v - r.mean
+	r.mean += delta / float64(r.n)
+	r.m2 += delta * (v - r.mean)
+}
+
+func (r *running) std() float64 {
+	if r.n < 2 {
+		return 0
+	}
+	return math.Sqrt(r.m2 / float64(r.n-1))
+}
+
+const hoursPerWeek = 7 * 24
+
+func hourOfWeek(t time.Time) int {
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// Seasonal compares each value with what is normal for the same hour of the
+// week, so a Monday-morning traffic ramp isn't mistaken for an incident. It
+// bootstraps from Days of history and folds in one sample per completed hour
+// while watching.
+type Seasonal struct {
+	Threshold float64 // z-score against the hour-of-week bucket (default 3)
+	Days      int     // days of history to bootstrap from (default 7)
+
+	buckets map[string]*[hoursPerWeek]running
+	pending map[string]*pendingHour
+}
+
+// pendingHour averages live values for the current hour until it completes.
+type pendingHour struct {
+	hour int
+	sum  float64
+	n    int
+}
+
+// NewSeasonal creates an hour-of-week detector. Zero values use the defaults.
+func NewSeasonal(threshold float64, days int) *Seasonal {
+	if threshold <= 0 {
+		threshold = 3
+	}
+	if days <= 0 {
+		days = 7
+	}
+	return &Seasonal{
+		Threshold: threshold,
+		Days:      days,
+		buckets:   make(map[string]*[hoursPerWeek]running),
+		pending:   make(map[string]*pendingHour),
+	}
+}
+
+func (d *Seasonal) Name() string { return "seasonal" }
+
+func (d *Seasonal) bucket(key string, hour int) *running {
+	b, ok := d.buckets[key]
+	if !ok {
+		b = &[hoursPerWeek]running{}
+		d.buckets[key] = b
+	}
+	return &b[hour]
+}
+
+// bootstrapWorkers bounds the concurrent queries of a seasonal bootstrap.
+const bootstrapWorkers = 8
+
+// Bootstrap samples one value per hour over the last Days days, each covering
+// the same lookback as the live values, and seeds the hour-of-week buckets.
+// Up to bootstrapWorkers hours are queried at once.
+func (d *Seasonal) Bootstrap(ctx context.Context, client signoz.SignozQuerier, now time.Time, lookback time.Duration, progress func(done, total int)) error {
+	hours := d.Days * 24
+	type sample struct {
+		end      time.Time
+		services []types.Service
+		err      error
+	}
+	samples := make([]sample, hours)
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	sem := make(chan struct{}, bootstrapWorkers)
+	for k := 1; k <= hours; k++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			end := now.Truncate(time.Hour).Add(-time.Duration(i+1) * time.Hour).Add(30 * time.Minute)
+			services, err := client.ListServicesRange(ctx, end.Add(-lookback), end)
+			samples[i] = sample{end, services, err}
+			if progress != nil {
+				mu.Lock()
+				done++
+				progress(done, hours)
+				mu.Unlock()
+			}
+		}(k - 1)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var failed int
+	var lastErr error
+	for _, smp := range samples {
+		if smp.err != nil {
+			failed++
+			lastErr = smp.err
+			continue
+		}
+		hour := hourOfWeek(smp.end)
+		for _, s := range smp.services {
+			if s.NumCalls == 0 {
+				continue
+			}
+			rate := float64(s.NumErrors) / float64(s.NumCalls) * 100
+			d.bucket(seriesKey(s.Name, MetricErrorRate), hour).add(rate)
+			if p99 := s.P99Ms(); p99 > 0 {
+				d.bucket(seriesKey(s.Name, MetricP99), hour).add(p99)
+			}
+		}
+	}
+	if failed == hours {
+		return fmt.Errorf("seasonal bootstrap: all %d queries failed: %w", hours, lastErr)
+	}
+	return nil
+}
+
+func (d *Seasonal) Observe(service, metric string, at time.Time, value float64) *Anomaly {
+	key := seriesKey(service, metric)
+	hour := hourOfWeek(at)
+	d.learn(key, hour, value)
+
+	b := d.bucket(key, hour)
+	if b.n == 0 {
+		return nil // nothing known about this hour yet
+	}
+	// With few samples the spread is unreliable, so never trust it to be
+	// tighter than 10% of the expected value or the metric floor.
+	scale := math.Max(b.std(), math.Max(0.1*math.Abs(b.mean), metricFloor[metric]/d.Threshold))
+	score := (value - b.mean) / scale
+	if score < d.Threshold {
+		return nil
+	}
+	return &Anomaly{
+		Detector: d.Name(),
+		Metric:   metric,
+		Value:    value,
+		Expected: b.mean,
+		Score:    score,
+		Reason: fmt.Sprintf("%s vs usual %s for %s %02d:00 (z=%.1f)",
+			formatMetric(metric, value), formatMetric(metric, b.mean), at.Weekday().String()[:3], at.Hour(), score),
+	}
+}
+
+// learn averages live values per hour and commits the hour's mean to its
+// bucket once the hour has passed, so a long session can't swamp the history.
+func (d *Seasonal) learn(key string, hour int, value float64) {
+	p, ok := d.pending[key]
+	if ok && p.hour != hour {
+		d.bucket(key, p.hour).add(p.sum / float64(p.n))
+		ok = false
+	}
+	if !ok {
+		p = &pendingHour{hour: hour}
+		d.pending[key] = p
+	}
+	p.sum += value
+	p.n++
+}
+
+// ──────────────────────────────────────────────
+// CUSUM change-point detection
+// ──────────────────────────────────────────────
+
+// CUSUM accumulates small upward deviations from a learned reference level and
+// alerts once they add up, catching slow drifts that never trip a spike check.
+type CUSUM struct {
+	Threshold float64 // decision interval h, in standard deviations (default 5)
+	Drift     float64 // slack k per observation, in standard deviations (default 0.5)
+	Warmup    int     // observations used to learn the reference level (default 10)
+
+	state map[string]*cusumState
+}
+
+type cusumState struct {
+	ref running
+	sum float64
+}
+
+// NewCUSUM creates a CUSUM detector. Zero values use the defaults.
+func NewCUSUM(threshold, drift float64) *CUSUM {
+	if threshold <= 0 {
+		threshold = 5
+	}
+	if drift <= 0 {
+		drift = 0.5
+	}
+	return &CUSUM{
+		Threshold: threshold,
+		Drift:     drift,
+		Warmup:    10,
+		state:     make(map[string]*cusumState),
+	}
+}
+
+func (d *CUSUM) Name() string { return "cusum" }
+
+func (d *CUSUM) Observe(service, metric string, at time.Time, value float64) *Anomaly {
+	key := seriesKey(service, metric)
+	st, ok := d.state[key]
+	if !ok {
+		st = &cusumState{}
+		d.state[key] = st
+	}
+
+	if st.ref.n < d.Warmup {
+		st.ref.add(value)
+		return nil
+	}
+
+	sigma := math.Max(st.ref.std(), metricFloor[metric]/d.Threshold)
+	st.sum = math.Max(0, st.sum+(value-st.ref.mean)/sigma-d.Drift)
+	if st.sum <= d.Threshold {
+		return nil
+	}
+
+	anomaly := &Anomaly{
+		Detector: d.Name(),
+		Metric:   metric,
+		Value:    value,
+		Expected: st.ref.mean,
+		Score:    st.sum,
+		Reason: fmt.Sprintf("%s has drifted up from %s (cusum=%.1f)",
+			formatMetric(metric, value), formatMetric(metric, st.ref.mean), st.sum),
+	}
+	// Relearn the reference so the new level only alerts once.
+	d.state[key] = &cusumState{}
+	return anomaly
+}
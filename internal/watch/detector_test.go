@@ -0,0 +1,163 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+func TestRobustZIgnoresNoiseFlagsOutlier(t *testing.T) {
+	d := NewRobustZ(0)
+	now := time.Now()
+
+	for _, v := range []float64{1.0, 1.2, 0.9, 1.1, 1.0, 1.3, 0.8, 1.0, 1.1, 0.9} {
+		if a := d.Observe("api", MetricErrorRate, now, v); a != nil {
+			t.Fatalf("unexpected anomaly during normal traffic: %+v", a)
+		}
+	}
+	if a := d.Observe("api", MetricErrorRate, now, 1.4); a != nil {
+		t.Errorf("small deviation should not alert: %+v", a)
+	}
+
+	a := d.Observe("api", MetricErrorRate, now, 8.0)
+	if a == nil {
+		t.Fatal("expected anomaly for 8% error rate")
+	}
+	if a.Detector != "mad" || a.Expected < 0.9 || a.Expected > 1.1 {
+		t.Errorf("unexpected anomaly: %+v", a)
+	}
+}
+
+func TestRobustZFlatHistoryUsesFloor(t *testing.T) {
+	d := NewRobustZ(0)
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		d.Observe("api", MetricP99, now, 200)
+	}
+	// MAD is zero, but 20ms is below the p99 floor.
+	if a := d.Observe("api", MetricP99, now, 220); a != nil {
+		t.Errorf("expected no anomaly below floor, got %+v", a)
+	}
+	if a := d.Observe("api", MetricP99, now, 400); a == nil {
+		t.Error("expected anomaly for 200ms → 400ms")
+	}
+}
+
+func TestSeasonalBootstrapAndObserve(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 15, 0, 0, time.Local) // Monday
+	var mu sync.Mutex
+	calls := 0
+	client := &rangeMock{mockSignozClient: &mockSignozClient{}, fn: func(start, end time.Time) []types.Service {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		// Mondays at 10:00 run hot (10% errors), everything else at 1%.
+		errs := 10
+		if end.Weekday() == time.Monday && end.Hour() == 10 {
+			errs = 100
+		}
+		return []types.Service{{Name: "api", NumCalls: 1000, NumErrors: errs}}
+	}}
+
+	d := NewSeasonal(0, 7)
+	var reported, total int
+	progress := func(done, n int) { reported, total = done, n }
+	if err := d.Bootstrap(context.Background(), client, now, time.Hour, progress); err != nil {
+		t.Fatalf("bootstrap failed: %v", err)
+	}
+	if calls != 7*24 {
+		t.Errorf("expected %d bootstrap queries, got %d", 7*24, calls)
+	}
+	if reported != 7*24 || total != 7*24 {
+		t.Errorf("expected progress to reach %d, got %d/%d", 7*24, reported, total)
+	}
+
+	if a := d.Observe("api", MetricErrorRate, now, 10.5); a != nil {
+		t.Errorf("Monday 10:00 at 10%% is normal, got %+v", a)
+	}
+	tuesday := now.Add(24 * time.Hour)
+	if a := d.Observe("api", MetricErrorRate, tuesday, 10.5); a == nil {
+		t.Error("Tuesday 10:00 at 10% should be anomalous")
+	}
+	if a := d.Observe("unknown", MetricErrorRate, now, 50); a != nil {
+		t.Errorf("no history should mean no alert, got %+v", a)
+	}
+}
+
+func TestSeasonalBootstrapConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	client := &rangeMock{mockSignozClient: &mockSignozClient{}, fn: func(start, end time.Time) []types.Service {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		time.Sleep(2 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return []types.Service{{Name: "api", NumCalls: 1000, NumErrors: 10}}
+	}}
+
+	if err := NewSeasonal(0, 2).Bootstrap(context.Background(), client, time.Now(), time.Hour, nil); err != nil {
+		t.Fatal(err)
+	}
+	if peak < 2 || peak > bootstrapWorkers {
+		t.Errorf("expected between 2 and %d queries at once, got %d", bootstrapWorkers, peak)
+	}
+}
+
+func TestCUSUMDetectsDrift(t *testing.T) {
+	d := NewCUSUM(0, 0)
+	now := time.Now()
+
+	for i := 0; i < 10; i++ {
+		d.Observe("api", MetricErrorRate, now, 1.0+0.1*float64(i%2))
+	}
+
+	// A sustained 1pp rise: never a spike, but it accumulates.
+	var fired int
+	for i := 0; i < 10; i++ {
+		if a := d.Observe("api", MetricErrorRate, now, 2.0); a != nil {
+			fired++
+			if a.Detector != "cusum" {
+				t.Errorf("unexpected detector %q", a.Detector)
+			}
+		}
+	}
+	if fired != 1 {
+		t.Errorf("expected exactly one drift alert, got %d", fired)
+	}
+}
+
+func TestAnalyzeRunsDetectors(t *testing.T) {
+	mock := &mockSignozClient{}
+	th := DefaultThresholds()
+	th.ErrorSpike = 0
+	w := NewWithOptions(mock, "test", 30*time.Second, th, &bytes.Buffer{}, Options{
+		Detectors: []Detector{NewRobustZ(0)},
+	})
+
+	for i := 0; i < 10; i++ {
+		w.analyze([]ServiceSnapshot{{Name: "api", Calls: 1000, Errors: 10, ErrorRate: 1.0}})
+	}
+	alerts := w.analyze([]ServiceSnapshot{{Name: "api", Calls: 1000, Errors: 40, ErrorRate: 4.0}})
+	if len(alerts) != 1 || !strings.Contains(alerts[0].Message, "[mad]") {
+		t.Errorf("expected one mad anomaly, got %+v", alerts)
+	}
+}
+
+// rangeMock answers ListServicesRange from fn.
+type rangeMock struct {
+	*mockSignozClient
+	fn func(start, end time.Time) []types.Service
+}
+
+func (m *rangeMock) ListServicesRange(ctx context.Context, start, end time.Time) ([]types.Service, error) {
+	return m.fn(start, end), nil
+}
@@ -0,0 +1,282 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types delivered to hooks.
+const (
+	EventAlert     = "alert"
+	EventRecovered = "recovered"
+)
+
+// hookTimeout bounds how long a single hook may take, so a hung webhook or
+// script can't stall the watch loop.
+const hookTimeout = 15 * time.Second
+
+// Event is the JSON document handed to --on-alert hooks.
+type Event struct {
+	Type      string    `json:"type"` // "alert" or "recovered"
+	Instance  string    `json:"instance"`
+	Service   string    `json:"service"`
+	Condition string    `json:"condition"`
+	Level     string    `json:"level"` // "info", "warning", "critical"
+	Message   string    `json:"message"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Timestamp time.Time `json:"timestamp"`
+	Since     time.Time `json:"since"` // when the condition first fired
+}
+
+// levelName is the stable, machine-readable name of an alert level.
+func levelName(l AlertLevel) string {
+	switch l {
+	case AlertCritical:
+		return "critical"
+	case AlertWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// Hook delivers watch events somewhere outside the terminal.
+type Hook interface {
+	Send(ctx context.Context, e Event) error
+}
+
+// ParseHook builds a hook from an --on-alert value:
+//
+//	https://...        POST the event as JSON to a webhook
+//	file:<path>        append the event as one JSON line to a file
+//	exec:<command>     run a shell command with the event as JSON on stdin
+//	<command>          same as exec:
+func ParseHook(spec string) (Hook, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "":
+		return nil, fmt.Errorf("empty --on-alert hook")
+	case strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://"):
+		return &WebhookHook{URL: spec, Client: &http.Client{Timeout: hookTimeout}}, nil
+	case strings.HasPrefix(spec, "file:"):
+		path := strings.TrimPrefix(spec, "file:")
+		if path == "" {
+			return nil, fmt.Errorf("file hook needs a path, e.g. file:~/alerts.jsonl")
+		}
+		if strings.HasPrefix(path, "~/") {
+			home, _ := os.UserHomeDir()
+			path = filepath.Join(home, path[2:])
+		}
+		return &FileHook{Path: path}, nil
+	default:
+		return &CommandHook{Command: strings.TrimPrefix(spec, "exec:")}, nil
+	}
+}
+
+// CommandHook runs a shell command with the event as JSON on stdin.
+type CommandHook struct {
+	Command string
+}
+
+func (h *CommandHook) Send(ctx context.Context, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshaling event: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Stdin = bytes.NewReader(data)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("running %q: %w: %s", h.Command, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// WebhookHook POSTs the event as JSON.
+type WebhookHook struct {
+	URL    string
+	Client *http.Client
+}
+
+func (h *WebhookHook) Send(ctx context.Context, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshaling event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook error (status %d): %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// FileHook appends each event as a JSON line.
+type FileHook struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (h *FileHook) Send(ctx context.Context, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshaling event: %w", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(h.Path), 0700); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(h.Path), err)
+	}
+	f, err := os.OpenFile(h.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", h.Path, err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// activeCondition tracks a service+condition that is firing, or that cleared
+// within the cooldown of its last alert.
+type activeCondition struct {
+	alert    Alert
+	since    time.Time
+	lastSent time.Time
+	level    AlertLevel
+	firing   bool
+	notified bool // an alert went out that no "recovered" event has answered
+}
+
+// notifier turns the alerts of each poll into hook events: new conditions are
+// sent immediately, repeats are suppressed until the cooldown has passed (or
+// the level escalates), and conditions that clear produce a "recovered" event.
+// A condition that clears and fires again within the cooldown counts as a
+// repeat, so one flapping around a threshold doesn't alert on every poll.
+type notifier struct {
+	hooks    []Hook
+	cooldown time.Duration
+	instance string
+	active   map[string]*activeCondition
+}
+
+func newNotifier(hooks []Hook, cooldown time.Duration, instance string) *notifier {
+	return &notifier{
+		hooks:    hooks,
+		cooldown: cooldown,
+		instance: instance,
+		active:   make(map[string]*activeCondition),
+	}
+}
+
+// events computes which events a poll's alerts produce and updates the
+// active set. It does not send anything.
+func (n *notifier) events(alerts []Alert, now time.Time) []Event {
+	var events []Event
+	seen := make(map[string]bool)
+
+	for _, a := range alerts {
+		key := a.Service + "\x00" + a.Condition
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		cur, ok := n.active[key]
+		if !ok {
+			cur = &activeCondition{}
+			n.active[key] = cur
+		}
+		if !cur.firing {
+			cur.firing = true
+			cur.since = now
+		}
+		due := !ok || now.Sub(cur.lastSent) >= n.cooldown || a.Level > cur.level
+		cur.alert = a
+		if !due {
+			continue
+		}
+		cur.lastSent = now
+		cur.level = a.Level
+		cur.notified = true
+		events = append(events, n.event(EventAlert, a, cur.since, now))
+	}
+
+	var cleared []string
+	for key := range n.active {
+		if !seen[key] {
+			cleared = append(cleared, key)
+		}
+	}
+	sort.Strings(cleared)
+	for _, key := range cleared {
+		cur := n.active[key]
+		if cur.firing && cur.notified {
+			a := cur.alert
+			a.Message = fmt.Sprintf("Recovered: %s", a.Message)
+			events = append(events, n.event(EventRecovered, a, cur.since, now))
+			cur.notified = false
+		}
+		cur.firing = false
+		// Remember the last alert until its cooldown runs out.
+		if now.Sub(cur.lastSent) >= n.cooldown {
+			delete(n.active, key)
+		}
+	}
+	return events
+}
+
+func (n *notifier) event(typ string, a Alert, since, now time.Time) Event {
+	return Event{
+		Type:      typ,
+		Instance:  n.instance,
+		Service:   a.Service,
+		Condition: a.Condition,
+		Level:     levelName(a.Level),
+		Message:   a.Message,
+		Value:     a.Value,
+		Threshold: a.Threshold,
+		Timestamp: now,
+		Since:     since,
+	}
+}
+
+// notify sends a poll's events to every hook and returns any delivery errors.
+func (n *notifier) notify(ctx context.Context, alerts []Alert, now time.Time) []error {
+	var errs []error
+	for _, e := range n.events(alerts, now) {
+		for _, h := range n.hooks {
+			if err := h.Send(ctx, e); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,204 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+func TestNotifierDeduplicatesAndRecovers(t *testing.T) {
+	n := newNotifier(nil, 10*time.Minute, "prod")
+	start := time.Now()
+	warn := Alert{Level: AlertWarning, Service: "api", Condition: "error_rate", Message: "Error rate 6%"}
+
+	events := n.events([]Alert{warn}, start)
+	if len(events) != 1 || events[0].Type != EventAlert || events[0].Level != "warning" {
+		t.Fatalf("expected one alert event, got %+v", events)
+	}
+
+	if events := n.events([]Alert{warn}, start.Add(time.Minute)); len(events) != 0 {
+		t.Errorf("repeat within cooldown should be suppressed, got %+v", events)
+	}
+
+	crit := warn
+	crit.Level = AlertCritical
+	if events := n.events([]Alert{crit}, start.Add(2*time.Minute)); len(events) != 1 || events[0].Level != "critical" {
+		t.Errorf("escalation should be sent immediately, got %+v", events)
+	}
+
+	if events := n.events([]Alert{crit}, start.Add(13*time.Minute)); len(events) != 1 {
+		t.Errorf("expected a reminder after the cooldown, got %+v", events)
+	}
+
+	events = n.events(nil, start.Add(14*time.Minute))
+	if len(events) != 1 || events[0].Type != EventRecovered {
+		t.Fatalf("expected a recovered event, got %+v", events)
+	}
+	if !events[0].Since.Equal(start) || events[0].Condition != "error_rate" {
+		t.Errorf("recovered event should carry the original condition and start, got %+v", events[0])
+	}
+
+	if events := n.events(nil, start.Add(15*time.Minute)); len(events) != 0 {
+		t.Errorf("recovery should only be sent once, got %+v", events)
+	}
+}
+
+func TestNotifierSuppressesFlapping(t *testing.T) {
+	n := newNotifier(nil, 10*time.Minute, "prod")
+	start := time.Now()
+	warn := Alert{Level: AlertWarning, Service: "api", Condition: "error_rate", Message: "Error rate 6%"}
+
+	if events := n.events([]Alert{warn}, start); len(events) != 1 {
+		t.Fatalf("expected one alert event, got %+v", events)
+	}
+	if events := n.events(nil, start.Add(time.Minute)); len(events) != 1 || events[0].Type != EventRecovered {
+		t.Fatalf("expected a recovered event, got %+v", events)
+	}
+	if events := n.events([]Alert{warn}, start.Add(2*time.Minute)); len(events) != 0 {
+		t.Errorf("firing again within the cooldown should be suppressed, got %+v", events)
+	}
+	if events := n.events(nil, start.Add(3*time.Minute)); len(events) != 0 {
+		t.Errorf("a suppressed alert needs no recovery, got %+v", events)
+	}
+
+	crit := warn
+	crit.Level = AlertCritical
+	if events := n.events([]Alert{crit}, start.Add(4*time.Minute)); len(events) != 1 || events[0].Level != "critical" {
+		t.Errorf("escalation should be sent even within the cooldown, got %+v", events)
+	}
+	n.events(nil, start.Add(5*time.Minute))
+
+	events := n.events([]Alert{warn}, start.Add(15*time.Minute))
+	if len(events) != 1 || events[0].Type != EventAlert {
+		t.Fatalf("expected an alert once the cooldown has passed, got %+v", events)
+	}
+	if !events[0].Since.Equal(start.Add(15 * time.Minute)) {
+		t.Errorf("a condition that fires again should start anew, got %v", events[0].Since)
+	}
+}
+
+func TestParseHook(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"https://hooks.example.com/x", "*watch.WebhookHook"},
+		{"file:/tmp/alerts.jsonl", "*watch.FileHook"},
+		{"exec:notify-send argus", "*watch.CommandHook"},
+		{"./page-me.sh", "*watch.CommandHook"},
+	}
+	for _, tt := range tests {
+		h, err := ParseHook(tt.spec)
+		if err != nil {
+			t.Errorf("ParseHook(%q) error: %v", tt.spec, err)
+			continue
+		}
+		if got := fmt.Sprintf("%T", h); got != tt.want {
+			t.Errorf("ParseHook(%q) = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+	if _, err := ParseHook(""); err == nil {
+		t.Error("expected error for empty hook")
+	}
+}
+
+func TestFileHookAppendsJSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "alerts.jsonl")
+	h := &FileHook{Path: path}
+	for _, typ := range []string{EventAlert, EventRecovered} {
+		if err := h.Send(context.Background(), Event{Type: typ, Service: "api"}); err != nil {
+			t.Fatalf("send failed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", data)
+	}
+	var e Event
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil || e.Type != EventRecovered {
+		t.Errorf("unexpected second line %q (%v)", lines[1], err)
+	}
+}
+
+func TestWebhookHook(t *testing.T) {
+	var got Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	h, _ := ParseHook(srv.URL)
+	if err := h.Send(context.Background(), Event{Type: EventAlert, Service: "api"}); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if got.Service != "api" {
+		t.Errorf("webhook received %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	h, _ = ParseHook(failing.URL)
+	if err := h.Send(context.Background(), Event{}); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("expected status error, got %v", err)
+	}
+}
+
+func TestCommandHookReceivesJSON(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "event.json")
+	h := &CommandHook{Command: "cat > " + out}
+	if err := h.Send(context.Background(), Event{Type: EventAlert, Service: "checkout"}); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	if !strings.Contains(string(data), `"service":"checkout"`) {
+		t.Errorf("command did not receive the event: %q", data)
+	}
+
+	if err := (&CommandHook{Command: "exit 3"}).Send(context.Background(), Event{}); err == nil {
+		t.Error("expected error from failing command")
+	}
+}
+
+func TestPollNotifiesHooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+	errorRate := 20
+	mock := &mockSignozClient{
+		listServicesFunc: func(ctx context.Context) ([]types.Service, error) {
+			return []types.Service{{Name: "api", NumCalls: 100, NumErrors: errorRate}}, nil
+		},
+	}
+	w := NewWithOptions(mock, "prod", 30*time.Second, DefaultThresholds(), &bytes.Buffer{}, Options{
+		Hooks: []Hook{&FileHook{Path: path}},
+	})
+
+	w.tick(context.Background())
+	w.tick(context.Background()) // same condition, suppressed
+	errorRate = 0
+	w.tick(context.Background()) // recovered
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"type":"alert"`) || !strings.Contains(lines[1], `"type":"recovered"`) {
+		t.Errorf("expected alert then recovered, got:\n%s", data)
+	}
+}
@@ -0,0 +1,219 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// FormatNDJSON makes Run emit one JSON object per line instead of colored text.
+const FormatNDJSON = "ndjson"
+
+// The event types below make up the NDJSON stream. Every event has "type" and
+// "timestamp"; field names are part of the output contract and must not change.
+
+type startEvent struct {
+	Type            string     `json:"type"` // "start"
+	Timestamp       time.Time  `json:"timestamp"`
+	Instance        string     `json:"instance"`
+	IntervalSeconds float64    `json:"interval_seconds"`
+	WindowSeconds   float64    `json:"window_seconds"`
+	Detectors       []string   `json:"detectors"`
+	Thresholds      Thresholds `json:"thresholds"`
+}
+
+type snapshotEvent struct {
+	Type      string          `json:"type"` // "snapshot"
+	Timestamp time.Time       `json:"timestamp"`
+	Instance  string          `json:"instance"`
+	Healthy   int             `json:"healthy"`
+	Warning   int             `json:"warning"`
+	Critical  int             `json:"critical"`
+	Services  []serviceStatus `json:"services"`
+}
+
+type serviceStatus struct {
+	Name      string  `json:"name"`
+	Calls     float64 `json:"calls"`
+	Errors    float64 `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	P99       float64 `json:"p99_ms"`
+	Status    string  `json:"status"` // "ok", "warning", "critical"
+}
+
+type alertEvent struct {
+	Type      string    `json:"type"` // "alert"
+	Timestamp time.Time `json:"timestamp"`
+	Instance  string    `json:"instance"`
+	Service   string    `json:"service"`
+	Condition string    `json:"condition"`
+	Level     string    `json:"level"`
+	Message   string    `json:"message"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+}
+
+type errorEvent struct {
+	Type      string    `json:"type"` // "error"
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+}
+
+type noticeEvent struct {
+	Type      string    `json:"type"` // "notice"
+	Timestamp time.Time `json:"timestamp"`
+	Warning   bool      `json:"warning"`
+	Message   string    `json:"message"`
+}
+
+type summaryEvent struct {
+	Type      string                    `json:"type"` // "summary"
+	Timestamp time.Time                 `json:"timestamp"`
+	Instance  string                    `json:"instance"`
+	StartedAt time.Time                 `json:"started_at"`
+	Ticks     int                       `json:"ticks"`
+	Alerts    int                       `json:"alerts"`
+	Services  map[string]serviceSummary `json:"services"`
+}
+
+type serviceSummary struct {
+	Alerts   int    `json:"alerts"`
+	MaxLevel string `json:"max_level"`
+}
+
+// emit writes one event as a JSON line. Encoding these fixed structs cannot fail.
+func (w *Watcher) emit(v interface{}) {
+	data, _ := json.Marshal(v)
+	w.out.Write(append(data, '\n'))
+}
+
+// runNDJSON is Run for --output ndjson: a start event, then per tick a
+// snapshot event followed by one event per alert, and a summary on exit.
+func (w *Watcher) runNDJSON(ctx context.Context) error {
+	started := time.Now()
+	var names []string
+	for _, d := range w.detectors {
+		names = append(names, d.Name())
+	}
+	if names == nil {
+		names = []string{}
+	}
+	w.emit(startEvent{
+		Type:            "start",
+		Timestamp:       started,
+		Instance:        w.instance,
+		IntervalSeconds: w.interval.Seconds(),
+		WindowSeconds:   w.lookback.Seconds(),
+		Detectors:       names,
+		Thresholds:      w.currentThresholds(),
+	})
+
+	w.warmStart(ctx)
+	w.bootstrap(ctx)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	ticks := 0
+	for {
+		w.tickNDJSON(ctx)
+		ticks++
+
+		select {
+		case <-ctx.Done():
+			w.emit(w.summaryEvent(started, ticks))
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Watcher) tickNDJSON(ctx context.Context) {
+	now := time.Now()
+	res, err := w.poll(ctx, now)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.emit(errorEvent{Type: "error", Timestamp: now, Message: "fetching services: " + err.Error()})
+		}
+		return
+	}
+
+	worst := make(map[string]AlertLevel)
+	for _, a := range res.Alerts {
+		if lvl, ok := worst[a.Service]; !ok || a.Level > lvl {
+			worst[a.Service] = a.Level
+		}
+	}
+
+	snap := snapshotEvent{Type: "snapshot", Timestamp: now, Instance: w.instance, Services: []serviceStatus{}}
+	for _, s := range res.Snapshots {
+		status := "ok"
+		if lvl, ok := worst[s.Name]; ok {
+			status = levelName(lvl)
+		}
+		switch status {
+		case "critical":
+			snap.Critical++
+		case "ok":
+			snap.Healthy++
+		default:
+			snap.Warning++
+		}
+		snap.Services = append(snap.Services, serviceStatus{
+			Name:      s.Name,
+			Calls:     s.Calls,
+			Errors:    s.Errors,
+			ErrorRate: s.ErrorRate,
+			P99:       s.P99,
+			Status:    status,
+		})
+	}
+	w.emit(snap)
+
+	for _, a := range res.Alerts {
+		w.emit(alertEvent{
+			Type:      "alert",
+			Timestamp: a.Timestamp,
+			Instance:  w.instance,
+			Service:   a.Service,
+			Condition: a.Condition,
+			Level:     levelName(a.Level),
+			Message:   a.Message,
+			Value:     a.Value,
+			Threshold: a.Threshold,
+		})
+	}
+
+	if res.SaveErr != nil {
+		w.emit(errorEvent{Type: "error", Timestamp: now, Message: "saving baseline: " + res.SaveErr.Error()})
+	}
+	for _, err := range res.HookErrs {
+		w.emit(errorEvent{Type: "error", Timestamp: now, Message: "alert hook: " + err.Error()})
+	}
+}
+
+func (w *Watcher) summaryEvent(started time.Time, ticks int) summaryEvent {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	ev := summaryEvent{
+		Type:      "summary",
+		Timestamp: time.Now(),
+		Instance:  w.instance,
+		StartedAt: started,
+		Ticks:     ticks,
+		Alerts:    len(w.alerts),
+		Services:  make(map[string]serviceSummary),
+	}
+	levels := make(map[string]AlertLevel)
+	for _, a := range w.alerts {
+		sum := ev.Services[a.Service]
+		sum.Alerts++
+		if lvl, ok := levels[a.Service]; !ok || a.Level > lvl {
+			levels[a.Service] = a.Level
+		}
+		sum.MaxLevel = levelName(levels[a.Service])
+		ev.Services[a.Service] = sum
+	}
+	return ev
+}
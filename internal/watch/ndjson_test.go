@@ -0,0 +1,114 @@
+package watch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+func TestRunNDJSON(t *testing.T) {
+	mock := &mockSignozClient{
+		listServicesFunc: func(ctx context.Context) ([]types.Service, error) {
+			return []types.Service{
+				{Name: "api", NumCalls: 100, NumErrors: 20},
+				{Name: "web", NumCalls: 100},
+			}, nil
+		},
+	}
+
+	var buf bytes.Buffer
+	w := NewWithOptions(mock, "prod", time.Hour, DefaultThresholds(), &buf, Options{Format: FormatNDJSON})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := w.Run(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var kinds []string
+	var events []map[string]interface{}
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var ev map[string]interface{}
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			t.Fatalf("line is not JSON: %q", sc.Text())
+		}
+		if _, ok := ev["timestamp"]; !ok {
+			t.Errorf("event without timestamp: %q", sc.Text())
+		}
+		kinds = append(kinds, ev["type"].(string))
+		events = append(events, ev)
+	}
+
+	// warm start backfills (a notice), then one tick and the final summary.
+	want := []string{"start", "notice", "snapshot", "alert", "summary"}
+	if len(kinds) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("expected events %v, got %v", want, kinds)
+		}
+	}
+
+	snap := events[2]
+	if snap["critical"].(float64) != 1 || snap["healthy"].(float64) != 1 {
+		t.Errorf("unexpected snapshot counts: %v", snap)
+	}
+	svc := snap["services"].([]interface{})[0].(map[string]interface{})
+	if svc["name"] != "api" || svc["error_rate"].(float64) != 20 || svc["status"] != "critical" {
+		t.Errorf("unexpected service entry: %v", svc)
+	}
+
+	alert := events[3]
+	if alert["service"] != "api" || alert["condition"] != "error_rate" || alert["level"] != "critical" {
+		t.Errorf("unexpected alert event: %v", alert)
+	}
+
+	summary := events[4]
+	if summary["alerts"].(float64) != 1 || summary["ticks"].(float64) != 1 {
+		t.Errorf("unexpected summary: %v", summary)
+	}
+	if api := summary["services"].(map[string]interface{})["api"].(map[string]interface{}); api["max_level"] != "critical" {
+		t.Errorf("unexpected service summary: %v", api)
+	}
+}
+
+func TestRunNDJSONReportsErrors(t *testing.T) {
+	mock := &failingMock{mockSignozClient: &mockSignozClient{}}
+	var buf bytes.Buffer
+	w := NewWithOptions(mock, "prod", time.Hour, DefaultThresholds(), &buf, Options{Format: FormatNDJSON})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	w.Run(ctx)
+
+	var sawError bool
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var ev struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(sc.Bytes(), &ev)
+		if ev.Type == "error" && ev.Message != "" {
+			sawError = true
+		}
+	}
+	if !sawError {
+		t.Errorf("expected an error event, got:\n%s", buf.String())
+	}
+}
+
+type failingMock struct {
+	*mockSignozClient
+}
+
+func (m *failingMock) ListServicesRange(ctx context.Context, start, end time.Time) ([]types.Service, error) {
+	return nil, context.DeadlineExceeded
+}
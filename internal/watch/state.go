@@ -0,0 +1,152 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// stateMaxAge is how old saved state may be before it is considered stale and
+// the baseline is backfilled from Signoz instead.
+const stateMaxAge = 24 * time.Hour
+
+// backfillSamples is how many historical polls are replayed into an empty baseline.
+const backfillSamples = 5
+
+// State is the part of a Watcher that survives restarts.
+type State struct {
+	Instance string                     `json:"instance"`
+	SavedAt  time.Time                  `json:"saved_at"`
+	Baseline map[string]ServiceSnapshot `json:"baseline"`
+	History  [][]ServiceSnapshot        `json:"history"`
+}
+
+var unsafeKeyChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+func statePath(key string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".argus", "state", "watch-"+unsafeKeyChars.ReplaceAllString(key, "_")+".json")
+}
+
+// LoadState reads the saved watch state for an instance. A missing file is not
+// an error; it returns nil.
+func LoadState(key string) (*State, error) {
+	data, err := os.ReadFile(statePath(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading watch state: %w", err)
+	}
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("parsing watch state: %w", err)
+	}
+	return &st, nil
+}
+
+// SaveState writes the watch state for an instance, replacing it atomically.
+func SaveState(key string, st *State) error {
+	path := statePath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("creating state dir: %w", err)
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling watch state: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("writing watch state: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// ResetState deletes the saved watch state for an instance.
+func ResetState(key string) error {
+	if err := os.Remove(statePath(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing watch state: %w", err)
+	}
+	return nil
+}
+
+// warmStart fills the baseline before the first tick: from disk when a recent
+// saved state exists, otherwise by replaying a few historical polls.
+func (w *Watcher) warmStart(ctx context.Context) {
+	if w.stateKey != "" {
+		st, err := LoadState(w.stateKey)
+		if err != nil {
+			w.notice(true, "Ignoring saved baseline: %v", err)
+		} else if st != nil && time.Since(st.SavedAt) < stateMaxAge && len(st.Baseline) > 0 {
+			w.restore(st)
+			w.notice(false, "Restored baseline for %d services (saved %s ago)",
+				len(st.Baseline), time.Since(st.SavedAt).Round(time.Second))
+			return
+		}
+	}
+
+	if n := w.backfill(ctx, time.Now()); n > 0 {
+		w.notice(false, "Backfilled baseline from %d historical samples", n)
+	}
+}
+
+// backfill replays polls ending at evenly spaced points before now into the
+// baseline and returns how many succeeded.
+func (w *Watcher) backfill(ctx context.Context, now time.Time) int {
+	step := w.interval
+	if step < 5*time.Minute {
+		step = 5 * time.Minute
+	}
+	var n int
+	for k := backfillSamples; k >= 1; k-- {
+		end := now.Add(-time.Duration(k) * step)
+		services, err := w.client.ListServicesRange(ctx, end.Add(-w.lookback), end)
+		if err != nil || len(services) == 0 {
+			continue
+		}
+		w.updateBaseline(w.buildSnapshots(services))
+		n++
+	}
+	return n
+}
+
+func (w *Watcher) restore(st *State) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.baseline = make(map[string]*ServiceSnapshot, len(st.Baseline))
+	for name, s := range st.Baseline {
+		s := s
+		w.baseline[name] = &s
+	}
+	w.history = st.History
+}
+
+func (w *Watcher) snapshotState() *State {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	st := &State{
+		Instance: w.stateKey,
+		SavedAt:  time.Now(),
+		Baseline: make(map[string]ServiceSnapshot, len(w.baseline)),
+		History:  w.history,
+	}
+	for name, s := range w.baseline {
+		st.Baseline[name] = *s
+	}
+	return st
+}
+
+// saveState persists the baseline after every tick so a crash loses at most
+// one interval of learning.
+func (w *Watcher) saveState() error {
+	if w.stateKey == "" {
+		return nil
+	}
+	return SaveState(w.stateKey, w.snapshotState())
+}
@@ -0,0 +1,146 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbarahona/argus/pkg/types"
+)
+
+func withTempHome(t *testing.T) {
+	t.Helper()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { os.Setenv("HOME", origHome) })
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	withTempHome(t)
+
+	st := &State{
+		Instance: "prod/eu",
+		SavedAt:  time.Now(),
+		Baseline: map[string]ServiceSnapshot{"api": {Name: "api", Calls: 100, Errors: 4, ErrorRate: 4}},
+		History:  [][]ServiceSnapshot{{{Name: "api", Calls: 100, Errors: 4}}},
+	}
+	if err := SaveState("prod/eu", st); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if !strings.HasSuffix(statePath("prod/eu"), "watch-prod_eu.json") {
+		t.Errorf("unexpected state path %s", statePath("prod/eu"))
+	}
+
+	loaded, err := LoadState("prod/eu")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if loaded == nil || loaded.Baseline["api"].Errors != 4 || len(loaded.History) != 1 {
+		t.Errorf("unexpected loaded state: %+v", loaded)
+	}
+
+	if err := ResetState("prod/eu"); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	if loaded, _ := LoadState("prod/eu"); loaded != nil {
+		t.Errorf("expected no state after reset, got %+v", loaded)
+	}
+	if err := ResetState("prod/eu"); err != nil {
+		t.Errorf("resetting missing state should not fail: %v", err)
+	}
+}
+
+func TestWarmStartFromDisk(t *testing.T) {
+	withTempHome(t)
+
+	if err := SaveState("prod", &State{
+		SavedAt:  time.Now().Add(-time.Hour),
+		Baseline: map[string]ServiceSnapshot{"api": {Name: "api", Calls: 1000, Errors: 10}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := &mockSignozClient{
+		listServicesFunc: func(ctx context.Context) ([]types.Service, error) {
+			t.Error("should not backfill when saved state is fresh")
+			return nil, nil
+		},
+	}
+	var buf bytes.Buffer
+	w := NewWithOptions(mock, "prod", 30*time.Second, DefaultThresholds(), &buf, Options{StateKey: "prod"})
+	w.warmStart(context.Background())
+
+	if b, ok := w.baseline["api"]; !ok || b.Errors != 10 {
+		t.Errorf("expected restored baseline, got %+v", w.baseline)
+	}
+	if !strings.Contains(buf.String(), "Restored baseline for 1 services") {
+		t.Errorf("expected restore message, got %q", buf.String())
+	}
+
+	// A warm baseline means the very first tick can detect a spike.
+	alerts := w.analyze([]ServiceSnapshot{{Name: "api", Calls: 1000, Errors: 60, ErrorRate: 6}})
+	found := false
+	for _, a := range alerts {
+		if strings.Contains(a.Message, "Error spike") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected spike alert on first tick, got %+v", alerts)
+	}
+}
+
+func TestWarmStartBackfillsWhenStale(t *testing.T) {
+	withTempHome(t)
+
+	if err := SaveState("prod", &State{
+		SavedAt:  time.Now().Add(-48 * time.Hour),
+		Baseline: map[string]ServiceSnapshot{"old": {Name: "old", Errors: 99}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	queries := 0
+	mock := &mockSignozClient{
+		listServicesFunc: func(ctx context.Context) ([]types.Service, error) {
+			queries++
+			return []types.Service{{Name: "api", NumCalls: 1000, NumErrors: 10}}, nil
+		},
+	}
+	var buf bytes.Buffer
+	w := NewWithOptions(mock, "prod", 30*time.Second, DefaultThresholds(), &buf, Options{StateKey: "prod"})
+	w.warmStart(context.Background())
+
+	if queries != backfillSamples {
+		t.Errorf("expected %d backfill queries, got %d", backfillSamples, queries)
+	}
+	if _, ok := w.baseline["old"]; ok {
+		t.Error("stale state should not be restored")
+	}
+	if b, ok := w.baseline["api"]; !ok || b.Errors != 10 {
+		t.Errorf("expected backfilled baseline, got %+v", w.baseline)
+	}
+}
+
+func TestTickPersistsState(t *testing.T) {
+	withTempHome(t)
+
+	mock := &mockSignozClient{
+		listServicesFunc: func(ctx context.Context) ([]types.Service, error) {
+			return []types.Service{{Name: "api", NumCalls: 100, NumErrors: 1}}, nil
+		},
+	}
+	w := NewWithOptions(mock, "prod", 30*time.Second, DefaultThresholds(), &bytes.Buffer{}, Options{StateKey: "prod"})
+	w.tick(context.Background())
+
+	st, err := LoadState("prod")
+	if err != nil || st == nil {
+		t.Fatalf("expected saved state, got %v / %v", st, err)
+	}
+	if st.Baseline["api"].Calls != 100 || len(st.History) != 1 {
+		t.Errorf("unexpected saved state: %+v", st)
+	}
+}
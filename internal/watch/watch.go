@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/lbarahona/argus/internal/signoz"
+	"github.com/lbarahona/argus/internal/stats"
 	"github.com/lbarahona/argus/pkg/types"
 )
 
@@ -48,6 +49,7 @@ func (a AlertLevel) Color() string {
 type Alert struct {
 	Level     AlertLevel
 	Service   string
+	Condition string // what fired, e.g. "error_rate", "p99", "error_spike", "anomaly:mad:p99"
 	Message   string
 	Value     float64
 	Threshold float64
@@ -56,21 +58,23 @@ type Alert struct {
 
 // ServiceSnapshot captures a service's health at a point in time.
 type ServiceSnapshot struct {
-	Name      string
-	Calls     float64
-	Errors    float64
-	ErrorRate float64
-	P99       float64
+	Name      string  `json:"name"`
+	Calls     float64 `json:"calls"`
+	Errors    float64 `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	P99       float64 `json:"p99_ms"`
 }
 
 // Thresholds configures when to fire alerts.
 type Thresholds struct {
-	ErrorRateWarning  float64 // error rate % to trigger warning (default 5)
-	ErrorRateCritical float64 // error rate % to trigger critical (default 15)
-	P99Warning        float64 // p99 latency ms to trigger warning (default 2000)
-	P99Critical       float64 // p99 latency ms to trigger critical (default 5000)
-	ErrorSpike        float64 // multiplier over baseline for error spike (default 3x)
-	NewErrors         bool    // alert on services with new errors (default true)
+	ErrorRateWarning  float64 `json:"error_rate_warning"`  // error rate % to trigger warning (default 5)
+	ErrorRateCritical float64 `json:"error_rate_critical"` // error rate % to trigger critical (default 15)
+	P99Warning        float64 `json:"p99_warning_ms"`      // p99 latency ms to trigger warning (default 2000)
+	P99Critical       float64 `json:"p99_critical_ms"`     // p99 latency ms to trigger critical (default 5000)
+	ErrorSpike        float64 `json:"error_spike"`         // multiplier over baseline for error spike (default 3x, 0 disables)
+	NewErrors         bool    `json:"new_errors"`          // alert on services with new errors (default true)
+	Confidence        float64 `json:"confidence"`          // spikes must be significant at this level (default 0.95)
+	MinErrors         float64 `json:"min_errors"`          // ignore spikes/new errors below this many errors (default 5)
 }
 
 // DefaultThresholds returns sensible defaults.
@@ -82,6 +86,8 @@ func DefaultThresholds() Thresholds {
 		P99Critical:       5000,
 		ErrorSpike:        3.0,
 		NewErrors:         true,
+		Confidence:        0.95,
+		MinErrors:         5,
 	}
 }
 
@@ -93,26 +99,71 @@ type Watcher struct {
 	thresholds Thresholds
 	out        io.Writer
 
+	lookback  time.Duration
+	detectors []Detector
+	stateKey  string
+	notifier  *notifier
+	format    string
+	rules     *Config
+	progress  io.Writer
+
 	mu       sync.RWMutex
 	baseline map[string]*ServiceSnapshot // rolling baseline
 	history  [][]ServiceSnapshot         // last N snapshots for trend
 	alerts   []Alert
 }
 
+// Options holds optional Watcher settings.
+type Options struct {
+	Lookback  time.Duration // window each poll aggregates over (default 6h)
+	Detectors []Detector    // anomaly detectors run on every tick, in addition to thresholds
+	StateKey  string        // persist baseline/history under this key (usually the instance key); "" disables
+	Hooks     []Hook        // notified of new and recovered alerts
+	Cooldown  time.Duration // minimum time between repeats of the same service+condition (default 15m)
+	Format    string        // "text" (default) or "ndjson"
+	Config    *Config       // per-service overrides, ignore lists and traffic floors; nil uses the global thresholds
+	Progress  io.Writer     // receives bootstrap progress, usually os.Stderr; nil hides it
+}
+
 // New creates a new Watcher.
 func New(client signoz.SignozQuerier, instance string, interval time.Duration, thresholds Thresholds, out io.Writer) *Watcher {
-	return &Watcher{
+	return NewWithOptions(client, instance, interval, thresholds, out, Options{})
+}
+
+// NewWithOptions creates a new Watcher with optional settings.
+func NewWithOptions(client signoz.SignozQuerier, instance string, interval time.Duration, thresholds Thresholds, out io.Writer, opts Options) *Watcher {
+	if opts.Lookback <= 0 {
+		opts.Lookback = 6 * time.Hour
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 15 * time.Minute
+	}
+	w := &Watcher{
 		client:     client,
 		instance:   instance,
 		interval:   interval,
 		thresholds: thresholds,
 		out:        out,
+		lookback:   opts.Lookback,
+		detectors:  opts.Detectors,
+		stateKey:   opts.StateKey,
+		format:     opts.Format,
+		rules:      opts.Config,
+		progress:   opts.Progress,
 		baseline:   make(map[string]*ServiceSnapshot),
 	}
+	if len(opts.Hooks) > 0 {
+		w.notifier = newNotifier(opts.Hooks, opts.Cooldown, instance)
+	}
+	return w
 }
 
 // Run starts the watch loop. Blocks until ctx is cancelled.
 func (w *Watcher) Run(ctx context.Context) error {
+	if w.format == FormatNDJSON {
+		return w.runNDJSON(ctx)
+	}
+
 	reset := "\033[0m"
 	dim := "\033[2m"
 	bold := "\033[1m"
@@ -124,8 +175,21 @@ func (w *Watcher) Run(ctx context.Context) error {
 		dim, w.thresholds.ErrorRateWarning, w.thresholds.ErrorRateCritical,
 		w.thresholds.P99Warning, w.thresholds.P99Critical,
 		w.thresholds.ErrorSpike, reset)
+	if len(w.detectors) > 0 {
+		var names []string
+		for _, d := range w.detectors {
+			names = append(names, d.Name())
+		}
+		fmt.Fprintf(w.out, "%sDetectors: %s | Window: %s%s\n", dim, strings.Join(names, ", "), w.lookback, reset)
+	}
+	if w.rules != nil {
+		fmt.Fprintf(w.out, "%sRules: %s%s\n", dim, w.rules.summary(), reset)
+	}
 	fmt.Fprintf(w.out, "%sPress Ctrl+C to stop%s\n\n", dim, reset)
 
+	w.warmStart(ctx)
+	w.bootstrap(ctx)
+
 	ticker := time.NewTicker(w.interval)
 	defer ticker.Stop()
 
@@ -144,6 +208,44 @@ func (w *Watcher) Run(ctx context.Context) error {
 	}
 }
 
+// pollResult is the outcome of one poll of the Signoz instance.
+type pollResult struct {
+	At        time.Time
+	Snapshots []ServiceSnapshot
+	Alerts    []Alert
+	SaveErr   error   // baseline could not be persisted; not fatal
+	HookErrs  []error // alert hooks that failed; not fatal
+}
+
+// poll fetches one round of service data, evaluates it and folds it into the
+// baseline. It is shared by the scrolling and full-screen modes.
+func (w *Watcher) poll(ctx context.Context, now time.Time) (*pollResult, error) {
+	services, err := w.client.ListServicesRange(ctx, now.Add(-w.lookback), now)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := w.buildSnapshots(services)
+	alerts := w.analyze(snapshots)
+
+	w.mu.Lock()
+	w.alerts = append(w.alerts, alerts...)
+	w.mu.Unlock()
+
+	w.updateBaseline(snapshots)
+
+	res := &pollResult{
+		At:        now,
+		Snapshots: snapshots,
+		Alerts:    alerts,
+		SaveErr:   w.saveState(),
+	}
+	if w.notifier != nil {
+		res.HookErrs = w.notifier.notify(ctx, alerts, now)
+	}
+	return res, nil
+}
+
 func (w *Watcher) tick(ctx context.Context) {
 	reset := "\033[0m"
 	dim := "\033[2m"
@@ -153,14 +255,12 @@ func (w *Watcher) tick(ctx context.Context) {
 	now := time.Now()
 	fmt.Fprintf(w.out, "%s── %s ──%s\n", dim, now.Format("15:04:05"), reset)
 
-	services, err := w.client.ListServices(ctx)
+	res, err := w.poll(ctx, now)
 	if err != nil {
 		fmt.Fprintf(w.out, "\033[31m  ✗ Failed to fetch services: %v%s\n", err, reset)
 		return
 	}
-
-	snapshots := w.buildSnapshots(services)
-	alerts := w.analyze(snapshots)
+	snapshots, alerts := res.Snapshots, res.Alerts
 
 	// Print service summary
 	healthyCount := 0
@@ -201,18 +301,18 @@ func (w *Watcher) tick(ctx context.Context) {
 		fmt.Fprintf(w.out, "  %s%s%s %s%s — %s%s\n",
 			color, bold, a.Level.String(), reset,
 			a.Service, a.Message, reset)
-
-		w.mu.Lock()
-		w.alerts = append(w.alerts, a)
-		w.mu.Unlock()
 	}
 
 	if len(alerts) == 0 {
 		fmt.Fprintf(w.out, "  %s✓ All clear%s\n", green, reset)
 	}
 
-	// Update baseline
-	w.updateBaseline(snapshots)
+	if res.SaveErr != nil {
+		fmt.Fprintf(w.out, "\033[33m  ⚠ Could not save baseline: %v%s\n", res.SaveErr, reset)
+	}
+	for _, err := range res.HookErrs {
+		fmt.Fprintf(w.out, "\033[33m  ⚠ Alert hook failed: %v%s\n", err, reset)
+	}
 
 	fmt.Fprintln(w.out)
 }
@@ -224,6 +324,7 @@ func (w *Watcher) buildSnapshots(services []types.Service) []ServiceSnapshot {
 			Name:   svc.Name,
 			Calls:  float64(svc.NumCalls),
 			Errors: float64(svc.NumErrors),
+			P99:    svc.P99Ms(),
 		}
 		if svc.NumCalls > 0 {
 			s.ErrorRate = (float64(svc.NumErrors) / float64(svc.NumCalls)) * 100
@@ -237,52 +338,59 @@ func (w *Watcher) buildSnapshots(services []types.Service) []ServiceSnapshot {
 }
 
 func (w *Watcher) analyze(snapshots []ServiceSnapshot) []Alert {
+	global := w.currentThresholds()
 	var alerts []Alert
 
 	for _, s := range snapshots {
-		// Skip services with no traffic
-		if s.Calls == 0 {
+		// Skip services with no traffic, ignored services and those below
+		// their traffic floor, where a handful of calls makes rates noisy.
+		th := w.rules.policy(s.Name, global)
+		if s.Calls == 0 || th.Ignored || s.Calls < th.MinCalls {
 			continue
 		}
 
 		// Error rate thresholds
-		if s.ErrorRate >= w.thresholds.ErrorRateCritical {
+		if s.ErrorRate >= th.ErrorRateCritical {
 			alerts = append(alerts, Alert{
 				Level:     AlertCritical,
 				Service:   s.Name,
-				Message:   fmt.Sprintf("Error rate %.1f%% (threshold: %.0f%%)", s.ErrorRate, w.thresholds.ErrorRateCritical),
+				Condition: "error_rate",
+				Message:   fmt.Sprintf("Error rate %.1f%% (threshold: %g%%)", s.ErrorRate, th.ErrorRateCritical),
 				Value:     s.ErrorRate,
-				Threshold: w.thresholds.ErrorRateCritical,
+				Threshold: th.ErrorRateCritical,
 				Timestamp: time.Now(),
 			})
-		} else if s.ErrorRate >= w.thresholds.ErrorRateWarning {
+		} else if s.ErrorRate >= th.ErrorRateWarning {
 			alerts = append(alerts, Alert{
 				Level:     AlertWarning,
 				Service:   s.Name,
-				Message:   fmt.Sprintf("Error rate %.1f%% (threshold: %.0f%%)", s.ErrorRate, w.thresholds.ErrorRateWarning),
+				Condition: "error_rate",
+				Message:   fmt.Sprintf("Error rate %.1f%% (threshold: %g%%)", s.ErrorRate, th.ErrorRateWarning),
 				Value:     s.ErrorRate,
-				Threshold: w.thresholds.ErrorRateWarning,
+				Threshold: th.ErrorRateWarning,
 				Timestamp: time.Now(),
 			})
 		}
 
 		// P99 latency thresholds
-		if s.P99 >= w.thresholds.P99Critical {
+		if s.P99 >= th.P99Critical {
 			alerts = append(alerts, Alert{
 				Level:     AlertCritical,
 				Service:   s.Name,
-				Message:   fmt.Sprintf("P99 latency %.0fms (threshold: %.0fms)", s.P99, w.thresholds.P99Critical),
+				Condition: "p99",
+				Message:   fmt.Sprintf("P99 latency %.0fms (threshold: %gms)", s.P99, th.P99Critical),
 				Value:     s.P99,
-				Threshold: w.thresholds.P99Critical,
+				Threshold: th.P99Critical,
 				Timestamp: time.Now(),
 			})
-		} else if s.P99 >= w.thresholds.P99Warning {
+		} else if s.P99 >= th.P99Warning {
 			alerts = append(alerts, Alert{
 				Level:     AlertWarning,
 				Service:   s.Name,
-				Message:   fmt.Sprintf("P99 latency %.0fms (threshold: %.0fms)", s.P99, w.thresholds.P99Warning),
+				Condition: "p99",
+				Message:   fmt.Sprintf("P99 latency %.0fms (threshold: %gms)", s.P99, th.P99Warning),
 				Value:     s.P99,
-				Threshold: w.thresholds.P99Warning,
+				Threshold: th.P99Warning,
 				Timestamp: time.Now(),
 			})
 		}
@@ -292,35 +400,139 @@ func (w *Watcher) analyze(snapshots []ServiceSnapshot) []Alert {
 		baseline, exists := w.baseline[s.Name]
 		w.mu.RUnlock()
 
-		if exists && baseline.Errors > 0 && s.Errors > 0 {
+		if th.ErrorSpike > 0 && exists && baseline.Errors > 0 && s.Errors >= th.MinErrors && s.Errors > 0 {
 			spike := s.Errors / baseline.Errors
-			if spike >= w.thresholds.ErrorSpike {
+			// How surprising is this many errors if the baseline rate still held?
+			p := stats.PoissonUpperTail(int(s.Errors), baseline.Errors)
+			if spike >= th.ErrorSpike && significant(p, th.Confidence) {
 				alerts = append(alerts, Alert{
 					Level:     AlertWarning,
 					Service:   s.Name,
-					Message:   fmt.Sprintf("Error spike %.1fx baseline (%.0f → %.0f errors)", spike, baseline.Errors, s.Errors),
+					Condition: "error_spike",
+					Message:   fmt.Sprintf("Error spike %.1fx baseline (%.0f → %.0f errors, p=%.3g)", spike, baseline.Errors, s.Errors, p),
 					Value:     spike,
-					Threshold: w.thresholds.ErrorSpike,
+					Threshold: th.ErrorSpike,
 					Timestamp: time.Now(),
 				})
 			}
 		}
 
 		// New errors detection
-		if w.thresholds.NewErrors && exists && baseline.Errors == 0 && s.Errors > 0 {
+		if th.NewErrors && exists && baseline.Errors == 0 && s.Errors > 0 && s.Errors >= th.MinErrors {
 			alerts = append(alerts, Alert{
-				Level:   AlertWarning,
-				Service: s.Name,
-				Message: fmt.Sprintf("New errors detected (%.0f errors, was clean)", s.Errors),
-				Value:   s.Errors,
+				Level:     AlertWarning,
+				Service:   s.Name,
+				Condition: "new_errors",
+				Message:   fmt.Sprintf("New errors detected (%.0f errors, was clean)", s.Errors),
+				Value:     s.Errors,
 				Timestamp: time.Now(),
 			})
 		}
+
+		alerts = append(alerts, w.detect(s)...)
+	}
+
+	return alerts
+}
+
+// detect feeds a snapshot's metrics to every configured detector.
+func (w *Watcher) detect(s ServiceSnapshot) []Alert {
+	if len(w.detectors) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	metrics := map[string]float64{MetricErrorRate: s.ErrorRate}
+	if s.P99 > 0 {
+		metrics[MetricP99] = s.P99
 	}
 
+	var alerts []Alert
+	for _, d := range w.detectors {
+		for _, metric := range []string{MetricErrorRate, MetricP99} {
+			value, ok := metrics[metric]
+			if !ok {
+				continue
+			}
+			if a := d.Observe(s.Name, metric, now, value); a != nil {
+				alerts = append(alerts, Alert{
+					Level:     AlertWarning,
+					Service:   s.Name,
+					Condition: "anomaly:" + a.Detector + ":" + a.Metric,
+					Message:   fmt.Sprintf("Anomaly [%s]: %s", a.Detector, a.Reason),
+					Value:     a.Value,
+					Threshold: a.Expected,
+					Timestamp: now,
+				})
+			}
+		}
+	}
 	return alerts
 }
 
+// notice reports a status message that isn't tied to a tick, e.g. how the
+// baseline was warmed up.
+func (w *Watcher) notice(warn bool, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if w.format == FormatNDJSON {
+		w.emit(noticeEvent{Type: "notice", Timestamp: time.Now(), Warning: warn, Message: msg})
+		return
+	}
+	if warn {
+		fmt.Fprintf(w.out, "\033[33m  ⚠ %s\033[0m\n", msg)
+		return
+	}
+	fmt.Fprintf(w.out, "\033[2m  %s\033[0m\n", msg)
+}
+
+// bootstrap warms up detectors that can learn from history. Failures are
+// reported but not fatal: those detectors just start cold.
+func (w *Watcher) bootstrap(ctx context.Context) {
+	for _, d := range w.detectors {
+		b, ok := d.(Bootstrapper)
+		if !ok {
+			continue
+		}
+		w.notice(false, "Bootstrapping %s baseline from history...", d.Name())
+		var progress func(done, total int)
+		if w.progress != nil {
+			name := d.Name()
+			progress = func(done, total int) {
+				fmt.Fprintf(w.progress, "\r  %s baseline: %d/%d hours", name, done, total)
+				if done == total {
+					fmt.Fprintln(w.progress)
+				}
+			}
+		}
+		if err := b.Bootstrap(ctx, w.client, time.Now(), w.lookback, progress); err != nil {
+			w.notice(true, "%s bootstrap failed, starting cold: %v", d.Name(), err)
+		}
+	}
+}
+
+// significant reports whether a p-value clears the given confidence.
+// A confidence of 0 disables the significance check.
+func significant(p, confidence float64) bool {
+	if confidence <= 0 {
+		return true
+	}
+	return p < 1-confidence
+}
+
+// currentThresholds returns a copy of the thresholds, which the dashboard may
+// change while a poll is running.
+func (w *Watcher) currentThresholds() Thresholds {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.thresholds
+}
+
+func (w *Watcher) setThresholds(th Thresholds) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.thresholds = th
+}
+
 func (w *Watcher) updateBaseline(snapshots []ServiceSnapshot) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -350,6 +562,12 @@ func ema(old, new float64, alpha float64) float64 {
 	return alpha*new + (1-alpha)*old
 }
 
+func (w *Watcher) alertCount() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return len(w.alerts)
+}
+
 // Summary returns a human-readable summary of the watch session.
 func (w *Watcher) Summary() string {
 	w.mu.RLock()
@@ -388,4 +606,3 @@ func (w *Watcher) Summary() string {
 
 	return sb.String()
 }
-
@@ -40,6 +40,18 @@ func (m *mockSignozClient) QueryMetrics(ctx context.Context, metricName string,
 	return &types.QueryResult{}, nil
 }
 
+func (m *mockSignozClient) ListServicesRange(ctx context.Context, start, end time.Time) ([]types.Service, error) {
+	return m.ListServices(ctx)
+}
+
+func (m *mockSignozClient) QueryLogsRange(ctx context.Context, service string, start, end time.Time, limit int, severityFilter string) (*types.QueryResult, error) {
+	return &types.QueryResult{}, nil
+}
+
+func (m *mockSignozClient) QueryTracesRange(ctx context.Context, service string, start, end time.Time, limit int) (*types.QueryResult, error) {
+	return &types.QueryResult{}, nil
+}
+
 // ──────────────────────────────────────────────
 // Tests
 // ──────────────────────────────────────────────
@@ -324,3 +336,32 @@ func TestRunCancellation(t *testing.T) {
 		t.Errorf("expected nil error on context cancellation, got %v", err)
 	}
 }
+
+func TestAnalyzeSpikeNotSignificant(t *testing.T) {
+	mock := &mockSignozClient{}
+	w := New(mock, "test", 30*time.Second, DefaultThresholds(), &bytes.Buffer{})
+
+	// 1 → 3 errors is a 3x "spike" but pure noise.
+	w.baseline["api"] = &ServiceSnapshot{Name: "api", Errors: 1}
+	snapshots := []ServiceSnapshot{
+		{Name: "api", Calls: 100, Errors: 3, ErrorRate: 3.0},
+	}
+
+	if alerts := w.analyze(snapshots); len(alerts) != 0 {
+		t.Errorf("expected no alerts for low-count spike, got %+v", alerts)
+	}
+}
+
+func TestAnalyzeNewErrorsBelowMinimum(t *testing.T) {
+	mock := &mockSignozClient{}
+	w := New(mock, "test", 30*time.Second, DefaultThresholds(), &bytes.Buffer{})
+
+	w.baseline["api"] = &ServiceSnapshot{Name: "api", Errors: 0}
+	snapshots := []ServiceSnapshot{
+		{Name: "api", Calls: 1000, Errors: 1, ErrorRate: 0.1},
+	}
+
+	if alerts := w.analyze(snapshots); len(alerts) != 0 {
+		t.Errorf("expected no alert for a single new error, got %+v", alerts)
+	}
+}
@@ -1,6 +1,11 @@
 package types
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Instance represents a configured Signoz instance.
 type Instance struct {
@@ -20,9 +25,182 @@ func (i Instance) GetAPIVersion() string {
 
 // Config represents the application configuration.
 type Config struct {
-	AnthropicKey    string              `yaml:"anthropic_key"`
-	DefaultInstance string              `yaml:"default_instance"`
-	Instances       map[string]Instance `yaml:"instances"`
+	AnthropicKey     string               `yaml:"anthropic_key"`
+	DefaultInstance  string               `yaml:"default_instance"`
+	Instances        map[string]Instance  `yaml:"instances"`
+	DefaultAIProfile string               `yaml:"default_ai_profile,omitempty"`
+	AIProfiles       map[string]AIProfile `yaml:"ai_profiles,omitempty"`
+	Reports          *ReportsConfig       `yaml:"reports,omitempty"`
+	Teams            map[string]Team      `yaml:"teams,omitempty"`     // service ownership catalog, keyed by team name
+	Redaction        *RedactionConfig     `yaml:"redaction,omitempty"` // scrubbing of data sent to AI providers
+	Cache            *CacheConfig         `yaml:"cache,omitempty"`     // reuse of AI answers to identical prompts
+}
+
+// AI providers.
+const (
+	ProviderAnthropic = "anthropic"
+	ProviderOpenAI    = "openai" // any OpenAI-compatible chat completions API
+)
+
+// AIProfile configures the model used for analysis. Zero values use the
+// provider's defaults.
+type AIProfile struct {
+	Provider     string   `yaml:"provider,omitempty"`      // "anthropic" (default) or "openai"
+	APIKey       string   `yaml:"api_key,omitempty"`       // Anthropic profiles default to anthropic_key
+	BaseURL      string   `yaml:"base_url,omitempty"`      // e.g. http://localhost:11434/v1 for Ollama
+	Model        string   `yaml:"model,omitempty"`         // required for OpenAI-compatible servers
+	MaxTokens    int      `yaml:"max_tokens,omitempty"`    // default 4096
+	Temperature  *float64 `yaml:"temperature,omitempty"`   // provider default when unset
+	PromptBudget int      `yaml:"prompt_budget,omitempty"` // estimated prompt tokens, default 12000
+
+	// Redaction overrides the top-level redaction settings for this
+	// profile, e.g. to turn it off for a local model.
+	Redaction *RedactionConfig `yaml:"redaction,omitempty"`
+	// Cache overrides the top-level cache settings for this profile.
+	Cache *CacheConfig `yaml:"cache,omitempty"`
+	// ShowPrompt prints everything sent to the provider (--show-prompt).
+	ShowPrompt bool `yaml:"-"`
+	// CacheDir turns the answer cache on, set by the CLI to ~/.argus/cache;
+	// NoCache (--no-cache) skips lookups but still stores fresh answers.
+	CacheDir string `yaml:"-"`
+	NoCache  bool   `yaml:"-"`
+	// Recording collects every exchange with the provider (--record).
+	Recording *Recording `yaml:"-"`
+}
+
+// CacheConfig controls the AI answer cache.
+type CacheConfig struct {
+	Disabled bool   `yaml:"disabled,omitempty"`
+	TTL      string `yaml:"ttl,omitempty"` // how long answers are reused, e.g. "30m" (default 1h)
+}
+
+// Recording collects the AI exchanges of a session, for session bundles.
+type Recording struct {
+	Exchanges []AIExchange `json:"exchanges"`
+}
+
+// AIExchange is one request to an AI provider and its answer.
+type AIExchange struct {
+	System   string          `json:"system"`
+	Messages json.RawMessage `json:"messages"`           // as sent, after redaction
+	Answer   string          `json:"answer,omitempty"`   // streamed answers
+	Response json.RawMessage `json:"response,omitempty"` // tool-use rounds
+	Cached   bool            `json:"cached,omitempty"`   // served from the answer cache
+}
+
+// RedactionConfig controls how data is scrubbed before it is sent to an AI
+// provider. The built-in detectors are on unless disabled.
+type RedactionConfig struct {
+	Disabled bool         `yaml:"disabled,omitempty"` // send data as is
+	Disable  []string     `yaml:"disable,omitempty"`  // built-in detectors to skip: email, jwt, bearer, api_key, card, ip
+	Rules    []RedactRule `yaml:"rules,omitempty"`
+}
+
+// RedactRule is a user-defined redaction.
+type RedactRule struct {
+	Name        string `yaml:"name"`
+	Pattern     string `yaml:"pattern"`               // Go regexp
+	Replacement string `yaml:"replacement,omitempty"` // default "[NAME]"; may use $1-style groups
+}
+
+// GetProvider returns the provider, defaulting to "anthropic".
+func (p AIProfile) GetProvider() string {
+	if p.Provider == "" {
+		return ProviderAnthropic
+	}
+	return p.Provider
+}
+
+// Ready reports whether the profile can make calls. Anthropic needs an API
+// key; OpenAI-compatible servers need a base URL or key (local servers
+// usually take no key).
+func (p AIProfile) Ready() bool {
+	if p.GetProvider() == ProviderOpenAI {
+		return p.BaseURL != "" || p.APIKey != ""
+	}
+	return p.APIKey != ""
+}
+
+// ResolveAIProfile returns the named AI profile, or the default one when name
+// is empty. Without any profiles configured it is Anthropic with
+// anthropic_key, as before profiles existed.
+func (c *Config) ResolveAIProfile(name string) (AIProfile, error) {
+	if name == "" {
+		name = c.DefaultAIProfile
+	}
+	var p AIProfile
+	if name != "" {
+		var ok bool
+		if p, ok = c.AIProfiles[name]; !ok {
+			return AIProfile{}, fmt.Errorf("AI profile %q not found", name)
+		}
+	}
+	if p.Redaction == nil {
+		p.Redaction = c.Redaction
+	}
+	if p.Cache == nil {
+		p.Cache = c.Cache
+	}
+	switch p.GetProvider() {
+	case ProviderAnthropic:
+		if p.APIKey == "" {
+			p.APIKey = c.AnthropicKey
+		}
+	case ProviderOpenAI:
+		if p.Model == "" {
+			return AIProfile{}, fmt.Errorf("AI profile %q: model is required for OpenAI-compatible providers", name)
+		}
+	default:
+		return AIProfile{}, fmt.Errorf("AI profile %q: unknown provider %q (want anthropic or openai)", name, p.Provider)
+	}
+	return p, nil
+}
+
+// Team is an entry in the service ownership catalog.
+type Team struct {
+	Services   []string `yaml:"services"`             // service names or globs (path.Match syntax)
+	Escalation string   `yaml:"escalation,omitempty"` // who to page: a rotation, channel or address
+	Runbook    string   `yaml:"runbook,omitempty"`    // runbook URL
+}
+
+// ReportsConfig configures scheduled reports (argus report schedule).
+type ReportsConfig struct {
+	OutputDir string           `yaml:"output_dir,omitempty"` // default ~/.argus/reports
+	Keep      int              `yaml:"keep,omitempty"`       // reports kept per schedule (default 30)
+	Schedules []ReportSchedule `yaml:"schedules"`
+	SMTP      *SMTPConfig      `yaml:"smtp,omitempty"`
+	Slack     *SlackConfig     `yaml:"slack,omitempty"`
+}
+
+// ReportSchedule is one recurring report.
+type ReportSchedule struct {
+	Name      string   `yaml:"name"`
+	Cron      string   `yaml:"cron"`               // five-field cron expression or @daily-style macro
+	Timezone  string   `yaml:"timezone,omitempty"` // IANA zone for the cron expression, default local
+	Instance  string   `yaml:"instance,omitempty"` // default instance when empty
+	Format    string   `yaml:"format,omitempty"`   // "html" (default), "json", "markdown" or "terminal"
+	AI        bool     `yaml:"ai,omitempty"`
+	AIProfile string   `yaml:"ai_profile,omitempty"` // default AI profile when empty
+	Team      string   `yaml:"team,omitempty"`       // scope the report to one team's services
+	Deliver   []string `yaml:"deliver,omitempty"`    // "smtp" and/or "slack"
+}
+
+// SMTPConfig delivers reports by email.
+type SMTPConfig struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port,omitempty"` // default 587
+	Username string   `yaml:"username,omitempty"`
+	Password string   `yaml:"password,omitempty"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+// SlackConfig delivers reports to Slack. With a bot token and channel the
+// report file is uploaded; with only a webhook URL a summary is posted.
+type SlackConfig struct {
+	Token      string `yaml:"token,omitempty"`   // bot token with files:write
+	Channel    string `yaml:"channel,omitempty"` // channel ID to upload to
+	WebhookURL string `yaml:"webhook_url,omitempty"`
 }
 
 // HealthStatus represents the health of a Signoz instance.
@@ -62,6 +240,16 @@ func (t TraceEntry) DurationMs() float64 {
 	return float64(t.DurationNano) / 1e6
 }
 
+// IsError reports whether the span failed, in any of the spellings Signoz
+// uses for the OpenTelemetry error status.
+func (t TraceEntry) IsError() bool {
+	switch strings.ToUpper(t.StatusCode) {
+	case "ERROR", "STATUS_CODE_ERROR", "2":
+		return true
+	}
+	return false
+}
+
 // MetricEntry represents a metric data point from Signoz.
 type MetricEntry struct {
 	Timestamp  time.Time         `json:"timestamp"`
@@ -81,8 +269,16 @@ type QueryResult struct {
 
 // Service represents a service discovered in Signoz.
 type Service struct {
-	Name      string  `json:"serviceName"`
-	NumErrors int     `json:"numErrors"`
-	NumCalls  int     `json:"numCalls"`
-	ErrorRate float64 `json:"errorRate,omitempty"`
+	Name        string  `json:"serviceName"`
+	NumErrors   int     `json:"numErrors"`
+	NumCalls    int     `json:"numCalls"`
+	ErrorRate   float64 `json:"errorRate,omitempty"`
+	P99         float64 `json:"p99,omitempty"`         // nanoseconds, as reported by Signoz
+	AvgDuration float64 `json:"avgDuration,omitempty"` // nanoseconds
+	CallRate    float64 `json:"callRate,omitempty"`    // calls per second
+}
+
+// P99Ms returns the p99 latency in milliseconds.
+func (s Service) P99Ms() float64 {
+	return s.P99 / 1e6
 }
@@ -23,9 +23,74 @@ func TestTraceEntryDurationMs(t *testing.T) {
 	}
 }
 
+func TestTraceEntryIsError(t *testing.T) {
+	for code, want := range map[string]bool{
+		"ERROR": true, "STATUS_CODE_ERROR": true, "Error": true, "2": true,
+		"": false, "OK": false, "0": false, "STATUS_CODE_UNSET": false, "STATUS_CODE_OK": false,
+	} {
+		if got := (TraceEntry{StatusCode: code}).IsError(); got != want {
+			t.Errorf("IsError(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
+
 func TestTraceEntryDurationMsSubMs(t *testing.T) {
 	entry := TraceEntry{DurationNano: 500000}
 	if ms := entry.DurationMs(); ms != 0.5 {
 		t.Errorf("expected 0.5ms, got %f", ms)
 	}
 }
+
+func TestResolveAIProfileDefault(t *testing.T) {
+	cfg := &Config{AnthropicKey: "sk-ant"}
+	p, err := cfg.ResolveAIProfile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GetProvider() != ProviderAnthropic || p.APIKey != "sk-ant" || !p.Ready() {
+		t.Errorf("expected Anthropic with anthropic_key, got %+v", p)
+	}
+}
+
+func TestResolveAIProfileNamed(t *testing.T) {
+	cfg := &Config{
+		AnthropicKey:     "sk-ant",
+		DefaultAIProfile: "local",
+		AIProfiles: map[string]AIProfile{
+			"local":  {Provider: ProviderOpenAI, BaseURL: "http://localhost:11434/v1", Model: "llama3.1"},
+			"claude": {Model: "claude-haiku"},
+			"broken": {Provider: ProviderOpenAI, BaseURL: "http://localhost:8080/v1"},
+		},
+	}
+	p, err := cfg.ResolveAIProfile("")
+	if err != nil || p.Model != "llama3.1" || p.APIKey != "" || !p.Ready() {
+		t.Errorf("expected the default local profile, got %+v (%v)", p, err)
+	}
+	p, err = cfg.ResolveAIProfile("claude")
+	if err != nil || p.APIKey != "sk-ant" || p.Model != "claude-haiku" {
+		t.Errorf("Anthropic profiles should inherit anthropic_key, got %+v (%v)", p, err)
+	}
+	if _, err := cfg.ResolveAIProfile("broken"); err == nil {
+		t.Error("expected an error for an OpenAI-compatible profile without a model")
+	}
+	if _, err := cfg.ResolveAIProfile("missing"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
+
+func TestResolveAIProfileRedaction(t *testing.T) {
+	shared := &RedactionConfig{Rules: []RedactRule{{Name: "host", Pattern: `\.corp\b`}}}
+	cfg := &Config{
+		AnthropicKey: "sk-ant",
+		Redaction:    shared,
+		AIProfiles: map[string]AIProfile{
+			"local": {Provider: ProviderOpenAI, BaseURL: "http://localhost:11434/v1", Model: "llama3.1", Redaction: &RedactionConfig{Disabled: true}},
+		},
+	}
+	if p, _ := cfg.ResolveAIProfile(""); p.Redaction != shared {
+		t.Errorf("expected the top-level redaction settings, got %+v", p.Redaction)
+	}
+	if p, _ := cfg.ResolveAIProfile("local"); p.Redaction == nil || !p.Redaction.Disabled {
+		t.Errorf("a profile's own redaction settings should win, got %+v", p.Redaction)
+	}
+}